@@ -5,7 +5,11 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
+	"time"
 
+	"github.com/aman-apptile/bob/pkg/android"
+	"github.com/aman-apptile/bob/pkg/trace"
 	"github.com/spf13/cobra"
 )
 
@@ -15,13 +19,107 @@ var androidCmd = &cobra.Command{
 	Short: "This command will build the Android applications for Apptile's react-native applications",
 	// Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Building Android application...")
+		if listVariants, _ := cmd.Flags().GetBool("list-variants"); listVariants {
+			gradleConfig, err := android.LoadGradleConfig(projectDir)
+			cobra.CheckErr(err)
+			for _, variant := range gradleConfig.Variants() {
+				fmt.Println(variant.Name())
+			}
+			return
+		}
+
+		err := runForApps(cmd, func() error {
+			env, err := loadBuildEnv()
+			if err != nil {
+				return err
+			}
+			return buildAndroid(cmd, env)
+		})
+		checkErr(cmd, err)
 	},
 }
 
+// buildAndroid builds the Android variant selected by the flags of cmd with the given environment and collects its artifacts.
+func buildAndroid(cmd *cobra.Command, env []string) error {
+	defer trace.Start("android").End()
+	beginBuild("android")
+	gradleConfig, err := android.LoadGradleConfig(projectDir)
+	if err != nil {
+		return err
+	}
+
+	flavor, _ := cmd.Flags().GetString("flavor")
+	buildType, _ := cmd.Flags().GetString("type")
+	format, _ := cmd.Flags().GetString("format")
+
+	variant, err := gradleConfig.ResolveVariant(flavor, buildType)
+	if err != nil {
+		return err
+	}
+	task, err := variant.Task(format)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Building Android application (%s, %s)...\n", variant.Name(), format)
+	currentBuild.variant = variant.Name()
+	started := time.Now()
+
+	gradleArgs := []string{task}
+	var signingEnv []string
+	options := map[string]string{"variant": variant.Name(), "format": format}
+	var inputFiles []string
+	if config := androidSigningConfig(); variant.BuildType != "debug" && !config.Empty() {
+		// A half configured keystore would otherwise silently produce an unsigned build.
+		if err := config.Validate(); err != nil {
+			return fmt.Errorf("%v (set it in bob.yaml or through the BOB_ANDROID_* environment variables)", err)
+		}
+		fmt.Printf("Signing with %s\n", config)
+		properties, propertiesEnv, err := config.GradleProperties()
+		if err != nil {
+			return err
+		}
+		gradleArgs = append(gradleArgs, properties...)
+		signingEnv = propertiesEnv
+		options["keyAlias"] = config.KeyAlias
+		inputFiles = append(inputFiles, config.StoreFile)
+	}
+
+	jdk, jdkEnv := projectJDK()
+	fmt.Printf("Using %s\n", jdk)
+
+	key := buildCacheKey("android", env, map[string]string{"jdk": jdk.Version}, options, inputFiles)
+	if restoreArtifacts("android", key) {
+		return nil
+	}
+
+	notifyBuildStart()
+	gradleEnv := append(append([]string{}, env...), jdkEnv...)
+	gradleEnv = append(gradleEnv, signingEnv...)
+	gradle := trace.Start("gradle " + task)
+	err = android.RunGradle(filepath.Join(projectDir, "android"), gradleEnv, gradleArgs...)
+	gradle.End()
+	if err != nil {
+		return err
+	}
+
+	dir, err := collectArtifacts("android", started)
+	if err != nil {
+		return err
+	}
+	storeArtifacts(key, dir)
+	notifyBuildSuccess(dir)
+	return nil
+}
+
 func init() {
 	buildCmd.AddCommand(androidCmd)
 
+	androidCmd.Flags().String("flavor", "", "product flavor to build, comma separated when the project has several flavor dimensions")
+	androidCmd.Flags().String("type", "release", "build type to build")
+	androidCmd.Flags().String("format", "apk", "output format, apk or aab")
+	androidCmd.Flags().Bool("list-variants", false, "list the variants that can be built and exit")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -0,0 +1,167 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aman-apptile/bob/pkg/android"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// androidAvdCmd represents the android avd command
+var androidAvdCmd = &cobra.Command{
+	Use:   "avd",
+	Short: "This command manages the Android Virtual Devices used to run the app on an emulator",
+	// Long:  ``,
+}
+
+// androidAvdCreateCmd represents the android avd create command
+var androidAvdCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "This command creates an AVD from the system image set in bob.yaml, installing the image when needed",
+	// Long:  ``,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config := avdConfig(cmd, args)
+		force, _ := cmd.Flags().GetBool("force")
+
+		fmt.Printf("Creating AVD %s from %s...\n", config.Name, config.SystemImage)
+		cobra.CheckErr(androidSDK().CreateAVD(config, force))
+		fmt.Printf("AVD %s created, start it with bob android avd start %s\n", config.Name, config.Name)
+	},
+}
+
+// androidAvdListCmd represents the android avd list command
+var androidAvdListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "This command lists the AVDs and the emulators that are running",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		avds, err := androidSDK().ListAVDs()
+		cobra.CheckErr(err)
+
+		if len(avds) == 0 {
+			fmt.Println("No AVDs found, create one with bob android avd create.")
+			return
+		}
+		for _, avd := range avds {
+			state := "stopped"
+			if avd.Serial != "" {
+				state = "running as " + avd.Serial
+			}
+			fmt.Printf("%-30s %s\n", avd.Name, state)
+		}
+	},
+}
+
+// androidAvdStartCmd represents the android avd start command
+var androidAvdStartCmd = &cobra.Command{
+	Use:   "start [name]",
+	Short: "This command starts the emulator of an AVD and waits until Android has booted",
+	// Long:  ``,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := avdConfig(cmd, args).Name
+		options := android.StartOptions{}
+		options.Headless, _ = cmd.Flags().GetBool("headless")
+		options.ColdBoot, _ = cmd.Flags().GetBool("cold-boot")
+		options.Timeout, _ = cmd.Flags().GetDuration("timeout")
+
+		fmt.Printf("Starting %s...\n", name)
+		serial, err := androidSDK().StartAVD(name, options)
+		cobra.CheckErr(err)
+		fmt.Printf("%s has booted (%s)\n", name, serial)
+	},
+}
+
+// androidAvdStopCmd represents the android avd stop command
+var androidAvdStopCmd = &cobra.Command{
+	Use:   "stop [name]",
+	Short: "This command shuts down the emulator of an AVD",
+	// Long:  ``,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := avdConfig(cmd, args).Name
+		cobra.CheckErr(androidSDK().StopAVD(name))
+		fmt.Printf("%s stopped\n", name)
+	},
+}
+
+// androidAvdDeleteCmd represents the android avd delete command
+var androidAvdDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "This command deletes an AVD, stopping its emulator first",
+	// Long:  ``,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		name := avdConfig(cmd, args).Name
+		cobra.CheckErr(androidSDK().DeleteAVD(name))
+		fmt.Printf("%s deleted\n", name)
+	},
+}
+
+// avdConfig reads the AVD settings from android.avd in bob.yaml, overridden by the name argument and the
+// --image and --device flags when the command has them. The name defaults to one derived from the system image.
+func avdConfig(cmd *cobra.Command, args []string) android.AVDConfig {
+	config := android.AVDConfig{
+		Name:        viper.GetString("android.avd.name"),
+		SystemImage: viper.GetString("android.avd.image"),
+		Device:      viper.GetString("android.avd.device"),
+	}
+	if len(args) > 0 {
+		config.Name = args[0]
+	}
+	if image, _ := cmd.Flags().GetString("image"); image != "" {
+		config.SystemImage = image
+	}
+	if device, _ := cmd.Flags().GetString("device"); device != "" {
+		config.Device = device
+	}
+	if config.Name == "" {
+		config.Name = android.DefaultAVDName(config.SystemImage)
+	}
+	if config.Name == "" {
+		cobra.CheckErr(fmt.Errorf("no AVD name given, pass one or set android.avd.name or android.avd.image in bob.yaml"))
+	}
+
+	return config
+}
+
+// androidSDK returns the Android SDK of the machine, with its tools running on the project's JDK, or on the
+// newest installed JDK outside of a project.
+func androidSDK() *android.SDK {
+	homeDir, err := os.UserHomeDir()
+	cobra.CheckErr(err)
+	root, err := android.FindSDKRoot(homeDir)
+	cobra.CheckErr(err)
+
+	var env []string
+	jdks := android.FindJDKs(homeDir)
+	if jdk, err := android.SelectJDK(projectDir, jdks); err == nil {
+		env = jdk.Env()
+	} else if len(jdks) > 0 {
+		env = jdks[0].Env()
+	}
+
+	return android.NewSDK(root, env)
+}
+
+func init() {
+	androidToolsCmd.AddCommand(androidAvdCmd)
+	androidAvdCmd.AddCommand(androidAvdCreateCmd)
+	androidAvdCmd.AddCommand(androidAvdListCmd)
+	androidAvdCmd.AddCommand(androidAvdStartCmd)
+	androidAvdCmd.AddCommand(androidAvdStopCmd)
+	androidAvdCmd.AddCommand(androidAvdDeleteCmd)
+
+	androidAvdCreateCmd.Flags().String("image", "", "system image package, e.g. system-images;android-34;google_apis;arm64-v8a (default android.avd.image)")
+	androidAvdCreateCmd.Flags().String("device", "", "hardware profile from avdmanager list device, e.g. pixel_6 (default android.avd.device)")
+	androidAvdCreateCmd.Flags().Bool("force", false, "replace an existing AVD of the same name")
+	androidAvdStartCmd.Flags().Bool("headless", false, "run the emulator without a window, e.g. on CI")
+	androidAvdStartCmd.Flags().Bool("cold-boot", false, "boot from scratch instead of the quick boot snapshot")
+	androidAvdStartCmd.Flags().Duration("timeout", android.DefaultBootTimeout, "how long to wait for the emulator to boot")
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aman-apptile/bob/pkg/android"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// androidToolsCmd represents the android command
+var androidToolsCmd = &cobra.Command{
+	Use:   "android",
+	Short: "This command groups the Android tooling for Apptile's react-native applications",
+	// Long:  ``,
+}
+
+// androidSigningCmd represents the android signing command
+var androidSigningCmd = &cobra.Command{
+	Use:   "signing",
+	Short: "This command manages the keystore used to sign Android release builds",
+	// Long:  ``,
+}
+
+// androidSigningInitCmd represents the android signing init command
+var androidSigningInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "This command generates a new release keystore from the signing configuration",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		config := androidSigningConfig()
+		dname, _ := cmd.Flags().GetString("dname")
+		validity, _ := cmd.Flags().GetInt("validity")
+
+		fmt.Printf("Generating keystore (%s)...\n", config)
+		err := android.GenerateKeystore(config, dname, validity)
+		cobra.CheckErr(err)
+
+		fmt.Println("Keystore generated. Keep it and its passwords safe, a lost keystore cannot be recovered.")
+	},
+}
+
+// androidSigningVerifyCmd represents the android signing verify command
+var androidSigningVerifyCmd = &cobra.Command{
+	Use:   "verify <apk|aab>",
+	Short: "This command prints the signing certificate of a built APK or AAB and compares it with the keystore",
+	// Long:  ``,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cert, err := android.InspectCertificate(args[0])
+		cobra.CheckErr(err)
+
+		fmt.Printf("Owner:  %s\n", cert.Owner)
+		fmt.Printf("SHA1:   %s\n", cert.SHA1)
+		fmt.Printf("SHA256: %s\n", cert.SHA256)
+
+		config := androidSigningConfig()
+		if config.Validate() != nil {
+			return
+		}
+
+		expected, err := android.KeystoreCertificate(config)
+		cobra.CheckErr(err)
+		if expected.SHA256 != cert.SHA256 {
+			cobra.CheckErr(fmt.Errorf("%s is not signed with the key %q from %s", args[0], config.KeyAlias, config.StoreFile))
+		}
+
+		fmt.Printf("%s is signed with the key %q from %s.\n", args[0], config.KeyAlias, config.StoreFile)
+	},
+}
+
+// androidSigningConfig reads the signing configuration from bob.yaml and the environment.
+// A white-label app being built can override it with the signing references in its config.
+func androidSigningConfig() android.SigningConfig {
+	storeFile := viper.GetString("android.signing.storeFile")
+	if storeFile != "" && !filepath.IsAbs(storeFile) {
+		storeFile = filepath.Join(projectDir, storeFile)
+	}
+
+	config := android.SigningConfig{
+		StoreFile:     storeFile,
+		StorePassword: viper.GetString("android.signing.storePassword"),
+		KeyAlias:      viper.GetString("android.signing.keyAlias"),
+		KeyPassword:   viper.GetString("android.signing.keyPassword"),
+	}
+
+	if currentApp != nil {
+		signing := currentApp.Signing.Android
+		if signing.StoreFile != "" {
+			config.StoreFile = currentApp.Asset(signing.StoreFile)
+		}
+		if signing.KeyAlias != "" {
+			config.KeyAlias = signing.KeyAlias
+		}
+		if signing.StorePasswordEnv != "" {
+			config.StorePassword = os.Getenv(signing.StorePasswordEnv)
+		}
+		if signing.KeyPasswordEnv != "" {
+			config.KeyPassword = os.Getenv(signing.KeyPasswordEnv)
+		}
+	}
+
+	return config
+}
+
+func init() {
+	rootCmd.AddCommand(androidToolsCmd)
+	androidToolsCmd.AddCommand(androidSigningCmd)
+	androidSigningCmd.AddCommand(androidSigningInitCmd)
+	androidSigningCmd.AddCommand(androidSigningVerifyCmd)
+
+	// Secrets are usually provided by CI through the environment rather than bob.yaml.
+	viper.BindEnv("android.signing.storeFile", "BOB_ANDROID_STORE_FILE")
+	viper.BindEnv("android.signing.storePassword", "BOB_ANDROID_STORE_PASSWORD")
+	viper.BindEnv("android.signing.keyAlias", "BOB_ANDROID_KEY_ALIAS")
+	viper.BindEnv("android.signing.keyPassword", "BOB_ANDROID_KEY_PASSWORD")
+
+	androidSigningInitCmd.Flags().String("dname", "CN=Apptile", "distinguished name of the generated certificate")
+	androidSigningInitCmd.Flags().Int("validity", 10000, "validity of the generated key in days")
+}
@@ -0,0 +1,74 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/aman-apptile/bob/pkg/assets"
+	"github.com/spf13/cobra"
+)
+
+// assetsCmd represents the assets command
+var assetsCmd = &cobra.Command{
+	Use:   "assets",
+	Short: "This command generates app icons and splash screens for Android and iOS",
+	// Long:  ``,
+}
+
+// assetsIconsCmd represents the assets icons command
+var assetsIconsCmd = &cobra.Command{
+	Use:   "icons",
+	Short: "This command generates launcher icons at every density and the iOS AppIcon set from one source image",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		source, _ := cmd.Flags().GetString("source")
+		background, err := backgroundFlag(cmd)
+		cobra.CheckErr(err)
+
+		written, err := assets.GenerateIcons(projectDir, source, assets.IconOptions{Background: background})
+		printWritten(written)
+		cobra.CheckErr(err)
+	},
+}
+
+// assetsSplashCmd represents the assets splash command
+var assetsSplashCmd = &cobra.Command{
+	Use:   "splash",
+	Short: "This command generates splash screen logos and backgrounds from one source image",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		source, _ := cmd.Flags().GetString("source")
+		size, _ := cmd.Flags().GetInt("size")
+		background, err := backgroundFlag(cmd)
+		cobra.CheckErr(err)
+
+		written, err := assets.GenerateSplash(projectDir, source, assets.SplashOptions{Background: background, LogoSize: size})
+		printWritten(written)
+		cobra.CheckErr(err)
+	},
+}
+
+func backgroundFlag(cmd *cobra.Command) (color.NRGBA, error) {
+	background, _ := cmd.Flags().GetString("background")
+	return assets.ParseHexColor(background)
+}
+
+func printWritten(files []string) {
+	for _, file := range files {
+		fmt.Printf("  wrote %s\n", relativePath(file))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(assetsCmd)
+	assetsCmd.AddCommand(assetsIconsCmd)
+	assetsCmd.AddCommand(assetsSplashCmd)
+
+	assetsCmd.PersistentFlags().String("source", "", "source image, a square PNG of at least 1024x1024 for icons")
+	assetsCmd.PersistentFlags().String("background", "#FFFFFF", "background color as #RRGGBB")
+	assetsCmd.MarkPersistentFlagRequired("source")
+	assetsSplashCmd.Flags().Int("size", assets.DefaultSplashLogoSize, "logo width in dp")
+}
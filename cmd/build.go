@@ -4,24 +4,442 @@ Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
 package cmd
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/aman-apptile/bob/pkg/artifacts"
+	"github.com/aman-apptile/bob/pkg/cache"
+	"github.com/aman-apptile/bob/pkg/dotenv"
+	"github.com/aman-apptile/bob/pkg/trace"
+	"github.com/aman-apptile/bob/pkg/utils"
+	"github.com/aman-apptile/bob/pkg/version"
+	"github.com/aman-apptile/bob/pkg/whitelabel"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
+var distDir string
+
+// envName selects the .env.<name> file a build reads its configuration from.
+var envName string
+
+// artifactsDir overrides where collectArtifacts writes to while a white-label app is built in a working copy.
+var artifactsDir string
+
+// currentApp is the white-label app being built, if any.
+var currentApp *whitelabel.App
+
+// sourceDir is the git checkout a build comes from when projectDir is a working copy of it, which has no git data.
+var sourceDir string
+
+// noCache disables restoring and storing build artifacts in the build cache.
+var noCache bool
+
+// cacheLockfiles are the lockfiles that are part of every build key.
+var cacheLockfiles = []string{"package-lock.json", "yarn.lock", "pnpm-lock.yaml", "Gemfile.lock", "ios/Gemfile.lock", "ios/Podfile.lock"}
+
 // buildCmd represents the build command
 var buildCmd = &cobra.Command{
 	Use:   "build",
 	Short: "This command will build the Android and iOS applications for Apptile's react-native applications",
 	// Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("build called")
+		platforms, _ := cmd.Flags().GetStringSlice("platforms")
+		err := runForApps(cmd, func() error {
+			env, err := loadBuildEnv()
+			if err != nil {
+				return err
+			}
+			for _, platform := range platforms {
+				switch platform {
+				case "android":
+					err = buildAndroid(androidCmd, env)
+				case "ios":
+					err = buildIos(iosCmd, env)
+				default:
+					err = fmt.Errorf("unknown platform %q, expected android or ios", platform)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		checkErr(cmd, err)
 	},
 }
 
+// runForApps runs build once per app passed with --app or --apps, each time in a working copy of the project
+// with the app's config applied. Without those flags, build runs once on the project itself.
+// The JavaScript dependencies are installed first, unless --skip-deps is set.
+// The first failing build stops the run. Its working copy is removed like the others, unless --keep-workdir is set.
+// Every failure, from installing the dependencies to collecting the artifacts, is posted by notifyBuildFailure.
+func runForApps(cmd *cobra.Command, build func() error) error {
+	// A broken webhook config is reported up front rather than when the first event is due.
+	if _, err := buildNotifier(); err != nil {
+		return err
+	}
+	started := time.Now()
+	currentBuild = nil
+
+	if skipDeps, _ := cmd.Flags().GetBool("skip-deps"); !skipDeps {
+		if err := installDeps(false); err != nil {
+			return notifyBuildFailure(cmd, started, fmt.Errorf("failed to install the dependencies: %v", err))
+		}
+	}
+
+	refs, _ := cmd.Flags().GetStringSlice("apps")
+	if ref, _ := cmd.Flags().GetString("app"); ref != "" {
+		refs = append([]string{ref}, refs...)
+	}
+	if len(refs) == 0 {
+		if err := build(); err != nil {
+			return notifyBuildFailure(cmd, started, err)
+		}
+		return nil
+	}
+
+	appsDir := viper.GetString("apps.dir")
+	if appsDir == "" {
+		appsDir = "apps"
+	}
+	if !filepath.IsAbs(appsDir) {
+		appsDir = filepath.Join(projectDir, appsDir)
+	}
+	keepWorkDir, _ := cmd.Flags().GetBool("keep-workdir")
+	distPath, err := distRoot(projectDir)
+	if err != nil {
+		return notifyBuildFailure(cmd, started, err)
+	}
+
+	originalProjectDir := projectDir
+	defer func() {
+		projectDir = originalProjectDir
+		artifactsDir = ""
+		sourceDir = ""
+		currentApp = nil
+	}()
+
+	for _, ref := range refs {
+		started = time.Now()
+		currentBuild = nil
+		app, err := whitelabel.Load(ref, appsDir)
+		if err != nil {
+			return notifyBuildFailure(cmd, started, err)
+		}
+
+		span := trace.Start(app.ID)
+		fmt.Printf("Preparing %s (%s)...\n", app.Name, app.ID)
+		currentApp = app
+		prepare := trace.Start("prepare")
+		workDir, err := whitelabel.Prepare(originalProjectDir, distPath, app)
+		prepare.End()
+		if err != nil {
+			span.End()
+			return notifyBuildFailure(cmd, started, fmt.Errorf("failed to prepare %s: %v", app.ID, err))
+		}
+
+		projectDir = workDir
+		artifactsDir = filepath.Join(distPath, app.ID)
+		sourceDir = originalProjectDir
+		err = build()
+		if err != nil {
+			// Posted before the working copy goes, the event reads the version from it.
+			err = notifyBuildFailure(cmd, started, fmt.Errorf("failed to build %s: %v", app.ID, err))
+		}
+
+		if keepWorkDir {
+			fmt.Printf("Working copy for %s kept at %s\n", app.ID, workDir)
+		} else {
+			os.RemoveAll(workDir)
+		}
+		span.End()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadBuildEnv loads the .env file selected with --env, checks that it sets every key listed under env.required
+// in bob.yaml and returns the variables to pass to Gradle and Xcode. From then on, the values of secret variables,
+// those listed under env.secrets or named like secrets, and the Android keystore passwords are masked in the output
+// of every command bob runs.
+func loadBuildEnv() ([]string, error) {
+	// Gradle can echo the keystore passwords, which would then also end up in failure notifications.
+	utils.MaskSecrets(androidSigningConfig().Secrets()...)
+
+	required := viper.GetStringSlice("env.required")
+	env, err := dotenv.Load(projectDir, envName)
+	if errors.Is(err, os.ErrNotExist) && envName == "" && len(required) == 0 {
+		// Projects that do not use react-native-config build without a .env file.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if missing := env.Missing(required); len(missing) > 0 {
+		return nil, fmt.Errorf("%s is missing required keys: %s", filepath.Base(env.File), strings.Join(missing, ", "))
+	}
+
+	secrets := viper.GetStringSlice("env.secrets")
+	utils.MaskSecrets(env.Secrets(secrets)...)
+
+	fmt.Printf("Using %s\n", filepath.Base(env.File))
+	for _, key := range env.Keys() {
+		value := env.Vars[key]
+		if dotenv.IsSecret(key, secrets) {
+			value = "****"
+		}
+		fmt.Printf("  %s=%s\n", key, value)
+	}
+
+	return env.Environ(), nil
+}
+
+// collectArtifacts copies the outputs of a build that started at the given time into dist/<platform>/<version>/,
+// or dist/<app>/<platform>/<version>/ for white-label apps, and writes the artifacts.json manifest describing them.
+// It returns the directory the artifacts were collected into, or "" when the build left no outputs.
+func collectArtifacts(platform string, started time.Time) (string, error) {
+	defer trace.Start("collect artifacts").End()
+	outputs, err := artifacts.Find(projectDir, platform, started)
+	if err != nil {
+		return "", err
+	}
+	if len(outputs) == 0 {
+		fmt.Printf("No %s build outputs found to collect.\n", platform)
+		return "", nil
+	}
+
+	manifest := newManifest(platform, started)
+	if path := artifacts.FindBundle(projectDir, platform, started); path != "" {
+		if manifest.Bundle, err = artifacts.DescribeBundle(path); err != nil {
+			return "", err
+		}
+	}
+
+	dir, err := artifactsPath(platform, manifest.Version)
+	if err != nil {
+		return "", err
+	}
+	if err := artifacts.Collect(dir, outputs, manifest); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("Collected %d artifacts into %s\n", len(manifest.Artifacts), dir)
+	for _, artifact := range manifest.Artifacts {
+		fmt.Printf("  %-40s %10d  %s\n", artifact.Name, artifact.Size, artifact.SHA256)
+	}
+	if manifest.Bundle != nil {
+		fmt.Printf("JavaScript bundle %s is %d bytes (Hermes %t)\n", manifest.Bundle.Name, manifest.Bundle.Size, manifest.Bundle.Hermes)
+	}
+
+	return dir, nil
+}
+
+// artifactsPath returns the directory the artifacts of a build of the platform and version are collected into.
+// Collecting replaces that directory, so an empty version, which would make it the directory of every version of
+// the platform, is refused.
+func artifactsPath(platform, appVersion string) (string, error) {
+	if appVersion == "" || appVersion == "." || appVersion == ".." || strings.ContainsAny(appVersion, `/\`) {
+		return "", fmt.Errorf("could not read a usable app version from %s, set one with bob version set", projectDir)
+	}
+
+	root := artifactsDir
+	if root == "" {
+		var err error
+		if root, err = distRoot(projectDir); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Join(root, platform, appVersion), nil
+}
+
+// distRoot returns the directory --dist points at inside dir. Values naming dir itself or a directory outside
+// of it are refused, so that collecting artifacts never replaces parts of the project such as android/.
+func distRoot(dir string) (string, error) {
+	root := filepath.Join(dir, distDir)
+	rel, err := filepath.Rel(dir, root)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("--dist %q must name a directory inside the project", distDir)
+	}
+	return root, nil
+}
+
+// buildCacheKey returns the key of a build of the platform with the given environment, extra toolchain versions,
+// build options and input files, or "" when the build is not cached, because of --no-cache or because the project
+// has uncommitted changes.
+func buildCacheKey(platform string, env []string, toolchain, options map[string]string, files []string) string {
+	if noCache {
+		return ""
+	}
+	defer trace.Start("cache key").End()
+
+	inputs := cache.Inputs{
+		ProjectDir: projectDir,
+		Exclude:    []string{distDir},
+		Lockfiles:  cacheLockfiles,
+		Files:      files,
+		Toolchain:  artifacts.ToolchainVersions(platform),
+		Options:    map[string]string{"platform": platform},
+	}
+	if sourceDir != "" {
+		inputs.ProjectDir = sourceDir
+	}
+	switch platform {
+	case "android":
+		inputs.Exclude = append(inputs.Exclude, "ios")
+	case "ios":
+		inputs.Exclude = append(inputs.Exclude, "android")
+	}
+	for name, version := range toolchain {
+		inputs.Toolchain[name] = version
+	}
+	for name, value := range options {
+		inputs.Options[name] = value
+	}
+
+	// The values are hashed rather than kept, they may hold secrets.
+	sortedEnv := append([]string{}, env...)
+	sort.Strings(sortedEnv)
+	envHash := sha256.Sum256([]byte(strings.Join(sortedEnv, "\n")))
+	inputs.Options["env"] = hex.EncodeToString(envHash[:])
+
+	if currentApp != nil {
+		config, err := json.Marshal(currentApp)
+		if err != nil {
+			fmt.Printf("Not using the build cache: %v\n", err)
+			return ""
+		}
+		inputs.Options["app"] = string(config)
+		for _, asset := range []string{currentApp.Icon, currentApp.Splash} {
+			if asset != "" {
+				inputs.Files = append(inputs.Files, currentApp.Asset(asset))
+			}
+		}
+	}
+
+	key, err := cache.Key(inputs)
+	if err != nil {
+		fmt.Printf("Not using the build cache: %v\n", err)
+		return ""
+	}
+	return key
+}
+
+// openBuildCache opens the cache back end set with cache.location in bob.yaml, the local cache directory by default.
+func openBuildCache() (cache.Backend, error) {
+	return cache.Open(viper.GetString("cache.location"))
+}
+
+// restoreArtifacts restores the artifacts of an earlier build with the same key, reporting whether it did.
+func restoreArtifacts(platform, key string) bool {
+	if key == "" {
+		return false
+	}
+	defer trace.Start("cache restore").End()
+
+	appVersion := ""
+	if locations, err := version.Read(projectDir); err == nil {
+		appVersion = version.Current(locations).Name
+	}
+	dir, err := artifactsPath(platform, appVersion)
+	if err != nil {
+		return false
+	}
+
+	// The entry replaces the artifacts of an earlier build of the version rather than being mixed with them.
+	if err := artifacts.Clean(dir); err != nil {
+		fmt.Printf("Warning: not using the build cache: %v\n", err)
+		return false
+	}
+	backend, err := openBuildCache()
+	if err != nil {
+		fmt.Printf("Warning: not using the build cache: %v\n", err)
+		return false
+	}
+	restored, err := cache.Restore(backend, key, dir)
+	if err != nil {
+		// Do not leave a partly restored entry behind for bob publish to pick up.
+		os.RemoveAll(dir)
+		fmt.Printf("Warning: %v\n", err)
+		return false
+	}
+	if !restored {
+		fmt.Printf("No cached %s build for key %s, building.\n", platform, key[:12])
+		return false
+	}
+
+	manifest, err := artifacts.ReadManifest(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		fmt.Printf("Warning: cache entry %s is not a build: %v\n", key[:12], err)
+		return false
+	}
+	fmt.Printf("Restored %d artifacts of %s build %s from the %s into %s, skipping the build.\n",
+		len(manifest.Artifacts), platform, key[:12], backend, dir)
+	return true
+}
+
+// storeArtifacts stores the collected artifacts of a build in the build cache under its key.
+func storeArtifacts(key, dir string) {
+	if key == "" || dir == "" {
+		return
+	}
+	defer trace.Start("cache store").End()
+
+	backend, err := openBuildCache()
+	if err == nil {
+		err = cache.Save(backend, key, dir)
+	}
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	fmt.Printf("Stored the artifacts in the %s under key %s\n", backend, key[:12])
+}
+
+// newManifest describes a build of the platform in the project that started at the given time.
+func newManifest(platform string, started time.Time) *artifacts.Manifest {
+	manifest := &artifacts.Manifest{
+		Platform:        platform,
+		GitCommit:       artifacts.GitCommit(projectDir),
+		CreatedAt:       time.Now().UTC(),
+		DurationSeconds: time.Since(started).Round(time.Millisecond).Seconds(),
+		Toolchain:       artifacts.ToolchainVersions(platform),
+	}
+	if locations, err := version.Read(projectDir); err == nil {
+		current := version.Current(locations)
+		manifest.Version = current.Name
+		manifest.BuildNumber = current.Build
+	}
+
+	return manifest
+}
+
 func init() {
 	rootCmd.AddCommand(buildCmd)
 
+	buildCmd.PersistentFlags().StringVar(&distDir, "dist", "dist", "directory, relative to the project, to collect build artifacts into")
+	buildCmd.PersistentFlags().StringVar(&envName, "env", "", "environment to build, reads .env.<env> instead of .env")
+	buildCmd.PersistentFlags().String("app", "", "white-label app to build, an app ID from the apps directory or a path to its JSON config")
+	buildCmd.PersistentFlags().StringSlice("apps", nil, "comma separated white-label apps to build one after another")
+	buildCmd.PersistentFlags().Bool("skip-deps", false, "do not install node_modules before building")
+	buildCmd.PersistentFlags().BoolVar(&noCache, "no-cache", false, "build even when the build cache has the artifacts of an identical build, and do not store them")
+	buildCmd.PersistentFlags().BoolVar(&noNotify, "no-notify", false, "do not post build events to the webhooks under notify in bob.yaml")
+	buildCmd.PersistentFlags().Bool("keep-workdir", false, "keep the working copies of white-label apps for inspection")
+	buildCmd.Flags().StringSlice("platforms", []string{"android", "ios"}, "platforms to build")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -0,0 +1,66 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/aman-apptile/bob/pkg/artifacts"
+	"github.com/aman-apptile/bob/pkg/bundle"
+	"github.com/aman-apptile/bob/pkg/trace"
+	"github.com/spf13/cobra"
+)
+
+// bundleCmd represents the bundle command
+var bundleCmd = &cobra.Command{
+	Use:   "bundle android|ios",
+	Short: "This command bundles the JavaScript of the app for a release build, compiling it with Hermes when enabled",
+	// Long:  ``,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"android", "ios"},
+	Run: func(cmd *cobra.Command, args []string) {
+		platform := args[0]
+		options := bundle.Options{
+			ProjectDir: projectDir,
+			Platform:   platform,
+			Hermes:     bundle.HermesEnabled(projectDir, platform),
+		}
+		options.EntryFile, _ = cmd.Flags().GetString("entry-file")
+		if cmd.Flags().Changed("hermes") {
+			options.Hermes, _ = cmd.Flags().GetBool("hermes")
+		}
+		output, _ := cmd.Flags().GetString("output")
+		options.OutputDir = filepath.Join(projectDir, output, platform)
+
+		fmt.Printf("Bundling JavaScript for %s (Hermes %t)...\n", platform, options.Hermes)
+		started := time.Now()
+		span := trace.Start("bundle " + platform)
+		result, err := bundle.Run(options)
+		span.End()
+		checkErr(cmd, err)
+
+		manifest := newManifest(platform, started)
+		manifest.Bundle, err = artifacts.DescribeBundle(result.Bundle)
+		cobra.CheckErr(err)
+		for _, output := range []struct{ path, kind string }{{result.Bundle, "jsbundle"}, {result.SourceMap, "sourcemap"}} {
+			artifact, err := artifacts.Describe(output.path, output.kind)
+			cobra.CheckErr(err)
+			manifest.Artifacts = append(manifest.Artifacts, *artifact)
+		}
+		cobra.CheckErr(artifacts.WriteManifest(options.OutputDir, manifest))
+
+		fmt.Printf("Bundle written to %s (%d bytes)\n", filepath.Join(options.OutputDir, filepath.Base(result.Bundle)), manifest.Bundle.Size)
+		fmt.Printf("Source map written to %s\n", filepath.Join(options.OutputDir, filepath.Base(result.SourceMap)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+
+	bundleCmd.Flags().String("entry-file", "", "entry file relative to the project (default is index.<platform>.js or index.js)")
+	bundleCmd.Flags().String("output", filepath.Join("build", "bundle"), "directory, relative to the project, to write the bundle into")
+	bundleCmd.Flags().Bool("hermes", false, "compile the bundle to Hermes bytecode (default is detected from the native project)")
+}
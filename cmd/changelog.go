@@ -0,0 +1,83 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aman-apptile/bob/pkg/appstore"
+	"github.com/aman-apptile/bob/pkg/changelog"
+	"github.com/aman-apptile/bob/pkg/play"
+	"github.com/spf13/cobra"
+)
+
+// Names of the files bob changelog writes.
+const (
+	changelogMarkdownFile   = "CHANGELOG.md"
+	changelogPlayFile       = "play.txt"
+	changelogTestflightFile = "testflight.txt"
+)
+
+// changelogCmd represents the changelog command
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "This command generates release notes from the Conventional Commits in the git history",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		log, err := changelog.Generate(projectDir, from, to)
+		cobra.CheckErr(err)
+
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			output = filepath.Join(projectDir, distDir, "changelog")
+		}
+		cobra.CheckErr(os.MkdirAll(output, 0755))
+
+		files := map[string]string{
+			changelogMarkdownFile:   log.Markdown(),
+			changelogPlayFile:       log.PlainText(play.MaxReleaseNotesLength),
+			changelogTestflightFile: log.PlainText(appstore.MaxWhatToTestLength),
+		}
+		for name, content := range files {
+			err := os.WriteFile(filepath.Join(output, name), []byte(content+"\n"), 0644)
+			cobra.CheckErr(err)
+		}
+
+		fmt.Print(files[changelogMarkdownFile])
+		fmt.Printf("\nWrote %s, %s and %s to %s\n", changelogMarkdownFile, changelogPlayFile, changelogTestflightFile, output)
+	},
+}
+
+// changelogNotes returns store release notes of at most limit characters for the commits since the tag given with
+// --changelog-from, or the most recent tag. It returns "" when the git history cannot be read or has no changes.
+func changelogNotes(cmd *cobra.Command, limit int) string {
+	from, _ := cmd.Flags().GetString("changelog-from")
+	log, err := changelog.Generate(projectDir, from, "HEAD")
+	if err != nil {
+		fmt.Printf("Publishing without release notes: %v\n", err)
+		return ""
+	}
+	if log.Empty() {
+		return ""
+	}
+
+	if log.From != "" {
+		fmt.Printf("Using the changes since %s as release notes\n", log.From)
+	} else {
+		fmt.Println("Using the git history as release notes")
+	}
+	return log.PlainText(limit)
+}
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+
+	changelogCmd.Flags().String("from", "", "tag or commit the changelog starts after (default the most recent tag)")
+	changelogCmd.Flags().String("to", "HEAD", "tag or commit the changelog ends at")
+	changelogCmd.Flags().StringP("output", "o", "", "directory to write the changelog files to (default <dist>/changelog)")
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/aman-apptile/bob/pkg/constants"
+	"github.com/aman-apptile/bob/pkg/deps"
+	"github.com/aman-apptile/bob/pkg/trace"
+	"github.com/aman-apptile/bob/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// depsCmd represents the deps command
+var depsCmd = &cobra.Command{
+	Use:   "deps",
+	Short: "This command installs the JavaScript and native dependencies of the project",
+	// Long:  ``,
+}
+
+// depsInstallCmd represents the deps install command
+var depsInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "This command installs node_modules from the lockfile with the package manager the project uses",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		force, _ := cmd.Flags().GetBool("force")
+		checkErr(cmd, installDeps(force))
+	},
+}
+
+// depsPodsCmd represents the deps pods command
+var depsPodsCmd = &cobra.Command{
+	Use:   "pods",
+	Short: "This command installs the CocoaPods of the iOS project and shows which pods changed",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		force, _ := cmd.Flags().GetBool("force")
+		if !force && deps.PodsUpToDate(filepath.Join(projectDir, "ios")) {
+			fmt.Println("Pods are up to date with Podfile.lock, skipping install.")
+			return
+		}
+
+		span := trace.Start("pod install")
+		changes, err := deps.InstallPods(utils.SystemExecutor{}, projectDir)
+		span.End()
+		checkErr(cmd, err)
+
+		if len(changes) == 0 {
+			fmt.Println("No pods changed.")
+			return
+		}
+		fmt.Printf("%d pods changed:\n", len(changes))
+		for _, change := range changes {
+			fmt.Printf("  %s\n", change)
+		}
+	},
+}
+
+// installDeps runs a frozen-lockfile install with the pinned Node.js version, unless node_modules was already
+// installed from the same package.json, lockfile and Node.js version.
+func installDeps(force bool) error {
+	defer trace.Start("node_modules").End()
+	pm, err := deps.DetectPackageManager(projectDir)
+	if err != nil {
+		return err
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	nodeVersion := viper.GetString("node.version")
+	if nodeVersion == "" {
+		nodeVersion = constants.REQUIRED_NODE_VERSION
+	}
+	env, err := deps.NodeEnv(homeDir, nodeVersion)
+	if err != nil {
+		return err
+	}
+
+	hash, err := deps.InstallHash(projectDir, pm, env)
+	if err != nil {
+		return err
+	}
+	if !force && deps.UpToDate(projectDir, hash) {
+		fmt.Printf("node_modules is up to date with %s, skipping install.\n", pm.Lockfile)
+		return nil
+	}
+
+	fmt.Printf("Installing dependencies with %s (Node.js %s)...\n", pm.Name, nodeVersion)
+	return deps.Install(projectDir, pm, env, hash)
+}
+
+func init() {
+	rootCmd.AddCommand(depsCmd)
+	depsCmd.AddCommand(depsInstallCmd)
+	depsCmd.AddCommand(depsPodsCmd)
+
+	depsInstallCmd.Flags().Bool("force", false, "install even when node_modules is up to date")
+	depsPodsCmd.Flags().Bool("force", false, "install even when the pods are up to date")
+}
@@ -31,12 +31,12 @@ var healthCmd = &cobra.Command{
 			utils.StopSpinner(s, " Homebrew is not installed.", "failure")
 		}
 
-		s = utils.StartSpinner(" Checking required Homebrew packages")
-		result = pkg.CheckHomebrewPackages([]string{"openjdk", "gradle"})
-		if result {
-			utils.StopSpinner(s, " Required Homebrew packages are installed.", "success")
+		s = utils.StartSpinner(" Checking the JDK")
+		jdk, err := pkg.CheckJDK(projectDir, homeDir, recommendedJDK())
+		if err == nil {
+			utils.StopSpinner(s, " "+jdk.String()+" is installed.", "success")
 		} else {
-			utils.StopSpinner(s, " Required Homebrew packages are not installed.", "failure")
+			utils.StopSpinner(s, " "+err.Error(), "failure")
 		}
 
 		s = utils.StartSpinner(" Checking NVM")
@@ -72,11 +72,11 @@ var healthCmd = &cobra.Command{
 		}
 
 		s = utils.StartSpinner(" Checking CocoaPods")
-		result = pkg.CheckCocoapods()
-		if result {
-			utils.StopSpinner(s, " CocoaPods is installed.", "success")
+		cocoapodsVersion, err := pkg.CheckCocoapods(projectDir)
+		if err == nil {
+			utils.StopSpinner(s, " CocoaPods "+cocoapodsVersion+" is installed.", "success")
 		} else {
-			utils.StopSpinner(s, " CocoaPods is not installed.", "failure")
+			utils.StopSpinner(s, " "+err.Error(), "failure")
 		}
 
 		s = utils.StartSpinner(" Checking Android environment")
@@ -87,6 +87,14 @@ var healthCmd = &cobra.Command{
 			utils.StopSpinner(s, " Android environment is not setup.", "failure")
 		}
 
+		s = utils.StartSpinner(" Checking Gradle and JDK compatibility")
+		gradleSummary, err := pkg.CheckGradleCompatibility(projectDir, homeDir)
+		if err == nil {
+			utils.StopSpinner(s, " "+gradleSummary+" are compatible.", "success")
+		} else {
+			utils.StopSpinner(s, " "+err.Error(), "failure")
+		}
+
 		s = utils.StartSpinner(" Checking iOS environment")
 		result = pkg.CheckIosEnvironment()
 		if result {
@@ -94,6 +102,30 @@ var healthCmd = &cobra.Command{
 		} else {
 			utils.StopSpinner(s, " iOS environment is not setup.", "failure")
 		}
+
+		s = utils.StartSpinner(" Checking Xcode project")
+		summary, err := pkg.CheckXcodeProject(projectDir)
+		if err == nil {
+			utils.StopSpinner(s, " Xcode project: "+summary, "success")
+		} else {
+			utils.StopSpinner(s, " Xcode project could not be read: "+err.Error(), "warning")
+		}
+
+		s = utils.StartSpinner(" Checking CocoaPods version")
+		err = pkg.CheckCocoapodsVersion(projectDir)
+		if err == nil {
+			utils.StopSpinner(s, " CocoaPods version matches Podfile.lock.", "success")
+		} else {
+			utils.StopSpinner(s, " "+err.Error(), "warning")
+		}
+
+		s = utils.StartSpinner(" Checking Xcode version")
+		err = pkg.CheckXcodeDeploymentTarget(projectDir)
+		if err == nil {
+			utils.StopSpinner(s, " Xcode supports the deployment target.", "success")
+		} else {
+			utils.StopSpinner(s, " "+err.Error(), "warning")
+		}
 	},
 }
 
@@ -5,7 +5,11 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
+	"time"
 
+	"github.com/aman-apptile/bob/pkg/ios"
+	"github.com/aman-apptile/bob/pkg/trace"
 	"github.com/spf13/cobra"
 )
 
@@ -15,13 +19,130 @@ var iosCmd = &cobra.Command{
 	Short: "This command will build the iOS applications for Apptile's react-native applications",
 	// Long:  ``,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("Building iOS application...")
+		if listSchemes, _ := cmd.Flags().GetBool("list-schemes"); listSchemes {
+			for _, scheme := range ios.ListSchemes(filepath.Join(projectDir, "ios")) {
+				fmt.Println(scheme)
+			}
+			return
+		}
+
+		err := runForApps(cmd, func() error {
+			env, err := loadBuildEnv()
+			if err != nil {
+				return err
+			}
+			return buildIos(cmd, env)
+		})
+		checkErr(cmd, err)
 	},
 }
 
+// buildIos archives and exports the scheme selected by the flags of cmd with the given environment and collects its artifacts.
+func buildIos(cmd *cobra.Command, env []string) error {
+	defer trace.Start("ios").End()
+	beginBuild("ios")
+	iosDir := filepath.Join(projectDir, "ios")
+
+	xcodeproj, err := ios.FindXcodeProject(iosDir)
+	if err != nil {
+		return err
+	}
+	project, err := ios.LoadProject(xcodeproj)
+	if err != nil {
+		return err
+	}
+
+	scheme, _ := cmd.Flags().GetString("scheme")
+	if scheme == "" {
+		scheme = project.Name
+	}
+	if err := ios.CheckScheme(iosDir, scheme); err != nil {
+		return err
+	}
+
+	configuration, _ := cmd.Flags().GetString("configuration")
+	exportOptions := ios.ExportOptions{
+		TeamID: project.Setting(project.MainTarget(), configuration, "DEVELOPMENT_TEAM"),
+	}
+	exportOptions.Method, _ = cmd.Flags().GetString("export-method")
+	if err := ios.ValidateExportMethod(exportOptions.Method); err != nil {
+		return err
+	}
+
+	bundleID, resolved := project.ResolvedBundleIdentifier(configuration)
+	if currentApp != nil && currentApp.Signing.Ios.TeamID != "" {
+		exportOptions.TeamID = currentApp.Signing.Ios.TeamID
+	}
+
+	// Sign manually with a matching installed profile when there is one, otherwise let Xcode manage signing.
+	if currentApp != nil && currentApp.Signing.Ios.Profile != "" {
+		exportOptions.Profiles = map[string]string{bundleID: currentApp.Signing.Ios.Profile}
+	} else if !resolved {
+		fmt.Printf("Could not resolve the bundle identifier %s, letting Xcode pick the provisioning profile\n", bundleID)
+	} else if profile, err := ios.SelectProfile(loadProvisioningProfiles(nil), bundleID, exportOptions.TeamID, exportOptions.Method, time.Now()); err == nil {
+		exportOptions.Profiles = map[string]string{bundleID: profile.Name}
+		if exportOptions.TeamID == "" {
+			exportOptions.TeamID = profile.TeamID
+		}
+	}
+	if profile, ok := exportOptions.Profiles[bundleID]; ok {
+		fmt.Printf("Signing with provisioning profile %q\n", profile)
+	}
+
+	fmt.Printf("Building iOS application (%s, %s, %s)...\n", scheme, configuration, exportOptions.Method)
+	currentBuild.variant = scheme
+	started := time.Now()
+
+	options := map[string]string{
+		"scheme":        scheme,
+		"configuration": configuration,
+		"exportMethod":  exportOptions.Method,
+		"teamId":        exportOptions.TeamID,
+		"profile":       exportOptions.Profiles[bundleID],
+	}
+	key := buildCacheKey("ios", env, nil, options, nil)
+	if restoreArtifacts("ios", key) {
+		return nil
+	}
+
+	notifyBuildStart()
+	buildDir := filepath.Join(iosDir, "build")
+	archivePath := filepath.Join(buildDir, scheme+".xcarchive")
+	archive := trace.Start("xcodebuild archive")
+	err = ios.Archive(ios.ArchiveOptions{
+		IosDir:        iosDir,
+		Scheme:        scheme,
+		Configuration: configuration,
+		ArchivePath:   archivePath,
+		Env:           env,
+	})
+	archive.End()
+	if err == nil {
+		export := trace.Start("xcodebuild export")
+		err = ios.ExportArchive(archivePath, buildDir, exportOptions)
+		export.End()
+	}
+	if err != nil {
+		return err
+	}
+
+	dir, err := collectArtifacts("ios", started)
+	if err != nil {
+		return err
+	}
+	storeArtifacts(key, dir)
+	notifyBuildSuccess(dir)
+	return nil
+}
+
 func init() {
 	buildCmd.AddCommand(iosCmd)
 
+	iosCmd.Flags().String("scheme", "", "shared scheme to build (default is the scheme named after the Xcode project)")
+	iosCmd.Flags().String("configuration", "Release", "build configuration to archive")
+	iosCmd.Flags().String("export-method", "app-store", "export method, one of app-store, ad-hoc, enterprise or development")
+	iosCmd.Flags().Bool("list-schemes", false, "list the shared schemes and exit")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -0,0 +1,162 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aman-apptile/bob/pkg/ios"
+	"github.com/aman-apptile/bob/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+// iosToolsCmd represents the ios command
+var iosToolsCmd = &cobra.Command{
+	Use:   "ios",
+	Short: "This command groups the iOS tooling for Apptile's react-native applications",
+	// Long:  ``,
+}
+
+// iosSigningCmd represents the ios signing command
+var iosSigningCmd = &cobra.Command{
+	Use:   "signing",
+	Short: "This command inspects the provisioning profiles and certificates used to sign iOS builds",
+	// Long:  ``,
+}
+
+// iosSigningListCmd represents the ios signing list command
+var iosSigningListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "This command lists the installed provisioning profiles",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		dirs, _ := cmd.Flags().GetStringSlice("profiles-dir")
+		profiles := loadProvisioningProfiles(dirs)
+		now := time.Now()
+
+		for _, profile := range profiles {
+			status := "valid"
+			if profile.Expired(now) {
+				status = "EXPIRED"
+			}
+
+			fmt.Printf("%s (%s)\n", profile.Name, profile.UUID)
+			fmt.Printf("  Team:         %s (%s)\n", profile.TeamName, profile.TeamID)
+			fmt.Printf("  App ID:       %s\n", profile.ApplicationIdentifier)
+			fmt.Printf("  Method:       %s\n", profile.ExportMethod())
+			fmt.Printf("  Expires:      %s (%s)\n", profile.ExpirationDate.Format("2006-01-02"), status)
+			fmt.Printf("  Entitlements: %s\n", strings.Join(entitlementKeys(profile), ", "))
+			for _, cert := range profile.Certificates {
+				fmt.Printf("  Certificate:  %s (expires %s)\n", cert.Subject.CommonName, cert.NotAfter.Format("2006-01-02"))
+			}
+		}
+	},
+}
+
+// iosSigningDoctorCmd represents the ios signing doctor command
+var iosSigningDoctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "This command checks that a valid provisioning profile and certificate exist for the iOS app before building",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		configuration, _ := cmd.Flags().GetString("configuration")
+		method, _ := cmd.Flags().GetString("export-method")
+		now := time.Now()
+
+		xcodeproj, err := ios.FindXcodeProject(filepath.Join(projectDir, "ios"))
+		cobra.CheckErr(err)
+		project, err := ios.LoadProject(xcodeproj)
+		cobra.CheckErr(err)
+
+		bundleID, resolved := project.ResolvedBundleIdentifier(configuration)
+		teamID := project.Setting(project.MainTarget(), configuration, "DEVELOPMENT_TEAM")
+		fmt.Printf("Checking code signing for %s (%s)...\n", bundleID, configuration)
+
+		dirs, _ := cmd.Flags().GetStringSlice("profiles-dir")
+		profiles := loadProvisioningProfiles(dirs)
+
+		s := utils.StartSpinner(" Checking provisioning profiles")
+		if !resolved {
+			utils.StopSpinner(s, fmt.Sprintf(" Could not resolve the bundle identifier %s from the project, skipping the profile check.", bundleID), "warning")
+			return
+		}
+		profile, err := ios.SelectProfile(profiles, bundleID, teamID, method, now)
+		if err != nil {
+			utils.StopSpinner(s, " "+err.Error(), "failure")
+			os.Exit(1)
+		}
+		utils.StopSpinner(s, fmt.Sprintf(" Using profile %q (%s, expires %s).", profile.Name, profile.ExportMethod(), profile.ExpirationDate.Format("2006-01-02")), "success")
+
+		for _, other := range profiles {
+			if other != profile && other.Matches(bundleID) && other.Expired(now) {
+				s = utils.StartSpinner(" Checking stale profiles")
+				utils.StopSpinner(s, fmt.Sprintf(" Expired profile %q is still installed at %s.", other.Name, other.Path), "warning")
+			}
+		}
+
+		s = utils.StartSpinner(" Checking signing certificates")
+		identities, err := ios.CodesigningIdentities()
+		if err != nil {
+			utils.StopSpinner(s, " Could not read the keychain: "+err.Error(), "warning")
+			return
+		}
+
+		var valid []string
+		for _, cert := range profile.Certificates {
+			if cert.NotAfter.Before(now) {
+				continue
+			}
+			if name, ok := identities[ios.CertificateFingerprint(cert)]; ok {
+				valid = append(valid, name)
+			}
+		}
+		if len(valid) == 0 {
+			utils.StopSpinner(s, " None of the profile's certificates are installed in the keychain with a private key.", "failure")
+			os.Exit(1)
+		}
+		utils.StopSpinner(s, " Signing identity: "+strings.Join(valid, ", "), "success")
+	},
+}
+
+// loadProvisioningProfiles decodes the profiles in the given directories, or in Xcode's directories by default.
+func loadProvisioningProfiles(dirs []string) []*ios.ProvisioningProfile {
+	if len(dirs) == 0 {
+		homeDir, err := os.UserHomeDir()
+		cobra.CheckErr(err)
+		dirs = ios.DefaultProfileDirs(homeDir)
+	}
+
+	profiles, errs := ios.LoadProfiles(dirs...)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "Skipping profile:", err)
+	}
+
+	return profiles
+}
+
+func entitlementKeys(profile *ios.ProvisioningProfile) []string {
+	keys := make([]string, 0, len(profile.Entitlements))
+	for key := range profile.Entitlements {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func init() {
+	rootCmd.AddCommand(iosToolsCmd)
+	iosToolsCmd.AddCommand(iosSigningCmd)
+	iosSigningCmd.AddCommand(iosSigningListCmd)
+	iosSigningCmd.AddCommand(iosSigningDoctorCmd)
+
+	iosSigningCmd.PersistentFlags().StringSlice("profiles-dir", nil, "directories to read .mobileprovision files from (default is Xcode's profile directories)")
+	iosSigningDoctorCmd.Flags().String("configuration", "Release", "build configuration to read the bundle identifier from")
+	iosSigningDoctorCmd.Flags().String("export-method", "", "only accept profiles for this export method (app-store, ad-hoc, enterprise or development)")
+}
@@ -0,0 +1,112 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/aman-apptile/bob/pkg/android"
+	"github.com/aman-apptile/bob/pkg/constants"
+	"github.com/spf13/cobra"
+)
+
+// javaCmd represents the java command
+var javaCmd = &cobra.Command{
+	Use:   "java",
+	Short: "This command manages the JDK that Gradle and the Android tools run on",
+	// Long:  ``,
+}
+
+// javaListCmd represents the java list command
+var javaListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "This command lists the installed JDKs and marks the one the project builds with",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		homeDir, err := os.UserHomeDir()
+		cobra.CheckErr(err)
+
+		jdks := android.FindJDKs(homeDir)
+		if len(jdks) == 0 {
+			cobra.CheckErr(fmt.Errorf("no JDK found, install one with brew install openjdk@%d", recommendedJDK()))
+		}
+
+		selected, err := android.SelectJDK(projectDir, jdks)
+		for _, jdk := range jdks {
+			marker := " "
+			if jdk.Home == selected.Home {
+				marker = "*"
+			}
+			fmt.Printf("%s %-3d %-14s %-14s %s\n", marker, jdk.Major(), jdk.Version, jdk.Source, jdk.Home)
+		}
+		if err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+// javaUseCmd represents the java use command
+var javaUseCmd = &cobra.Command{
+	Use:   "use <version>",
+	Short: "This command pins the JDK the project builds with in .java-version",
+	// Long:  ``,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		homeDir, err := os.UserHomeDir()
+		cobra.CheckErr(err)
+
+		major := android.JavaMajorVersion(args[0])
+		if major == 0 {
+			cobra.CheckErr(fmt.Errorf("invalid JDK version %q, expected a feature release such as 17", args[0]))
+		}
+		jdk, ok := android.FindJDK(android.FindJDKs(homeDir), major)
+		if !ok {
+			cobra.CheckErr(fmt.Errorf("JDK %d is not installed, install it with brew install openjdk@%d", major, major))
+		}
+
+		if agpVersion, err := android.AGPVersion(projectDir); err == nil {
+			if gradleVersion, err := android.GradleWrapperVersion(projectDir); err == nil {
+				if err := android.CheckCompatibility(agpVersion, gradleVersion, major); err != nil {
+					fmt.Println("Warning:", err)
+				}
+			}
+		}
+
+		cobra.CheckErr(android.PinJDK(projectDir, major))
+		fmt.Printf("Builds of this project now use %s\n", jdk)
+	},
+}
+
+// projectJDK returns the JDK the project builds with and the environment that exports it to build steps.
+// Only the commands bob runs see it, the JAVA_HOME of the user's shell is left alone. When no JDK can be
+// selected, e.g. because the Android Gradle plugin version cannot be read, the build runs on the JDK of the
+// environment, with a warning.
+func projectJDK() (android.JDK, []string) {
+	homeDir, _ := os.UserHomeDir()
+	jdk, err := android.SelectJDK(projectDir, android.FindJDKs(homeDir))
+	if err == nil {
+		return jdk, jdk.Env()
+	}
+
+	jdk = android.EnvironmentJDK()
+	fmt.Printf("Warning: %v, building with the JDK of the environment\n", err)
+	return jdk, nil
+}
+
+// recommendedJDK returns the JDK the project's Android Gradle plugin recommends, or the default one without a project.
+func recommendedJDK() int {
+	if agpVersion, err := android.AGPVersion(projectDir); err == nil {
+		return android.RecommendedJDK(agpVersion)
+	}
+	major, _ := strconv.Atoi(constants.REQUIRED_JDK_VERSION)
+	return major
+}
+
+func init() {
+	rootCmd.AddCommand(javaCmd)
+	javaCmd.AddCommand(javaListCmd)
+	javaCmd.AddCommand(javaUseCmd)
+}
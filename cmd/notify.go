@@ -0,0 +1,179 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aman-apptile/bob/pkg/artifacts"
+	"github.com/aman-apptile/bob/pkg/notify"
+	"github.com/aman-apptile/bob/pkg/utils"
+	"github.com/aman-apptile/bob/pkg/version"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// logTailLines is how many lines of the build output a failure notification carries.
+const logTailLines = 40
+
+var noNotify bool
+
+// buildNotifier returns a notifier of the webhooks under notify.webhooks in bob.yaml, or nil when there are none
+// or --no-notify is set. ${VAR} references in the URLs and headers are expanded from the environment, so that
+// secret webhook URLs stay out of bob.yaml, and masked.
+func buildNotifier() (*notify.Notifier, error) {
+	if noNotify {
+		return nil, nil
+	}
+
+	var webhooks []notify.Webhook
+	if err := viper.UnmarshalKey("notify.webhooks", &webhooks); err != nil {
+		return nil, fmt.Errorf("invalid notify.webhooks in bob.yaml: %v", err)
+	}
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+
+	for i := range webhooks {
+		webhooks[i].URL = os.ExpandEnv(webhooks[i].URL)
+		utils.MaskSecrets(webhooks[i].URL)
+		for name, value := range webhooks[i].Headers {
+			webhooks[i].Headers[name] = os.ExpandEnv(value)
+			utils.MaskSecrets(webhooks[i].Headers[name])
+		}
+		if err := webhooks[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return notify.NewNotifier(webhooks), nil
+}
+
+// runningBuild is a build of one platform that has begun.
+type runningBuild struct {
+	platform string
+	// variant is the Android variant or iOS scheme, set once it is resolved.
+	variant string
+	started time.Time
+}
+
+// currentBuild is the platform build in progress, set by beginBuild and cleared once its outcome is posted, so
+// that a failure at any of its steps is reported for it.
+var currentBuild *runningBuild
+
+// beginBuild records that a build of the platform begins.
+func beginBuild(platform string) {
+	currentBuild = &runningBuild{platform: platform, started: time.Now()}
+}
+
+// buildEvent returns an event of the kind about the build of the platform that started at the given time.
+func buildEvent(kind, platform, variant string, started time.Time) notify.Event {
+	// --project defaults to ".", which names no app.
+	dir, _ := filepath.Abs(projectDir)
+	event := notify.Event{
+		Kind:      kind,
+		App:       filepath.Base(dir),
+		Platform:  platform,
+		Variant:   variant,
+		GitCommit: artifacts.GitCommit(projectDir),
+		Duration:  time.Since(started),
+		Time:      time.Now().UTC(),
+	}
+	if sourceDir != "" {
+		dir, _ = filepath.Abs(sourceDir)
+		event.App = filepath.Base(dir)
+	}
+	if currentApp != nil {
+		event.App = currentApp.Name
+	}
+	if locations, err := version.Read(projectDir); err == nil {
+		current := version.Current(locations)
+		event.Version, event.BuildNumber = current.Name, current.Build
+	}
+	return event
+}
+
+// notifyBuild posts the event to the configured webhooks. A failed delivery is reported but does not fail the build.
+func notifyBuild(event notify.Event) {
+	notifier, err := buildNotifier()
+	if err == nil && notifier != nil {
+		err = notifier.Notify(event)
+	}
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// notifyBuildStart posts the start event of the current build.
+func notifyBuildStart() {
+	notifyBuild(buildEvent(notify.Start, currentBuild.platform, currentBuild.variant, currentBuild.started))
+}
+
+// notifyBuildFailure posts a failure event with err and the last lines of the build output, and returns err. Every
+// failing build command reports through it: the event describes the current build, which gets its terminal event
+// that way, or, when the failure came before any platform build began, the platforms of cmd since started.
+func notifyBuildFailure(cmd *cobra.Command, started time.Time, err error) error {
+	build := currentBuild
+	if build == nil {
+		build = &runningBuild{platform: commandPlatforms(cmd), started: started}
+	}
+	currentBuild = nil
+
+	event := buildEvent(notify.Failure, build.platform, build.variant, build.started)
+	event.Error = err.Error()
+	event.LogTail = utils.OutputTail(logTailLines)
+	notifyBuild(event)
+	return err
+}
+
+// commandPlatforms returns the platforms the build command builds, comma separated.
+func commandPlatforms(cmd *cobra.Command) string {
+	if platforms, err := cmd.Flags().GetStringSlice("platforms"); err == nil {
+		return strings.Join(platforms, ",")
+	}
+	return cmd.Name()
+}
+
+// notifyBuildSuccess posts the success event of the current build, linking to the artifacts collected into dir.
+// The links point below notify.artifactsUrl when it is set, e.g. to where CI or bob publish s3 puts the dist
+// directory, and to the local files otherwise.
+func notifyBuildSuccess(dir string) {
+	event := buildEvent(notify.Success, currentBuild.platform, currentBuild.variant, currentBuild.started)
+	currentBuild = nil
+	if manifest, err := artifacts.ReadManifest(dir); err == nil {
+		for _, artifact := range manifest.Artifacts {
+			event.Artifacts = append(event.Artifacts, notify.Artifact{
+				Name: artifact.Name,
+				Kind: artifact.Kind,
+				Size: artifact.Size,
+				URL:  artifactURL(filepath.Join(dir, artifact.Name)),
+			})
+		}
+	}
+	notifyBuild(event)
+}
+
+func artifactURL(file string) string {
+	root := filepath.Join(projectDir, distDir)
+	if sourceDir != "" {
+		root = filepath.Join(sourceDir, distDir)
+	}
+	base := viper.GetString("notify.artifactsUrl")
+	rel, err := filepath.Rel(root, file)
+	if base == "" || err != nil {
+		return (&url.URL{Scheme: "file", Path: file}).String()
+	}
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return base
+	}
+	u.Path = path.Join(u.Path, filepath.ToSlash(rel))
+	return u.String()
+}
@@ -0,0 +1,358 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aman-apptile/bob/pkg/android"
+	"github.com/aman-apptile/bob/pkg/appstore"
+	"github.com/aman-apptile/bob/pkg/artifacts"
+	"github.com/aman-apptile/bob/pkg/cache"
+	"github.com/aman-apptile/bob/pkg/ios"
+	"github.com/aman-apptile/bob/pkg/play"
+	"github.com/aman-apptile/bob/pkg/s3"
+	"github.com/aman-apptile/bob/pkg/utils"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// contentTypes are the content types of the artifact kinds that mime does not know.
+var contentTypes = map[string]string{
+	".apk":  "application/vnd.android.package-archive",
+	".aab":  "application/octet-stream",
+	".ipa":  "application/octet-stream",
+	".json": "application/json",
+	".txt":  "text/plain; charset=utf-8",
+}
+
+// publishCmd represents the publish command
+var publishCmd = &cobra.Command{
+	Use:   "publish",
+	Short: "This command publishes the collected build artifacts",
+	// Long:  ``,
+}
+
+// publishS3Cmd represents the publish s3 command
+var publishS3Cmd = &cobra.Command{
+	Use:   "s3",
+	Short: "This command uploads the artifacts listed in artifacts.json to an S3 compatible bucket and prints pre-signed links to them",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := publishArtifactsDir(cmd, "")
+		manifest, err := artifacts.ReadManifest(dir)
+		cobra.CheckErr(err)
+
+		bucket, _ := cmd.Flags().GetString("bucket")
+		if bucket == "" {
+			bucket = viper.GetString("s3.bucket")
+		}
+		if bucket == "" {
+			cobra.CheckErr(fmt.Errorf("no bucket given, pass --bucket or set s3.bucket in bob.yaml"))
+		}
+		prefix, _ := cmd.Flags().GetString("prefix")
+		if prefix == "" {
+			prefix = viper.GetString("s3.prefix")
+		}
+		// Without a prefix, the objects mirror the layout of the dist directory, e.g. acme/android/1.2.3/.
+		if rel, err := filepath.Rel(filepath.Join(projectDir, distDir), dir); err == nil && !strings.HasPrefix(rel, "..") {
+			prefix = path.Join(prefix, filepath.ToSlash(rel))
+		} else {
+			prefix = path.Join(prefix, manifest.Platform, manifest.Version)
+		}
+		expires, _ := cmd.Flags().GetDuration("expires")
+
+		config, err := s3Config()
+		cobra.CheckErr(err)
+		client := s3.NewClient(config)
+
+		names := []string{artifacts.ManifestFile}
+		for _, artifact := range manifest.Artifacts {
+			names = append(names, artifact.Name)
+		}
+
+		fmt.Printf("Uploading %d files to s3://%s/%s...\n", len(names), bucket, prefix)
+		for _, name := range names {
+			key := path.Join(prefix, name)
+			err := client.UploadFile(bucket, key, filepath.Join(dir, name), contentType(name))
+			cobra.CheckErr(err)
+
+			link, err := client.PresignGet(bucket, key, expires)
+			cobra.CheckErr(err)
+			fmt.Printf("  %s\n    %s\n", name, link)
+		}
+		fmt.Printf("Links expire in %s.\n", expires)
+	},
+}
+
+// publishPlayCmd represents the publish play command
+var publishPlayCmd = &cobra.Command{
+	Use:   "play",
+	Short: "This command uploads the Android App Bundle listed in artifacts.json to a Google Play track",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := publishArtifactsDir(cmd, "android")
+		manifest, err := artifacts.ReadManifest(dir)
+		cobra.CheckErr(err)
+
+		upload := play.Upload{ReleaseName: manifest.Version}
+		for _, artifact := range manifest.Artifacts {
+			switch artifact.Kind {
+			case "aab":
+				upload.Bundle = filepath.Join(dir, artifact.Name)
+			case "mapping":
+				upload.Mapping = filepath.Join(dir, artifact.Name)
+			}
+		}
+		if upload.Bundle == "" {
+			cobra.CheckErr(fmt.Errorf("no app bundle in %s, Google Play needs one, build it with bob build android --format aab", dir))
+		}
+
+		upload.Track, _ = cmd.Flags().GetString("track")
+		upload.Rollout, _ = cmd.Flags().GetFloat64("rollout")
+		upload.PackageName = playPackageName(cmd)
+		notes, _ := cmd.Flags().GetString("release-notes")
+		if notes == "" {
+			notes = viper.GetString("play.releaseNotes")
+		}
+		language, _ := cmd.Flags().GetString("language")
+		if notes != "" {
+			upload.ReleaseNotes, err = play.ReadReleaseNotes(resolvePath(notes), language)
+			cobra.CheckErr(err)
+		} else if text := changelogNotes(cmd, play.MaxReleaseNotesLength); text != "" {
+			upload.ReleaseNotes = []play.LocalizedText{{Language: language, Text: text}}
+		}
+		cobra.CheckErr(upload.Validate())
+
+		keyFile, _ := cmd.Flags().GetString("service-account")
+		keyFile = firstNonEmpty(keyFile, viper.GetString("play.serviceAccount"), os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"))
+		if keyFile == "" {
+			cobra.CheckErr(fmt.Errorf("no service account key given, pass --service-account or set play.serviceAccount in bob.yaml"))
+		}
+		account, err := play.LoadServiceAccount(resolvePath(keyFile))
+		cobra.CheckErr(err)
+		if tokenURI := viper.GetString("play.tokenUri"); tokenURI != "" {
+			account.TokenURI = tokenURI
+		}
+
+		fmt.Printf("Publishing %s %s to Google Play as %s...\n", upload.PackageName, manifest.Version, account.ClientEmail)
+		client := play.NewClient(viper.GetString("play.baseUrl"), account)
+		versionCode, err := client.Publish(upload, os.Stdout)
+		cobra.CheckErr(err)
+		fmt.Printf("Published version code %d of %s to the %s track.\n", versionCode, upload.PackageName, upload.Track)
+	},
+}
+
+// publishTestflightCmd represents the publish testflight command
+var publishTestflightCmd = &cobra.Command{
+	Use:   "testflight",
+	Short: "This command uploads the IPA listed in artifacts.json to App Store Connect and hands it to TestFlight testers",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := publishArtifactsDir(cmd, "ios")
+		manifest, err := artifacts.ReadManifest(dir)
+		cobra.CheckErr(err)
+
+		upload := appstore.Upload{Version: manifest.Version, BuildNumber: fmt.Sprint(manifest.BuildNumber)}
+		for _, artifact := range manifest.Artifacts {
+			if artifact.Kind == "ipa" {
+				upload.IPA = filepath.Join(dir, artifact.Name)
+			}
+		}
+		if upload.IPA == "" {
+			cobra.CheckErr(fmt.Errorf("no IPA in %s, build one with bob build ios --export-method app-store", dir))
+		}
+
+		upload.BundleID = testflightBundleID(cmd)
+		upload.Locale, _ = cmd.Flags().GetString("locale")
+		upload.Timeout, _ = cmd.Flags().GetDuration("timeout")
+		upload.Groups, _ = cmd.Flags().GetStringSlice("group")
+		if len(upload.Groups) == 0 {
+			upload.Groups = viper.GetStringSlice("testflight.groups")
+		}
+		notes, _ := cmd.Flags().GetString("what-to-test")
+		if notes = firstNonEmpty(notes, viper.GetString("testflight.whatToTest")); notes != "" {
+			data, err := os.ReadFile(resolvePath(notes))
+			cobra.CheckErr(err)
+			upload.WhatToTest = strings.TrimSpace(string(data))
+		} else {
+			upload.WhatToTest = changelogNotes(cmd, appstore.MaxWhatToTestLength)
+		}
+		cobra.CheckErr(upload.Validate())
+
+		key, err := appStoreConnectKey()
+		cobra.CheckErr(err)
+
+		fmt.Printf("Publishing %s %s (%s) to TestFlight with API key %s...\n", upload.BundleID, upload.Version, upload.BuildNumber, key.KeyID)
+		client := appstore.NewClient(viper.GetString("appStoreConnect.baseUrl"), key)
+		if interval := viper.GetDuration("appStoreConnect.pollInterval"); interval > 0 {
+			client.PollInterval = interval
+		}
+		_, err = client.Publish(upload, os.Stdout)
+		cobra.CheckErr(err)
+		fmt.Printf("%s (%s) is available in TestFlight.\n", upload.Version, upload.BuildNumber)
+	},
+}
+
+// testflightBundleID returns the bundle ID set with --bundle-id or testflight.bundleId, or the one of the Release
+// configuration of the Xcode project.
+func testflightBundleID(cmd *cobra.Command) string {
+	if bundleID, _ := cmd.Flags().GetString("bundle-id"); bundleID != "" {
+		return bundleID
+	}
+	if bundleID := viper.GetString("testflight.bundleId"); bundleID != "" {
+		return bundleID
+	}
+
+	xcodeproj, err := ios.FindXcodeProject(filepath.Join(projectDir, "ios"))
+	cobra.CheckErr(err)
+	project, err := ios.LoadProject(xcodeproj)
+	cobra.CheckErr(err)
+	bundleID, resolved := project.ResolvedBundleIdentifier("Release")
+	if bundleID == "" || !resolved {
+		cobra.CheckErr(fmt.Errorf("could not read the bundle ID from %s, pass --bundle-id", filepath.Base(xcodeproj)))
+	}
+	return bundleID
+}
+
+// appStoreConnectKey reads the App Store Connect API key from appStoreConnect.* in bob.yaml, or from
+// APP_STORE_CONNECT_KEY_ID, APP_STORE_CONNECT_ISSUER_ID and either APP_STORE_CONNECT_KEY_FILE or
+// APP_STORE_CONNECT_KEY holding the content of the .p8 file.
+func appStoreConnectKey() (*appstore.APIKey, error) {
+	keyID := firstNonEmpty(os.Getenv("APP_STORE_CONNECT_KEY_ID"), viper.GetString("appStoreConnect.keyId"))
+	issuerID := firstNonEmpty(os.Getenv("APP_STORE_CONNECT_ISSUER_ID"), viper.GetString("appStoreConnect.issuerId"))
+
+	data := []byte(os.Getenv("APP_STORE_CONNECT_KEY"))
+	if len(data) == 0 {
+		keyFile := firstNonEmpty(os.Getenv("APP_STORE_CONNECT_KEY_FILE"), viper.GetString("appStoreConnect.keyFile"))
+		if keyFile == "" {
+			return nil, fmt.Errorf("no App Store Connect API key, set APP_STORE_CONNECT_KEY_FILE or appStoreConnect.keyFile in bob.yaml")
+		}
+		var err error
+		if data, err = os.ReadFile(resolvePath(keyFile)); err != nil {
+			return nil, fmt.Errorf("failed to read the App Store Connect API key: %v", err)
+		}
+	}
+	utils.MaskSecrets(string(data))
+
+	return appstore.ParseAPIKey(keyID, issuerID, data)
+}
+
+// playPackageName returns the package name set with --package or play.packageName, or the application ID of the
+// Gradle project.
+func playPackageName(cmd *cobra.Command) string {
+	packageName, _ := cmd.Flags().GetString("package")
+	if packageName = firstNonEmpty(packageName, viper.GetString("play.packageName")); packageName != "" {
+		return packageName
+	}
+
+	gradleConfig, err := android.LoadGradleConfig(projectDir)
+	cobra.CheckErr(err)
+	if gradleConfig.ApplicationID == "" {
+		cobra.CheckErr(fmt.Errorf("no applicationId found in %s, pass --package", android.AppBuildGradle(projectDir)))
+	}
+	return gradleConfig.ApplicationID
+}
+
+// resolvePath resolves a path relative to the project directory.
+func resolvePath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(projectDir, path)
+}
+
+// publishArtifactsDir returns the directory set with --artifacts, or the one of the most recent build of the
+// platform in the dist directory.
+func publishArtifactsDir(cmd *cobra.Command, platform string) string {
+	if dir, _ := cmd.Flags().GetString("artifacts"); dir != "" {
+		return dir
+	}
+
+	dir, err := artifacts.LatestManifestDir(filepath.Join(projectDir, distDir), platform)
+	cobra.CheckErr(err)
+	return dir
+}
+
+// s3Config reads the S3 settings from s3.* in bob.yaml and the credentials from the standard AWS environment
+// variables, falling back to s3.accessKeyId and s3.secretAccessKey. The secrets are masked in the output.
+func s3Config() (s3.Config, error) {
+	config := s3.Config{
+		Endpoint:        viper.GetString("s3.endpoint"),
+		Region:          firstNonEmpty(viper.GetString("s3.region"), os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")),
+		AccessKeyID:     firstNonEmpty(os.Getenv("AWS_ACCESS_KEY_ID"), viper.GetString("s3.accessKeyId")),
+		SecretAccessKey: firstNonEmpty(os.Getenv("AWS_SECRET_ACCESS_KEY"), viper.GetString("s3.secretAccessKey")),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		PathStyle:       viper.GetBool("s3.pathStyle"),
+	}
+	if config.Endpoint != "" && config.Region == "" {
+		// MinIO and most other stores accept any region in the signature.
+		config.Region = "us-east-1"
+	}
+	utils.MaskSecrets(config.SecretAccessKey, config.SessionToken)
+
+	return config, config.Validate()
+}
+
+func contentType(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if contentType, ok := contentTypes[ext]; ok {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func init() {
+	rootCmd.AddCommand(publishCmd)
+	publishCmd.AddCommand(publishS3Cmd)
+	publishCmd.AddCommand(publishPlayCmd)
+	publishCmd.AddCommand(publishTestflightCmd)
+
+	publishCmd.PersistentFlags().String("artifacts", "", "directory holding the artifacts.json to publish (default is the most recent build in the dist directory)")
+	publishCmd.PersistentFlags().String("changelog-from", "", "tag the generated release notes start after when no notes file is given (default the most recent tag)")
+	publishS3Cmd.Flags().String("bucket", "", "bucket to upload to (default s3.bucket)")
+	publishS3Cmd.Flags().String("prefix", "", "key prefix to upload under, followed by the layout of the dist directory (default s3.prefix)")
+	publishS3Cmd.Flags().Duration("expires", s3.MaxPresignExpiry, "how long the pre-signed links stay valid, at most 168h")
+
+	publishPlayCmd.Flags().String("track", "internal", "track to release to, one of "+strings.Join(play.Tracks, ", "))
+	publishPlayCmd.Flags().Float64("rollout", 0, "percentage of users to stage the release to, e.g. 10, default is everyone")
+	publishPlayCmd.Flags().String("release-notes", "", "file with the release notes, or directory with one <language>.txt file per language (default play.releaseNotes, or notes generated from the git history)")
+	publishPlayCmd.Flags().String("language", "en-US", "language of a single release notes file")
+	publishPlayCmd.Flags().String("service-account", "", "service account key JSON file (default play.serviceAccount or GOOGLE_APPLICATION_CREDENTIALS)")
+	publishPlayCmd.Flags().String("package", "", "package name of the app (default play.packageName or the applicationId of the Gradle project)")
+
+	publishTestflightCmd.Flags().StringSlice("group", nil, "beta group to add the build to, can be repeated (default testflight.groups)")
+	publishTestflightCmd.Flags().String("what-to-test", "", "file with the What to Test notes for the testers (default testflight.whatToTest, or notes generated from the git history)")
+	publishTestflightCmd.Flags().String("locale", "en-US", "locale of the What to Test notes")
+	publishTestflightCmd.Flags().String("bundle-id", "", "bundle ID of the app (default testflight.bundleId or the one of the Xcode project)")
+	publishTestflightCmd.Flags().Duration("timeout", time.Hour, "how long to wait for App Store Connect to process the build")
+
+	// Build artifacts can be cached in a bucket with cache.location set to s3://<bucket>/<prefix>.
+	cache.Register("s3", func(location *url.URL) (cache.Backend, error) {
+		config, err := s3Config()
+		if err != nil {
+			return nil, err
+		}
+		return &cache.S3Backend{Client: s3.NewClient(config), Bucket: location.Host, Prefix: strings.Trim(location.Path, "/")}, nil
+	})
+}
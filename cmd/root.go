@@ -6,13 +6,17 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/aman-apptile/bob/pkg/utils"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
 var cfgFile string
 
+var projectDir string
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "bob",
@@ -21,6 +25,7 @@ var rootCmd = &cobra.Command{
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	// Run: func(cmd *cobra.Command, args []string) { },
+	PersistentPostRun: reportTimings,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -40,6 +45,8 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.bob.yaml)")
+	rootCmd.PersistentFlags().StringVarP(&projectDir, "project", "p", ".", "path to the react-native project")
+	rootCmd.PersistentFlags().StringVar(&traceFile, "trace", "", "write a Chrome trace of the timed steps of the run to this file, for Perfetto")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
@@ -68,4 +75,15 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
+
+	// Project settings in bob.yaml take precedence over the user's config file.
+	projectConfig := filepath.Join(projectDir, "bob.yaml")
+	if _, err := os.Stat(projectConfig); err == nil {
+		viper.SetConfigFile(projectConfig)
+		if err := viper.MergeInConfig(); err == nil {
+			fmt.Fprintln(os.Stderr, "Using project config file:", projectConfig)
+		} else {
+			utils.CheckError(err, "Failed to read "+projectConfig)
+		}
+	}
 }
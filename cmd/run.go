@@ -0,0 +1,161 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/aman-apptile/bob/pkg/android"
+	"github.com/aman-apptile/bob/pkg/ios"
+	"github.com/spf13/cobra"
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run android|ios",
+	Short: "This command installs the app on a device, emulator or simulator, launches it and streams its logs",
+	// Long:  ``,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"android", "ios"},
+	Run: func(cmd *cobra.Command, args []string) {
+		switch args[0] {
+		case "android":
+			runAndroid(cmd)
+		case "ios":
+			runIos(cmd)
+		}
+	},
+}
+
+// runAndroid installs the APK of the selected variant on the selected device, building it first when asked to
+// or when it was not built yet, then launches its launcher activity and streams the app's logcat.
+func runAndroid(cmd *cobra.Command) {
+	gradleConfig, err := android.LoadGradleConfig(projectDir)
+	cobra.CheckErr(err)
+	flavor, _ := cmd.Flags().GetString("flavor")
+	buildType, _ := cmd.Flags().GetString("type")
+	variant, err := gradleConfig.ResolveVariant(flavor, buildType)
+	cobra.CheckErr(err)
+
+	sdk := androidSDK()
+	devices, err := sdk.Devices()
+	cobra.CheckErr(err)
+	query, _ := cmd.Flags().GetString("device")
+	device, err := android.SelectDevice(devices, query)
+	cobra.CheckErr(err)
+
+	build, _ := cmd.Flags().GetBool("build")
+	apk, err := android.FindAPK(projectDir, variant)
+	if build || err != nil {
+		task, err := variant.Task("apk")
+		cobra.CheckErr(err)
+		env, err := loadBuildEnv()
+		cobra.CheckErr(err)
+		jdk, jdkEnv := projectJDK()
+		fmt.Printf("Building %s with %s...\n", variant.Name(), jdk)
+		err = android.RunGradle(filepath.Join(projectDir, "android"), append(env, jdkEnv...), task)
+		cobra.CheckErr(err)
+
+		apk, err = android.FindAPK(projectDir, variant)
+		cobra.CheckErr(err)
+	}
+
+	namespace := gradleConfig.Namespace
+	if namespace == "" {
+		namespace = gradleConfig.ApplicationID
+	}
+	activity, err := android.LaunchActivity(projectDir, variant, namespace)
+	cobra.CheckErr(err)
+	applicationID := gradleConfig.VariantApplicationID(variant)
+
+	fmt.Printf("Installing %s on %s...\n", filepath.Base(apk), device)
+	cobra.CheckErr(sdk.Install(device.Serial, apk))
+	fmt.Printf("Launching %s/%s...\n", applicationID, activity)
+	cobra.CheckErr(sdk.Launch(device.Serial, applicationID, activity))
+
+	if noLogs, _ := cmd.Flags().GetBool("no-logs"); noLogs {
+		return
+	}
+	pid, err := sdk.AppPID(device.Serial, applicationID, 30*time.Second)
+	cobra.CheckErr(err)
+	filters, _ := cmd.Flags().GetStringSlice("log-filter")
+	fmt.Printf("Streaming logcat of %s (pid %s), press Ctrl+C to stop...\n", applicationID, pid)
+	cobra.CheckErr(sdk.Logcat(device.Serial, pid, filters))
+}
+
+// runIos builds the scheme for the selected simulator when asked to or when it was not built yet, boots the
+// simulator, installs and launches the app and streams its log.
+func runIos(cmd *cobra.Command) {
+	iosDir := filepath.Join(projectDir, "ios")
+	xcodeproj, err := ios.FindXcodeProject(iosDir)
+	cobra.CheckErr(err)
+	project, err := ios.LoadProject(xcodeproj)
+	cobra.CheckErr(err)
+
+	scheme, _ := cmd.Flags().GetString("scheme")
+	if scheme == "" {
+		scheme = project.Name
+	}
+	cobra.CheckErr(ios.CheckScheme(iosDir, scheme))
+	configuration, _ := cmd.Flags().GetString("configuration")
+
+	simctl := ios.NewSimctl()
+	simulators, err := simctl.Simulators()
+	cobra.CheckErr(err)
+	query, _ := cmd.Flags().GetString("device")
+	simulator, err := ios.SelectSimulator(simulators, query)
+	cobra.CheckErr(err)
+	if !simulator.Booted() {
+		fmt.Printf("Booting %s...\n", simulator)
+		cobra.CheckErr(simctl.Boot(simulator.UDID))
+	}
+
+	derivedDataPath := filepath.Join(iosDir, "build", "simulator")
+	build, _ := cmd.Flags().GetBool("build")
+	app, err := ios.FindSimulatorApp(derivedDataPath, configuration)
+	if build || err != nil {
+		env, err := loadBuildEnv()
+		cobra.CheckErr(err)
+		fmt.Printf("Building %s (%s) for %s...\n", scheme, configuration, simulator.Name)
+		app, err = ios.BuildForSimulator(ios.SimulatorBuildOptions{
+			IosDir:          iosDir,
+			Scheme:          scheme,
+			Configuration:   configuration,
+			UDID:            simulator.UDID,
+			DerivedDataPath: derivedDataPath,
+			Env:             env,
+		})
+		cobra.CheckErr(err)
+	}
+
+	bundleID, executable, err := simctl.AppBundle(app)
+	cobra.CheckErr(err)
+
+	fmt.Printf("Installing %s on %s...\n", filepath.Base(app), simulator)
+	cobra.CheckErr(simctl.Install(simulator.UDID, app))
+	fmt.Printf("Launching %s...\n", bundleID)
+	cobra.CheckErr(simctl.Launch(simulator.UDID, bundleID))
+
+	if noLogs, _ := cmd.Flags().GetBool("no-logs"); noLogs || executable == "" {
+		return
+	}
+	fmt.Printf("Streaming the log of %s, press Ctrl+C to stop...\n", executable)
+	cobra.CheckErr(simctl.StreamLogs(simulator.UDID, executable))
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().String("device", "", "device serial or model, or simulator UDID or name, to run on (default is the only connected device or the booted simulator)")
+	runCmd.Flags().Bool("build", false, "build before installing even when a build already exists")
+	runCmd.Flags().StringVar(&envName, "env", "", "environment to build with, reads .env.<env> instead of .env")
+	runCmd.Flags().Bool("no-logs", false, "exit after launching instead of streaming the app's logs")
+	runCmd.Flags().String("flavor", "", "Android product flavor to run, comma separated when the project has several flavor dimensions")
+	runCmd.Flags().String("type", "debug", "Android build type to run")
+	runCmd.Flags().StringSlice("log-filter", nil, "logcat filter specs, e.g. ReactNativeJS:V,*:S")
+	runCmd.Flags().String("scheme", "", "iOS scheme to run (default is the scheme named after the Xcode project)")
+	runCmd.Flags().String("configuration", "Debug", "iOS build configuration to run")
+}
@@ -6,9 +6,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/aman-apptile/bob/pkg"
-	"github.com/aman-apptile/bob/pkg/constants"
+	"github.com/aman-apptile/bob/pkg/trace"
 	"github.com/aman-apptile/bob/pkg/utils"
 	"github.com/spf13/cobra"
 )
@@ -24,18 +25,26 @@ var setupCmd = &cobra.Command{
 
 		fmt.Println("Setting up development environment...")
 
-		pkg.SetupHomebrew()
-		pkg.SetupHomebrewPackages([]string{"openjdk@" + constants.REQUIRED_JDK_VERSION, "gradle"})
-		pkg.SetupNVM(homeDir)
-		pkg.SetupRbenv(homeDir)
-		pkg.SetupCocoapods()
-		pkg.SetupAndroidEnvironment(homeDir)
-		pkg.SetupIosEnvironment()
+		setupStep("homebrew", pkg.SetupHomebrew)
+		// Projects build with the Gradle wrapper, so only the JDK their Android Gradle plugin needs is installed.
+		// bob finds it in the Homebrew cellar and exports it to builds, see bob java.
+		setupStep("jdk", func() { pkg.SetupHomebrewPackages([]string{"openjdk@" + strconv.Itoa(recommendedJDK())}) })
+		setupStep("nvm", func() { pkg.SetupNVM(homeDir) })
+		setupStep("rbenv", func() { pkg.SetupRbenv(homeDir) })
+		setupStep("cocoapods", func() { pkg.SetupCocoapods(projectDir) })
+		setupStep("android", func() { pkg.SetupAndroidEnvironment(homeDir) })
+		setupStep("ios", pkg.SetupIosEnvironment)
 
 		fmt.Println("Development environment setup complete!")
 	},
 }
 
+// setupStep runs a step of the setup in a timing span.
+func setupStep(name string, step func()) {
+	defer trace.Start(name).End()
+	step()
+}
+
 func init() {
 	rootCmd.AddCommand(setupCmd)
 
@@ -0,0 +1,166 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aman-apptile/bob/pkg/trace"
+	"github.com/spf13/cobra"
+)
+
+// traceFile is where --trace writes the Chrome trace of the run.
+var traceFile string
+
+// trendRuns is how many of the most recent runs bob stats draws the trend of.
+const trendRuns = 10
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "This command shows how long the steps of past runs took in this project and flags the ones that got slower",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		historyFile, err := trace.DefaultHistoryFile()
+		cobra.CheckErr(err)
+		runs, err := trace.ReadHistory(historyFile)
+		cobra.CheckErr(err)
+
+		project, _ := filepath.Abs(projectDir)
+		command, _ := cmd.Flags().GetString("command")
+		limit, _ := cmd.Flags().GetInt("runs")
+		if limit < 1 {
+			cobra.CheckErr(fmt.Errorf("--runs must be at least 1, not %d", limit))
+		}
+
+		// Runs are grouped by command, the most recently run command first.
+		byCommand := map[string][]trace.Run{}
+		var commands []string
+		for i := len(runs) - 1; i >= 0; i-- {
+			run := runs[i]
+			if run.Project != project || (command != "" && !strings.HasPrefix(run.Command, command)) {
+				continue
+			}
+			kept := byCommand[run.Command]
+			if len(kept) >= limit {
+				continue
+			}
+			if len(kept) == 0 {
+				commands = append(commands, run.Command)
+			}
+			byCommand[run.Command] = append([]trace.Run{run}, kept...)
+		}
+		if len(commands) == 0 {
+			if command != "" {
+				fmt.Printf("No timings of bob %s recorded for %s yet.\n", command, project)
+			} else {
+				fmt.Printf("No timings recorded for %s yet, they are recorded by every build.\n", project)
+			}
+			return
+		}
+
+		for i, command := range commands {
+			if i > 0 {
+				fmt.Println()
+			}
+			printStats(command, byCommand[command])
+		}
+	},
+}
+
+func printStats(command string, runs []trace.Run) {
+	last := runs[len(runs)-1]
+	fmt.Printf("bob %s, %d runs, last on %s\n", command, len(runs), last.Time.Local().Format("2006-01-02 15:04"))
+
+	stats := trace.Stats(runs)
+	width := len("Step")
+	for _, s := range stats {
+		width = max(width, len(stepName(s.Path)))
+	}
+
+	fmt.Printf("%-*s  %4s  %9s  %9s  %9s  %9s  %s\n", width, "Step", "Runs", "Last", "Median", "Min", "Max", "Trend")
+	var regressions []string
+	for _, s := range stats {
+		change := ""
+		if s.Previous > 0 {
+			change = fmt.Sprintf("%+.0f%%", s.Change()*100)
+		}
+		if s.Regression() {
+			change += " slower"
+			regressions = append(regressions, s.Path)
+		}
+		// The sparkline covers the last trendRuns runs, padded by hand since its characters are multi-byte.
+		trend := trace.Sparkline(s.Durations[max(0, len(s.Durations)-trendRuns):])
+		trend += strings.Repeat(" ", trendRuns-utf8.RuneCountInString(trend))
+		line := fmt.Sprintf("%-*s  %4d  %9s  %9s  %9s  %9s  %s  %s", width, stepName(s.Path), s.Runs,
+			seconds(s.Last), seconds(s.Median), seconds(s.Min), seconds(s.Max), trend, change)
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+
+	if len(regressions) > 0 {
+		fmt.Printf("The last run of %s was markedly slower than the ones before.\n", strings.Join(regressions, ", "))
+	}
+}
+
+// stepName indents the last element of a step path by its depth.
+func stepName(path string) string {
+	depth := strings.Count(path, "/")
+	return strings.Repeat("  ", depth) + path[strings.LastIndex(path, "/")+1:]
+}
+
+func seconds(value float64) string {
+	return trace.FormatDuration(time.Duration(value * float64(time.Second)))
+}
+
+// reportTimings runs after every command that recorded steps. It prints how long each step took, writes the
+// Chrome trace requested with --trace and adds the run to the history bob stats reads.
+func reportTimings(cmd *cobra.Command, args []string) {
+	spans := trace.Spans()
+	if len(spans) == 0 {
+		return
+	}
+	start := trace.RunStart()
+	command := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+
+	fmt.Println()
+	trace.WriteTable(os.Stdout, spans, time.Since(start))
+
+	if traceFile != "" {
+		if err := trace.WriteChromeTrace(traceFile, "bob "+command, start, spans); err != nil {
+			fmt.Printf("Warning: failed to write the trace: %v\n", err)
+		} else {
+			fmt.Printf("Trace written to %s, open it in https://ui.perfetto.dev\n", traceFile)
+		}
+	}
+
+	project, _ := filepath.Abs(projectDir)
+	historyFile, err := trace.DefaultHistoryFile()
+	if err == nil {
+		err = trace.AppendHistory(historyFile, trace.NewRun(command, project, start, spans))
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to record the timings of this run: %v\n", err)
+	}
+}
+
+// checkErr is cobra.CheckErr for the commands that record steps. cobra.CheckErr exits, which skips
+// PersistentPostRun, so the timings of a failed run are reported here first.
+func checkErr(cmd *cobra.Command, err error) {
+	if err != nil {
+		reportTimings(cmd, nil)
+	}
+	cobra.CheckErr(err)
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().String("command", "", "only show runs of commands starting with this, e.g. \"build android\"")
+	statsCmd.Flags().Int("runs", 20, "how many of the most recent runs of each command to show")
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2024 Mohammed Aman Khan <mohammed.aman@apptile.io>
+*/
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/aman-apptile/bob/pkg/version"
+	"github.com/spf13/cobra"
+)
+
+// versionCmd represents the version command
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "This command shows and updates the app version across package.json, Android and iOS",
+	// Long:  ``,
+}
+
+// versionShowCmd represents the version show command
+var versionShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "This command shows the version recorded in each project file",
+	// Long:  ``,
+	Run: func(cmd *cobra.Command, args []string) {
+		locations, err := version.Read(projectDir)
+		cobra.CheckErr(err)
+
+		for _, location := range locations {
+			if location.HasBuild {
+				fmt.Printf("%-12s %s\n", location.Version, relativePath(location.File))
+			} else {
+				fmt.Printf("%-12s %s\n", location.Version.Name, relativePath(location.File))
+			}
+		}
+
+		current := version.Current(locations)
+		for _, location := range locations {
+			if location.Version.Name != current.Name || (location.HasBuild && location.Version.Build != current.Build) {
+				fmt.Printf("\nVersions are out of sync, the next bump will set every file to %s.\n", current)
+				break
+			}
+		}
+	},
+}
+
+// versionBumpCmd represents the version bump command
+var versionBumpCmd = &cobra.Command{
+	Use:   "bump major|minor|patch|build",
+	Short: "This command increments the version and build number on both platforms",
+	// Long:  ``,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"major", "minor", "patch", "build"},
+	Run: func(cmd *cobra.Command, args []string) {
+		locations, err := version.Read(projectDir)
+		cobra.CheckErr(err)
+
+		next, err := version.Bump(version.Current(locations), args[0])
+		cobra.CheckErr(err)
+
+		writeVersion(cmd, next)
+	},
+}
+
+// versionSetCmd represents the version set command
+var versionSetCmd = &cobra.Command{
+	Use:   "set X.Y.Z",
+	Short: "This command sets the version on both platforms and increments the build number",
+	// Long:  ``,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(version.Validate(args[0]))
+
+		locations, err := version.Read(projectDir)
+		cobra.CheckErr(err)
+
+		next := version.Version{Name: args[0], Build: version.Current(locations).Build + 1}
+		if build, _ := cmd.Flags().GetInt("build"); build > 0 {
+			next.Build = build
+		}
+
+		writeVersion(cmd, next)
+	},
+}
+
+// writeVersion records the version in the project files and tags it in git when --tag is set.
+func writeVersion(cmd *cobra.Command, v version.Version) {
+	changed, err := version.Write(projectDir, v)
+	cobra.CheckErr(err)
+
+	fmt.Printf("Version set to %s\n", v)
+	for _, file := range changed {
+		fmt.Printf("  updated %s\n", relativePath(file))
+	}
+
+	if tag, _ := cmd.Flags().GetBool("tag"); tag && len(changed) > 0 {
+		cobra.CheckErr(version.Tag(projectDir, v, changed))
+		fmt.Printf("Tagged v%s\n", v.Name)
+	}
+}
+
+// relativePath shortens a path to be relative to the project directory for display.
+func relativePath(path string) string {
+	if rel, err := filepath.Rel(projectDir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	versionCmd.AddCommand(versionShowCmd)
+	versionCmd.AddCommand(versionBumpCmd)
+	versionCmd.AddCommand(versionSetCmd)
+
+	versionCmd.PersistentFlags().Bool("tag", false, "commit the version files and create a v<version> git tag")
+	versionSetCmd.Flags().Int("build", 0, "build number to set (default is the current build number plus one)")
+}
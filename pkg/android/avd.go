@@ -0,0 +1,328 @@
+package android
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// DefaultBootTimeout is how long StartAVD waits for an emulator to finish booting.
+const DefaultBootTimeout = 5 * time.Minute
+
+// SDK drives the tools of an Android SDK installation: sdkmanager, avdmanager, emulator and adb.
+type SDK struct {
+	Root string
+	// Env is added to the environment of every tool, e.g. the JDK that avdmanager and sdkmanager run on.
+	Env  []string
+	Exec utils.Executor
+	// PollInterval is how often StartAVD checks whether the emulator has booted.
+	PollInterval time.Duration
+}
+
+// AVDConfig describes an Android Virtual Device to create, set under android.avd in bob.yaml.
+type AVDConfig struct {
+	Name string
+	// SystemImage is the sdkmanager package of the system image, e.g. system-images;android-34;google_apis;arm64-v8a.
+	SystemImage string
+	// Device is the hardware profile from avdmanager list device, e.g. pixel_6. Empty uses the default profile.
+	Device string
+}
+
+// AVD is an Android Virtual Device. Serial is the adb serial of its emulator, empty when it is not running.
+type AVD struct {
+	Name   string
+	Serial string
+}
+
+// StartOptions controls how StartAVD launches an emulator.
+type StartOptions struct {
+	Headless bool
+	ColdBoot bool
+	Timeout  time.Duration
+	// LogFile receives the emulator output, defaults to bob-emulator-<name>.log in the temp directory.
+	LogFile string
+}
+
+// FindSDKRoot returns the Android SDK from ANDROID_HOME or ANDROID_SDK_ROOT, or the default location of Android Studio.
+func FindSDKRoot(homeDir string) (string, error) {
+	candidates := []string{
+		os.Getenv("ANDROID_HOME"),
+		os.Getenv("ANDROID_SDK_ROOT"),
+		filepath.Join(homeDir, "Library", "Android", "sdk"),
+		filepath.Join(homeDir, "Android", "Sdk"),
+	}
+	for _, candidate := range candidates {
+		if candidate == "" {
+			continue
+		}
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no Android SDK found, set ANDROID_HOME or run bob setup")
+}
+
+// NewSDK returns an SDK that runs the tools of the SDK at root on the machine.
+func NewSDK(root string, env []string) *SDK {
+	return &SDK{Root: root, Env: env, Exec: utils.SystemExecutor{}, PollInterval: 2 * time.Second}
+}
+
+// Validate checks that the config names an AVD and a system image.
+func (c AVDConfig) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("no AVD name set, pass one or set android.avd.name in bob.yaml")
+	}
+	if c.SystemImage == "" {
+		return fmt.Errorf("no system image set, pass --image or set android.avd.image in bob.yaml")
+	}
+	if !strings.HasPrefix(c.SystemImage, "system-images;") || len(strings.Split(c.SystemImage, ";")) != 4 {
+		return fmt.Errorf("invalid system image %q, expected system-images;android-<api>;<tag>;<abi>", c.SystemImage)
+	}
+	return nil
+}
+
+// DefaultAVDName returns the name bob gives an AVD of the system image, e.g. bob_android-34_google_apis.
+func DefaultAVDName(systemImage string) string {
+	parts := strings.Split(systemImage, ";")
+	if len(parts) < 3 {
+		return ""
+	}
+	return "bob_" + parts[1] + "_" + parts[2]
+}
+
+// tool returns the path of an SDK tool, falling back to the PATH when the SDK does not have it.
+func (s *SDK) tool(name string, locations ...string) string {
+	for _, location := range locations {
+		path := filepath.Join(s.Root, location, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return name
+}
+
+func (s *SDK) avdmanager() string {
+	return s.tool("avdmanager", "cmdline-tools/latest/bin", "cmdline-tools/latest/cmdline-tools/bin", "tools/bin")
+}
+
+func (s *SDK) sdkmanager() string {
+	return s.tool("sdkmanager", "cmdline-tools/latest/bin", "cmdline-tools/latest/cmdline-tools/bin", "tools/bin")
+}
+
+func (s *SDK) emulator() string {
+	return s.tool("emulator", "emulator")
+}
+
+func (s *SDK) adb() string {
+	return s.tool("adb", "platform-tools")
+}
+
+func (s *SDK) env() []string {
+	return append([]string{"ANDROID_HOME=" + s.Root, "ANDROID_SDK_ROOT=" + s.Root}, s.Env...)
+}
+
+// CreateAVD installs the system image of the config when it is missing and creates the AVD from it.
+// With force, an existing AVD of the same name is replaced.
+func (s *SDK) CreateAVD(config AVDConfig, force bool) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	imageDir := filepath.Join(append([]string{s.Root}, strings.Split(config.SystemImage, ";")...)...)
+	if _, err := os.Stat(imageDir); os.IsNotExist(err) {
+		fmt.Printf("Installing %s...\n", config.SystemImage)
+		if err := s.Exec.Run("", s.env(), s.sdkmanager(), "--install", config.SystemImage); err != nil {
+			return fmt.Errorf("failed to install %s, accept the SDK licenses with sdkmanager --licenses if it asked for them: %v", config.SystemImage, err)
+		}
+	}
+
+	if !force {
+		avds, err := s.ListAVDs()
+		if err != nil {
+			return err
+		}
+		for _, avd := range avds {
+			if avd.Name == config.Name {
+				return fmt.Errorf("AVD %s already exists, pass --force to replace it", config.Name)
+			}
+		}
+	}
+
+	args := []string{"create", "avd", "--name", config.Name, "--package", config.SystemImage}
+	if config.Device != "" {
+		args = append(args, "--device", config.Device)
+	}
+	if force {
+		args = append(args, "--force")
+	}
+	if err := s.Exec.Run("", s.env(), s.avdmanager(), args...); err != nil {
+		return fmt.Errorf("failed to create AVD %s: %v", config.Name, err)
+	}
+	return nil
+}
+
+// ListAVDs returns the AVDs of the machine with the serials of those whose emulator is running.
+func (s *SDK) ListAVDs() ([]AVD, error) {
+	output, err := s.Exec.Output("", s.env(), s.emulator(), "-list-avds")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AVDs: %v", err)
+	}
+
+	running, err := s.RunningEmulators()
+	if err != nil {
+		return nil, err
+	}
+
+	var avds []AVD
+	for _, line := range strings.Split(output, "\n") {
+		name := strings.TrimSpace(line)
+		// The emulator prints INFO and WARNING lines in between the AVD names.
+		if name == "" || strings.ContainsAny(name, " |") {
+			continue
+		}
+		avds = append(avds, AVD{Name: name, Serial: running[name]})
+	}
+	return avds, nil
+}
+
+// RunningEmulators returns the adb serials of the running emulators by the name of their AVD.
+func (s *SDK) RunningEmulators() (map[string]string, error) {
+	output, err := s.Exec.Output("", s.env(), s.adb(), "devices")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices with adb: %v", err)
+	}
+
+	running := map[string]string{}
+	for _, serial := range ParseADBDevices(output) {
+		if !strings.HasPrefix(serial, "emulator-") {
+			continue
+		}
+		name, err := s.Exec.Output("", s.env(), s.adb(), "-s", serial, "emu", "avd", "name")
+		if err != nil {
+			// An emulator that is still starting does not answer console commands yet.
+			continue
+		}
+		if lines := strings.Fields(name); len(lines) > 0 {
+			running[lines[0]] = serial
+		}
+	}
+	return running, nil
+}
+
+// ParseADBDevices returns the serials of the devices that adb devices lists as connected and ready.
+func ParseADBDevices(output string) []string {
+	var serials []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[1] == "device" {
+			serials = append(serials, fields[0])
+		}
+	}
+	return serials
+}
+
+// StartAVD launches the emulator of the AVD in the background and waits until Android reports sys.boot_completed.
+// It returns the adb serial of the emulator. An AVD that is already running is left as it is.
+func (s *SDK) StartAVD(name string, options StartOptions) (string, error) {
+	avds, err := s.ListAVDs()
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	found := false
+	for _, avd := range avds {
+		if avd.Name == name {
+			if avd.Serial != "" {
+				return avd.Serial, nil
+			}
+			found = true
+		}
+		names = append(names, avd.Name)
+	}
+	if !found {
+		if len(names) == 0 {
+			return "", fmt.Errorf("no AVDs found, create one with bob android avd create")
+		}
+		return "", unknownNameError("AVD", name, names)
+	}
+
+	logFile := options.LogFile
+	if logFile == "" {
+		logFile = filepath.Join(os.TempDir(), "bob-emulator-"+name+".log")
+	}
+	args := []string{"-avd", name, "-netdelay", "none", "-netspeed", "full"}
+	if options.Headless {
+		args = append(args, "-no-window", "-no-audio", "-no-boot-anim")
+	}
+	if options.ColdBoot {
+		args = append(args, "-no-snapshot-load")
+	}
+	if err := s.Exec.Start("", s.env(), logFile, s.emulator(), args...); err != nil {
+		return "", fmt.Errorf("failed to start the emulator of %s: %v", name, err)
+	}
+
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = DefaultBootTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	serial := ""
+	for {
+		if serial == "" {
+			running, err := s.RunningEmulators()
+			if err != nil {
+				return "", err
+			}
+			serial = running[name]
+		}
+		if serial != "" {
+			booted, _ := s.Exec.Output("", s.env(), s.adb(), "-s", serial, "shell", "getprop", "sys.boot_completed")
+			if strings.TrimSpace(booted) == "1" {
+				return serial, nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return serial, fmt.Errorf("%s did not finish booting within %s, see %s", name, timeout, logFile)
+		}
+		time.Sleep(s.PollInterval)
+	}
+}
+
+// StopAVD shuts down the running emulator of the AVD.
+func (s *SDK) StopAVD(name string) error {
+	running, err := s.RunningEmulators()
+	if err != nil {
+		return err
+	}
+	serial, ok := running[name]
+	if !ok {
+		return fmt.Errorf("AVD %s is not running", name)
+	}
+
+	if err := s.Exec.Run("", s.env(), s.adb(), "-s", serial, "emu", "kill"); err != nil {
+		return fmt.Errorf("failed to stop %s (%s): %v", name, serial, err)
+	}
+	return nil
+}
+
+// DeleteAVD deletes the AVD, stopping its emulator first when it is running.
+func (s *SDK) DeleteAVD(name string) error {
+	if running, err := s.RunningEmulators(); err == nil {
+		if _, ok := running[name]; ok {
+			if err := s.StopAVD(name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.Exec.Run("", s.env(), s.avdmanager(), "delete", "avd", "--name", name); err != nil {
+		return fmt.Errorf("failed to delete AVD %s: %v", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package android
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeExecutor records the commands it is asked to run, as the base name of the tool followed by its arguments,
+// and answers them with respond. Commands started in the background are recorded with a "start " prefix.
+type fakeExecutor struct {
+	calls   []string
+	respond func(call string) (string, error)
+}
+
+func (f *fakeExecutor) record(prefix, command string, args []string) string {
+	call := prefix + strings.Join(append([]string{filepath.Base(command)}, args...), " ")
+	f.calls = append(f.calls, call)
+	return call
+}
+
+func (f *fakeExecutor) answer(call string) (string, error) {
+	if f.respond == nil {
+		return "", nil
+	}
+	return f.respond(call)
+}
+
+func (f *fakeExecutor) Run(dir string, env []string, command string, args ...string) error {
+	_, err := f.answer(f.record("", command, args))
+	return err
+}
+
+func (f *fakeExecutor) Output(dir string, env []string, command string, args ...string) (string, error) {
+	return f.answer(f.record("", command, args))
+}
+
+func (f *fakeExecutor) Capture(dir string, env []string, command string, args ...string) (string, error) {
+	return f.answer(f.record("", command, args))
+}
+
+func (f *fakeExecutor) Start(dir string, env []string, logFile string, command string, args ...string) error {
+	_, err := f.answer(f.record("start ", command, args))
+	return err
+}
+
+// ran reports whether a command starting with prefix was run.
+func (f *fakeExecutor) ran(prefix string) bool {
+	for _, call := range f.calls {
+		if strings.HasPrefix(call, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// count returns how many recorded commands start with prefix.
+func (f *fakeExecutor) count(prefix string) int {
+	n := 0
+	for _, call := range f.calls {
+		if strings.HasPrefix(call, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func newFakeSDK(t *testing.T, exec *fakeExecutor) *SDK {
+	return &SDK{Root: t.TempDir(), Exec: exec, PollInterval: time.Millisecond}
+}
+
+const testImage = "system-images;android-34;google_apis;arm64-v8a"
+
+func TestCreateAVD(t *testing.T) {
+	exec := &fakeExecutor{respond: func(call string) (string, error) {
+		if call == "emulator -list-avds" {
+			return "INFO    | Storing crashdata in: /tmp/android/emu-crash.db\nPixel_7\n", nil
+		}
+		return "", nil
+	}}
+	sdk := newFakeSDK(t, exec)
+
+	config := AVDConfig{Name: "bob_android-34_google_apis", SystemImage: testImage, Device: "pixel_6"}
+	if err := sdk.CreateAVD(config, false); err != nil {
+		t.Fatal(err)
+	}
+	if !exec.ran("sdkmanager --install " + testImage) {
+		t.Errorf("CreateAVD() did not install the missing system image, ran %q", exec.calls)
+	}
+	want := "avdmanager create avd --name bob_android-34_google_apis --package " + testImage + " --device pixel_6"
+	if last := exec.calls[len(exec.calls)-1]; last != want {
+		t.Errorf("CreateAVD() ran %q last, want %q", last, want)
+	}
+
+	// An existing AVD is only replaced with force.
+	config.Name = "Pixel_7"
+	if err := sdk.CreateAVD(config, false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("CreateAVD() of an existing AVD = %v, want an already exists error", err)
+	}
+	exec.calls = nil
+	if err := sdk.CreateAVD(config, true); err != nil {
+		t.Fatal(err)
+	}
+	if last := exec.calls[len(exec.calls)-1]; !strings.HasSuffix(last, " --force") {
+		t.Errorf("CreateAVD() with force ran %q, want --force", last)
+	}
+	if exec.count("emulator -list-avds") != 0 {
+		t.Errorf("CreateAVD() with force listed the AVDs: %q", exec.calls)
+	}
+}
+
+func TestCreateAVDFailures(t *testing.T) {
+	exec := &fakeExecutor{}
+	sdk := newFakeSDK(t, exec)
+	if err := sdk.CreateAVD(AVDConfig{Name: "test", SystemImage: "android-34"}, false); err == nil {
+		t.Error("CreateAVD() with an invalid system image succeeded")
+	}
+	if len(exec.calls) != 0 {
+		t.Errorf("CreateAVD() with an invalid config ran %q", exec.calls)
+	}
+
+	exec.respond = func(call string) (string, error) {
+		if strings.HasPrefix(call, "sdkmanager") {
+			return "", fmt.Errorf("exit status 1")
+		}
+		return "", nil
+	}
+	err := sdk.CreateAVD(AVDConfig{Name: "test", SystemImage: testImage}, false)
+	if err == nil || !strings.Contains(err.Error(), "sdkmanager --licenses") {
+		t.Errorf("CreateAVD() = %v, want a hint to accept the licenses", err)
+	}
+}
+
+// bootingEmulator fakes an emulator of the AVD Pixel_7 that appears in adb once it was started and reports
+// sys.boot_completed after bootPolls checks. A negative bootPolls never finishes booting.
+func bootingEmulator(bootPolls int) func(call string) (string, error) {
+	running, polls := false, 0
+	return func(call string) (string, error) {
+		switch {
+		case strings.HasPrefix(call, "start emulator -avd Pixel_7 "):
+			running = true
+		case call == "emulator -list-avds":
+			return "Pixel_7\nPixel_Tablet\n", nil
+		case strings.HasPrefix(call, "adb devices"):
+			if running {
+				return "List of devices attached\nemulator-5554\tdevice product:sdk_gphone64_arm64 model:sdk_gphone64_arm64 transport_id:1\n\n", nil
+			}
+			return "List of devices attached\n\n", nil
+		case call == "adb -s emulator-5554 emu avd name":
+			return "Pixel_7\nOK\n", nil
+		case call == "adb -s emulator-5554 shell getprop sys.boot_completed":
+			polls++
+			if bootPolls >= 0 && polls > bootPolls {
+				return "1\n", nil
+			}
+			return "\n", nil
+		}
+		return "", nil
+	}
+}
+
+func TestStartAVD(t *testing.T) {
+	exec := &fakeExecutor{respond: bootingEmulator(2)}
+	sdk := newFakeSDK(t, exec)
+
+	serial, err := sdk.StartAVD("Pixel_7", StartOptions{Headless: true, ColdBoot: true, LogFile: "emulator.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if serial != "emulator-5554" {
+		t.Errorf("StartAVD() = %q, want emulator-5554", serial)
+	}
+
+	var start []string
+	for _, call := range exec.calls {
+		if strings.HasPrefix(call, "start ") {
+			start = append(start, call)
+		}
+	}
+	want := []string{"start emulator -avd Pixel_7 -netdelay none -netspeed full -no-window -no-audio -no-boot-anim -no-snapshot-load"}
+	if !reflect.DeepEqual(start, want) {
+		t.Errorf("StartAVD() started %q, want %q", start, want)
+	}
+	if polls := exec.count("adb -s emulator-5554 shell getprop"); polls != 3 {
+		t.Errorf("StartAVD() checked sys.boot_completed %d times, want 3", polls)
+	}
+
+	// A running AVD is not started again.
+	exec.calls = nil
+	if serial, err := sdk.StartAVD("Pixel_7", StartOptions{}); err != nil || serial != "emulator-5554" {
+		t.Errorf("StartAVD() of a running AVD = %q, %v, want emulator-5554", serial, err)
+	}
+	if exec.ran("start ") {
+		t.Errorf("StartAVD() of a running AVD started it again: %q", exec.calls)
+	}
+}
+
+func TestStartAVDTimeout(t *testing.T) {
+	exec := &fakeExecutor{respond: bootingEmulator(-1)}
+	sdk := newFakeSDK(t, exec)
+
+	serial, err := sdk.StartAVD("Pixel_7", StartOptions{Timeout: 20 * time.Millisecond, LogFile: "emulator.log"})
+	if err == nil || !strings.Contains(err.Error(), "did not finish booting") || !strings.Contains(err.Error(), "emulator.log") {
+		t.Errorf("StartAVD() = %v, want a boot timeout pointing at the log", err)
+	}
+	if serial != "emulator-5554" {
+		t.Errorf("StartAVD() = %q, want the serial of the emulator that did not boot", serial)
+	}
+}
+
+func TestStartAVDUnknown(t *testing.T) {
+	exec := &fakeExecutor{respond: bootingEmulator(0)}
+	sdk := newFakeSDK(t, exec)
+
+	_, err := sdk.StartAVD("Pixel_8", StartOptions{})
+	if err == nil || !strings.Contains(err.Error(), "Pixel_7") {
+		t.Errorf("StartAVD() of an unknown AVD = %v, want an error listing the AVDs", err)
+	}
+	if exec.ran("start ") {
+		t.Errorf("StartAVD() of an unknown AVD started an emulator: %q", exec.calls)
+	}
+}
@@ -0,0 +1,196 @@
+package android
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+var (
+	wrapperDistributionRegexp = regexp.MustCompile(`(?m)^distributionUrl=.*gradle-([0-9][^-/]*)-(?:all|bin)\.zip`)
+	agpClasspathRegexp        = regexp.MustCompile(`com\.android\.tools\.build:gradle:([0-9][^"')]*)`)
+	agpPluginRegexp           = regexp.MustCompile(`id\s*\(?\s*["']com\.android\.application["']\s*\)?\s*version\s*["']([0-9][^"']*)["']`)
+	agpVersionCatalogRegexp   = regexp.MustCompile(`(?m)^\s*(?:agp|androidGradlePlugin|android-gradle-plugin)\s*=\s*"([0-9][^"]*)"`)
+	javaVersionRegexp         = regexp.MustCompile(`version "([^"]+)"`)
+)
+
+// agpRequirement is the oldest Gradle and JDK a line of the Android Gradle plugin runs with.
+type agpRequirement struct {
+	AGP       string
+	MinGradle string
+	MinJDK    int
+}
+
+// agpRequirements follows the compatibility tables of the Android Gradle plugin release notes, oldest first.
+var agpRequirements = []agpRequirement{
+	{"4.2", "6.7.1", 8},
+	{"7.0", "7.0", 11},
+	{"7.1", "7.2", 11},
+	{"7.2", "7.3.3", 11},
+	{"7.3", "7.4", 11},
+	{"7.4", "7.5", 11},
+	{"8.0", "8.0", 17},
+	{"8.1", "8.0", 17},
+	{"8.2", "8.2", 17},
+	{"8.3", "8.4", 17},
+	{"8.4", "8.6", 17},
+	{"8.5", "8.7", 17},
+	{"8.6", "8.7", 17},
+	{"8.7", "8.9", 17},
+	{"8.8", "8.10.2", 17},
+	{"8.9", "8.11.1", 17},
+	{"8.10", "8.11.1", 17},
+	{"8.11", "8.13", 17},
+	{"8.12", "8.13", 17},
+	{"8.13", "8.13", 17},
+	{"9.0", "9.1.0", 17},
+}
+
+// gradleJDKSupport lists the first Gradle version that runs on each JDK, oldest first.
+var gradleJDKSupport = []struct {
+	JDK       int
+	MinGradle string
+}{
+	{8, "2.0"},
+	{11, "5.0"},
+	{17, "7.3"},
+	{21, "8.5"},
+	{22, "8.8"},
+	{23, "8.10"},
+	{24, "8.14"},
+	{25, "9.1.0"},
+}
+
+// GradleWrapperVersion returns the Gradle version the project's wrapper downloads.
+func GradleWrapperVersion(projectDir string) (string, error) {
+	path := filepath.Join(projectDir, "android", "gradle", "wrapper", "gradle-wrapper.properties")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the Gradle wrapper: %v", err)
+	}
+
+	match := wrapperDistributionRegexp.FindSubmatch(data)
+	if match == nil {
+		return "", fmt.Errorf("no Gradle distribution found in %s", path)
+	}
+	return string(match[1]), nil
+}
+
+// AGPVersion returns the version of the Android Gradle plugin the project builds with. Since React Native 0.71
+// the root build.gradle leaves the version out and the React Native Gradle plugin's version catalog sets it.
+func AGPVersion(projectDir string) (string, error) {
+	androidDir := filepath.Join(projectDir, "android")
+	sources := []struct {
+		path   string
+		regexp *regexp.Regexp
+	}{
+		{filepath.Join(androidDir, "build.gradle"), agpClasspathRegexp},
+		{filepath.Join(androidDir, "build.gradle.kts"), agpClasspathRegexp},
+		{filepath.Join(androidDir, "settings.gradle"), agpPluginRegexp},
+		{filepath.Join(androidDir, "settings.gradle.kts"), agpPluginRegexp},
+		{filepath.Join(androidDir, "gradle", "libs.versions.toml"), agpVersionCatalogRegexp},
+		{filepath.Join(projectDir, "node_modules", "@react-native", "gradle-plugin", "gradle", "libs.versions.toml"), agpVersionCatalogRegexp},
+		{filepath.Join(projectDir, "node_modules", "react-native-gradle-plugin", "gradle", "libs.versions.toml"), agpVersionCatalogRegexp},
+		{filepath.Join(projectDir, "node_modules", "react-native-gradle-plugin", "build.gradle.kts"), agpClasspathRegexp},
+	}
+
+	for _, source := range sources {
+		data, err := os.ReadFile(source.path)
+		if err != nil {
+			continue
+		}
+		content := string(data)
+		if !strings.HasSuffix(source.path, ".toml") {
+			content = stripGradleComments(content)
+		}
+		if match := source.regexp.FindStringSubmatch(content); match != nil {
+			return match[1], nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find the Android Gradle plugin version, run npm install if the project uses the React Native Gradle plugin")
+}
+
+// JavaVersion returns the version of the JDK in javaHome, or of the java on the PATH when javaHome is empty.
+func JavaVersion(javaHome string) (string, error) {
+	java := "java"
+	if javaHome != "" {
+		java = filepath.Join(javaHome, "bin", "java")
+	}
+
+	output, err := utils.RunCommandWithOutput(java, "-version")
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s -version: %v", java, err)
+	}
+
+	match := javaVersionRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return "", fmt.Errorf("could not read the Java version from %q", strings.TrimSpace(output))
+	}
+	return match[1], nil
+}
+
+// JavaMajorVersion returns the feature release of a Java version, reading 1.8.0_392 as 8.
+func JavaMajorVersion(version string) int {
+	return utils.MajorVersion(strings.TrimPrefix(version, "1."))
+}
+
+// MaxJDK returns the newest JDK the given Gradle version runs on.
+func MaxJDK(gradleVersion string) int {
+	newest := 0
+	for _, support := range gradleJDKSupport {
+		if utils.CompareVersions(gradleVersion, support.MinGradle) >= 0 {
+			newest = support.JDK
+		}
+	}
+	return newest
+}
+
+// requirementFor returns the requirements of the AGP release line of the given version.
+func requirementFor(agpVersion string) *agpRequirement {
+	var found *agpRequirement
+	for i := range agpRequirements {
+		if utils.CompareVersions(agpVersion, agpRequirements[i].AGP) >= 0 {
+			found = &agpRequirements[i]
+		}
+	}
+	return found
+}
+
+// RecommendedJDK returns the JDK to build the project with: the oldest JDK the Android Gradle plugin accepts.
+func RecommendedJDK(agpVersion string) int {
+	if requirement := requirementFor(agpVersion); requirement != nil {
+		return requirement.MinJDK
+	}
+	return 8
+}
+
+// CheckCompatibility checks the Android Gradle plugin, Gradle wrapper and JDK versions against each other
+// and says what to change when they do not fit.
+func CheckCompatibility(agpVersion, gradleVersion string, jdk int) error {
+	requirement := requirementFor(agpVersion)
+	if requirement == nil {
+		return fmt.Errorf("Android Gradle plugin %s is older than bob supports", agpVersion)
+	}
+
+	if utils.CompareVersions(gradleVersion, requirement.MinGradle) < 0 {
+		return fmt.Errorf("Android Gradle plugin %s needs Gradle %s or newer but the wrapper uses %s, run ./gradlew wrapper --gradle-version %s in android/",
+			agpVersion, requirement.MinGradle, gradleVersion, requirement.MinGradle)
+	}
+
+	maxJDK := MaxJDK(gradleVersion)
+	switch {
+	case jdk < requirement.MinJDK:
+		return fmt.Errorf("Android Gradle plugin %s needs JDK %d or newer but the build uses JDK %d, install JDK %d (brew install openjdk@%d) and run bob java use %d",
+			agpVersion, requirement.MinJDK, jdk, requirement.MinJDK, requirement.MinJDK, requirement.MinJDK)
+	case jdk > maxJDK:
+		return fmt.Errorf("Gradle %s does not run on JDK %d, install JDK %d (brew install openjdk@%d) and run bob java use %d",
+			gradleVersion, jdk, requirement.MinJDK, requirement.MinJDK, requirement.MinJDK)
+	}
+
+	return nil
+}
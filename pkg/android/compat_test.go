@@ -0,0 +1,159 @@
+package android
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGradleWrapperVersion(t *testing.T) {
+	tests := map[string]string{
+		"classpath": "8.13",
+		"plugin":    "9.1.0",
+		// The commented-out distributionUrl is skipped.
+		"catalog": "8.6",
+	}
+	for project, want := range tests {
+		if got, err := GradleWrapperVersion(filepath.Join("testdata", "compat", project)); err != nil || got != want {
+			t.Errorf("GradleWrapperVersion(%s) = %q, %v, want %q", project, got, err, want)
+		}
+	}
+
+	if _, err := GradleWrapperVersion(filepath.Join("testdata", "compat", "no-wrapper")); err == nil || !strings.Contains(err.Error(), "failed to read the Gradle wrapper") {
+		t.Errorf("GradleWrapperVersion() without a wrapper = %v", err)
+	}
+}
+
+func TestAGPVersion(t *testing.T) {
+	tests := map[string]string{
+		// The classpath in build.gradle, not the commented-out ones.
+		"classpath": "8.11.1",
+		// The plugin version in settings.gradle.kts.
+		"plugin": "9.0.0",
+		// The version catalog of the React Native Gradle plugin, as build.gradle leaves the version out.
+		"catalog": "8.2.1",
+	}
+	for project, want := range tests {
+		if got, err := AGPVersion(filepath.Join("testdata", "compat", project)); err != nil || got != want {
+			t.Errorf("AGPVersion(%s) = %q, %v, want %q", project, got, err, want)
+		}
+	}
+
+	if _, err := AGPVersion(filepath.Join("testdata", "compat", "no-wrapper")); err == nil || !strings.Contains(err.Error(), "run npm install") {
+		t.Errorf("AGPVersion() without a version = %v, want an error suggesting npm install", err)
+	}
+}
+
+func TestRequirementFor(t *testing.T) {
+	tests := []struct {
+		agp       string
+		minGradle string
+		minJDK    int
+	}{
+		{agp: "4.2.2", minGradle: "6.7.1", minJDK: 8},
+		{agp: "7.4.2", minGradle: "7.5", minJDK: 11},
+		{agp: "8.1.4", minGradle: "8.0", minJDK: 17},
+		{agp: "8.7.3", minGradle: "8.9", minJDK: 17},
+		{agp: "8.8.0", minGradle: "8.10.2", minJDK: 17},
+		{agp: "8.10.1", minGradle: "8.11.1", minJDK: 17},
+		{agp: "8.11.1", minGradle: "8.13", minJDK: 17},
+		{agp: "8.13.0", minGradle: "8.13", minJDK: 17},
+		{agp: "9.0.0", minGradle: "9.1.0", minJDK: 17},
+	}
+	for _, test := range tests {
+		got := requirementFor(test.agp)
+		if got == nil || got.MinGradle != test.minGradle || got.MinJDK != test.minJDK {
+			t.Errorf("requirementFor(%s) = %+v, want Gradle %s and JDK %d", test.agp, got, test.minGradle, test.minJDK)
+		}
+	}
+
+	if got := requirementFor("3.6.4"); got != nil {
+		t.Errorf("requirementFor(3.6.4) = %+v, want none", got)
+	}
+}
+
+func TestRecommendedJDK(t *testing.T) {
+	tests := map[string]int{"3.6.4": 8, "4.2.2": 8, "7.4.2": 11, "8.2.1": 17, "9.0.0": 17}
+	for agp, want := range tests {
+		if got := RecommendedJDK(agp); got != want {
+			t.Errorf("RecommendedJDK(%s) = %d, want %d", agp, got, want)
+		}
+	}
+}
+
+func TestMaxJDK(t *testing.T) {
+	tests := map[string]int{
+		"1.12":   0,
+		"6.7.1":  11,
+		"7.5.1":  17,
+		"8.5":    21,
+		"8.10.2": 23,
+		"8.13":   23,
+		"8.14":   24,
+		"8.14.3": 24,
+		"9.0.0":  24,
+		"9.1.0":  25,
+	}
+	for gradle, want := range tests {
+		if got := MaxJDK(gradle); got != want {
+			t.Errorf("MaxJDK(%s) = %d, want %d", gradle, got, want)
+		}
+	}
+}
+
+func TestJavaMajorVersion(t *testing.T) {
+	tests := map[string]int{"1.8.0_392": 8, "11.0.21": 11, "17": 17, "25": 25}
+	for version, want := range tests {
+		if got := JavaMajorVersion(version); got != want {
+			t.Errorf("JavaMajorVersion(%s) = %d, want %d", version, got, want)
+		}
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		name   string
+		agp    string
+		gradle string
+		jdk    int
+		err    string
+	}{
+		{name: "React Native 0.74", agp: "8.2.1", gradle: "8.6", jdk: 17},
+		{name: "newest JDK the wrapper runs on", agp: "8.2.1", gradle: "8.6", jdk: 21},
+		{name: "AGP 8.11 on Gradle 8.14 and JDK 24", agp: "8.11.1", gradle: "8.14", jdk: 24},
+		{name: "AGP 9 on JDK 25", agp: "9.0.0", gradle: "9.1.0", jdk: 25},
+		{name: "too old AGP", agp: "3.6.4", gradle: "6.7.1", jdk: 8, err: "Android Gradle plugin 3.6.4 is older than bob supports"},
+		{
+			name: "wrapper too old for AGP 8.11", agp: "8.11.1", gradle: "8.9", jdk: 17,
+			err: "Android Gradle plugin 8.11.1 needs Gradle 8.13 or newer but the wrapper uses 8.9, run ./gradlew wrapper --gradle-version 8.13 in android/",
+		},
+		{
+			name: "wrapper too old for AGP 9", agp: "9.0.0", gradle: "8.14", jdk: 17,
+			err: "Android Gradle plugin 9.0.0 needs Gradle 9.1.0 or newer but the wrapper uses 8.14",
+		},
+		{
+			name: "JDK too old", agp: "8.2.1", gradle: "8.6", jdk: 11,
+			err: "Android Gradle plugin 8.2.1 needs JDK 17 or newer but the build uses JDK 11, install JDK 17 (brew install openjdk@17) and run bob java use 17",
+		},
+		{
+			name: "JDK too new", agp: "8.2.1", gradle: "8.6", jdk: 23,
+			err: "Gradle 8.6 does not run on JDK 23, install JDK 17 (brew install openjdk@17) and run bob java use 17",
+		},
+		{name: "JDK 25 on Gradle 8.14", agp: "8.11.1", gradle: "8.14", jdk: 25, err: "Gradle 8.14 does not run on JDK 25"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := CheckCompatibility(test.agp, test.gradle, test.jdk)
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("CheckCompatibility() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("CheckCompatibility() = %v, want an error containing %q", err, test.err)
+			}
+		})
+	}
+}
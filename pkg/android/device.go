@@ -0,0 +1,245 @@
+package android
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Device is a phone or emulator that adb sees.
+type Device struct {
+	Serial string
+	// State is device when the device is ready, or e.g. unauthorized or offline.
+	State   string
+	Model   string
+	Product string
+}
+
+// Emulator reports whether the device is an emulator rather than a connected phone.
+func (d Device) Emulator() bool {
+	return strings.HasPrefix(d.Serial, "emulator-")
+}
+
+func (d Device) String() string {
+	if d.Model == "" {
+		return d.Serial
+	}
+	return fmt.Sprintf("%s (%s)", d.Serial, strings.ReplaceAll(d.Model, "_", " "))
+}
+
+// ParseDevices parses the output of adb devices -l, in which each device is listed as its serial, its state and
+// key:value pairs such as model:Pixel_7 and transport_id:1.
+func ParseDevices(output string) []Device {
+	var devices []Device
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(line, "List of devices") || strings.HasPrefix(fields[0], "*") {
+			continue
+		}
+
+		device := Device{Serial: fields[0], State: fields[1]}
+		for _, field := range fields[2:] {
+			key, value, _ := strings.Cut(field, ":")
+			switch key {
+			case "model":
+				device.Model = value
+			case "product":
+				device.Product = value
+			}
+		}
+		devices = append(devices, device)
+	}
+	return devices
+}
+
+// SelectDevice picks the device to run on: the one whose serial or model matches query, or the only ready
+// device when query is empty. Devices that are not ready, e.g. unauthorized phones, are reported as such.
+func SelectDevice(devices []Device, query string) (Device, error) {
+	var ready []Device
+	for _, device := range devices {
+		if query != "" && device.Serial != query && !strings.EqualFold(strings.ReplaceAll(device.Model, "_", " "), strings.ReplaceAll(query, "_", " ")) {
+			continue
+		}
+		if device.State != "device" {
+			if query != "" {
+				return Device{}, fmt.Errorf("%s is %s, check the USB debugging prompt on the device", device, device.State)
+			}
+			continue
+		}
+		ready = append(ready, device)
+	}
+
+	switch {
+	case len(ready) == 1:
+		return ready[0], nil
+	case query != "" && len(ready) == 0:
+		return Device{}, fmt.Errorf("no device %q connected", query)
+	case len(ready) == 0:
+		return Device{}, fmt.Errorf("no device connected, connect a phone or start an emulator with bob android avd start")
+	}
+
+	var names []string
+	for _, device := range ready {
+		names = append(names, device.String())
+	}
+	return Device{}, fmt.Errorf("several devices are connected, pick one with --device: %s", strings.Join(names, ", "))
+}
+
+type androidManifest struct {
+	Package    string `xml:"package,attr"`
+	Activities []struct {
+		Name          string `xml:"http://schemas.android.com/apk/res/android name,attr"`
+		IntentFilters []struct {
+			Actions []struct {
+				Name string `xml:"http://schemas.android.com/apk/res/android name,attr"`
+			} `xml:"action"`
+			Categories []struct {
+				Name string `xml:"http://schemas.android.com/apk/res/android name,attr"`
+			} `xml:"category"`
+		} `xml:"intent-filter"`
+	} `xml:"application>activity"`
+}
+
+// LaunchActivity returns the fully qualified class of the activity the launcher starts, read from the manifest of
+// the variant's flavors or from the main manifest. namespace qualifies relative names when the manifest has no package.
+func LaunchActivity(projectDir string, variant Variant, namespace string) (string, error) {
+	srcDir := filepath.Join(projectDir, "android", "app", "src")
+	var manifests []string
+	for _, sourceSet := range append([]string{variant.Name()}, variant.Flavors...) {
+		manifests = append(manifests, filepath.Join(srcDir, sourceSet, "AndroidManifest.xml"))
+	}
+	manifests = append(manifests, filepath.Join(srcDir, "main", "AndroidManifest.xml"))
+
+	for _, path := range manifests {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		activity, pkg, err := ParseLaunchActivity(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		if activity == "" {
+			continue
+		}
+
+		if pkg == "" {
+			pkg = namespace
+		}
+		switch {
+		case strings.HasPrefix(activity, "."):
+			return pkg + activity, nil
+		case !strings.Contains(activity, "."):
+			return pkg + "." + activity, nil
+		default:
+			return activity, nil
+		}
+	}
+
+	return "", fmt.Errorf("no activity with the MAIN action and LAUNCHER category found in the manifests of %s", variant.Name())
+}
+
+// ParseLaunchActivity returns the name of the launcher activity declared in an AndroidManifest.xml, as written
+// in the manifest, and the package of the manifest.
+func ParseLaunchActivity(data []byte) (string, string, error) {
+	var manifest androidManifest
+	if err := xml.Unmarshal(data, &manifest); err != nil {
+		return "", "", err
+	}
+
+	for _, activity := range manifest.Activities {
+		for _, filter := range activity.IntentFilters {
+			main, launcher := false, false
+			for _, action := range filter.Actions {
+				main = main || action.Name == "android.intent.action.MAIN"
+			}
+			for _, category := range filter.Categories {
+				launcher = launcher || category.Name == "android.intent.category.LAUNCHER"
+			}
+			if main && launcher {
+				return activity.Name, manifest.Package, nil
+			}
+		}
+	}
+	return "", manifest.Package, nil
+}
+
+// FindAPK returns the most recently built APK of the variant.
+func FindAPK(projectDir string, variant Variant) (string, error) {
+	dir := filepath.Join(projectDir, "android", "app", "build", "outputs", "apk")
+	if len(variant.Flavors) > 0 {
+		dir = filepath.Join(dir, Variant{Flavors: variant.Flavors}.Name())
+	}
+	dir = filepath.Join(dir, variant.BuildType)
+
+	apks, _ := filepath.Glob(filepath.Join(dir, "*.apk"))
+	if len(apks) == 0 {
+		return "", fmt.Errorf("no APK of %s found in %s, build it first", variant.Name(), dir)
+	}
+	sort.Slice(apks, func(i, j int) bool {
+		return modTime(apks[i]).After(modTime(apks[j]))
+	})
+	return apks[0], nil
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// Devices returns the devices adb sees, ready or not.
+func (s *SDK) Devices() ([]Device, error) {
+	output, err := s.Exec.Output("", s.env(), s.adb(), "devices", "-l")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices with adb: %v", err)
+	}
+	return ParseDevices(output), nil
+}
+
+// Install installs the APK on the device, replacing the installed version of the app.
+func (s *SDK) Install(serial, apk string) error {
+	if err := s.Exec.Run("", s.env(), s.adb(), "-s", serial, "install", "-r", apk); err != nil {
+		return fmt.Errorf("failed to install %s on %s: %v", filepath.Base(apk), serial, err)
+	}
+	return nil
+}
+
+// Launch starts the activity of the app on the device and clears the log first, so that Logcat only shows this run.
+func (s *SDK) Launch(serial, applicationID, activity string) error {
+	s.Exec.Output("", s.env(), s.adb(), "-s", serial, "logcat", "-c")
+
+	component := applicationID + "/" + activity
+	if err := s.Exec.Run("", s.env(), s.adb(), "-s", serial, "shell", "am", "start", "-n", component); err != nil {
+		return fmt.Errorf("failed to launch %s on %s: %v", component, serial, err)
+	}
+	return nil
+}
+
+// AppPID waits for the process of the app to come up on the device and returns its pid.
+func (s *SDK) AppPID(serial, applicationID string, timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		output, err := s.Exec.Output("", s.env(), s.adb(), "-s", serial, "shell", "pidof", applicationID)
+		if fields := strings.Fields(output); err == nil && len(fields) > 0 {
+			return fields[0], nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("%s is not running on %s, it may have crashed on start", applicationID, serial)
+		}
+		time.Sleep(s.PollInterval)
+	}
+}
+
+// Logcat streams the log of the process with the given pid until it is interrupted. filters are logcat filter
+// specs such as ReactNativeJS:V *:S.
+func (s *SDK) Logcat(serial, pid string, filters []string) error {
+	args := append([]string{"-s", serial, "logcat", "-v", "color", "--pid=" + pid}, filters...)
+	return s.Exec.Run("", s.env(), s.adb(), args...)
+}
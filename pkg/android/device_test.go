@@ -0,0 +1,80 @@
+package android
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseDevices(t *testing.T) {
+	devices := ParseDevices(readFixture(t, "adb-devices-l.txt"))
+	want := []Device{
+		{Serial: "emulator-5554", State: "device", Model: "sdk_gphone64_arm64", Product: "sdk_gphone64_arm64"},
+		{Serial: "R5CT32ABCDE", State: "unauthorized"},
+		{Serial: "0A051FDD4003BK", State: "offline"},
+		{Serial: "28041FDH200ABC", State: "device", Model: "Pixel_7", Product: "panther"},
+	}
+	if !reflect.DeepEqual(devices, want) {
+		t.Errorf("ParseDevices() = %+v, want %+v", devices, want)
+	}
+	if !devices[0].Emulator() || devices[3].Emulator() {
+		t.Error("Emulator() should only report emulator- serials")
+	}
+	if got := devices[3].String(); got != "28041FDH200ABC (Pixel 7)" {
+		t.Errorf("String() = %q, want the model with spaces", got)
+	}
+}
+
+func TestParseADBDevices(t *testing.T) {
+	serials := ParseADBDevices(readFixture(t, "adb-devices.txt"))
+	if want := []string{"emulator-5554", "28041FDH200ABC"}; !reflect.DeepEqual(serials, want) {
+		t.Errorf("ParseADBDevices() = %q, want %q", serials, want)
+	}
+}
+
+func TestSelectDevice(t *testing.T) {
+	devices := ParseDevices(readFixture(t, "adb-devices-l.txt"))
+
+	tests := []struct {
+		name    string
+		devices []Device
+		query   string
+		want    string
+		err     string
+	}{
+		{name: "several ready", devices: devices, err: "several devices are connected"},
+		{name: "only ready", devices: devices[:3], want: "emulator-5554"},
+		{name: "by serial", devices: devices, query: "emulator-5554", want: "emulator-5554"},
+		{name: "by model", devices: devices, query: "pixel 7", want: "28041FDH200ABC"},
+		{name: "unauthorized", devices: devices, query: "R5CT32ABCDE", err: "is unauthorized"},
+		{name: "offline", devices: devices, query: "0A051FDD4003BK", err: "is offline"},
+		{name: "unknown", devices: devices, query: "Pixel_8", err: `no device "Pixel_8" connected`},
+		{name: "none ready", devices: devices[1:3], err: "no device connected"},
+		{name: "none", err: "no device connected"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			device, err := SelectDevice(test.devices, test.query)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("SelectDevice(%q) = %v, %v, want an error containing %q", test.query, device, err, test.err)
+				}
+				return
+			}
+			if err != nil || device.Serial != test.want {
+				t.Errorf("SelectDevice(%q) = %v, %v, want %s", test.query, device, err, test.want)
+			}
+		})
+	}
+}
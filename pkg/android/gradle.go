@@ -0,0 +1,428 @@
+package android
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// Flavor is a product flavor declared in the app's build.gradle.
+type Flavor struct {
+	Name                string
+	Dimension           string
+	ApplicationID       string
+	ApplicationIDSuffix string
+}
+
+// GradleConfig holds the parts of android/app/build.gradle that decide which variants can be built.
+type GradleConfig struct {
+	Namespace     string
+	ApplicationID string
+	Dimensions    []string
+	Flavors       []Flavor
+	BuildTypes    []string
+	// BuildTypeSuffixes holds the applicationIdSuffix of the build types that set one.
+	BuildTypeSuffixes map[string]string
+}
+
+// Variant is a buildable combination of product flavors and a build type.
+type Variant struct {
+	Flavors   []string
+	BuildType string
+}
+
+var (
+	flavorDimensionsRegexp  = regexp.MustCompile(`flavorDimensions\s*(?:\+?=)?\s*\(?\s*((?:["'][^"']+["']\s*,?\s*)+)\)?`)
+	quotedRegexp            = regexp.MustCompile(`["']([^"']+)["']`)
+	dimensionRegexp         = regexp.MustCompile(`dimension\s*=?\s*["']([^"']+)["']`)
+	namespaceRegexp         = regexp.MustCompile(`\bnamespace\s*=?\s*["']([^"']+)["']`)
+	applicationIDRegexp     = regexp.MustCompile(`\bapplicationId\s*=?\s*["']([^"']+)["']`)
+	applicationSuffixRegexp = regexp.MustCompile(`\bapplicationIdSuffix\s*=?\s*["']([^"']+)["']`)
+	blockNameRegexp         = regexp.MustCompile(`^(?:(?:create|register|getByName|maybeCreate)\s*\(\s*)?["']?([A-Za-z_][\w]*)["']?\s*\)?\s*$`)
+)
+
+// AppBuildGradle returns the path of the app module's build script, preferring the Groovy one.
+func AppBuildGradle(projectDir string) string {
+	groovy := filepath.Join(projectDir, "android", "app", "build.gradle")
+	if _, err := os.Stat(groovy); err == nil {
+		return groovy
+	}
+	return groovy + ".kts"
+}
+
+// LoadGradleConfig reads and parses the app module's build script.
+func LoadGradleConfig(projectDir string) (*GradleConfig, error) {
+	path := AppBuildGradle(projectDir)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return ParseGradleConfig(string(data)), nil
+}
+
+// ParseGradleConfig extracts the application ID, flavor dimensions, product flavors and build types from a Groovy or
+// Kotlin build script.
+func ParseGradleConfig(script string) *GradleConfig {
+	script = stripGradleComments(script)
+	config := &GradleConfig{BuildTypeSuffixes: map[string]string{}}
+
+	if body, ok := gradleBlock(script, "defaultConfig"); ok {
+		if match := applicationIDRegexp.FindStringSubmatch(body); match != nil {
+			config.ApplicationID = match[1]
+		}
+	}
+	if match := namespaceRegexp.FindStringSubmatch(script); match != nil {
+		config.Namespace = match[1]
+	}
+
+	if match := flavorDimensionsRegexp.FindStringSubmatch(script); match != nil {
+		for _, dimension := range quotedRegexp.FindAllStringSubmatch(match[1], -1) {
+			config.Dimensions = append(config.Dimensions, dimension[1])
+		}
+	}
+
+	if body, ok := gradleBlock(script, "productFlavors"); ok {
+		for _, child := range gradleChildBlocks(body) {
+			flavor := Flavor{Name: child.name}
+			if match := dimensionRegexp.FindStringSubmatch(child.body); match != nil {
+				flavor.Dimension = match[1]
+			} else if len(config.Dimensions) == 1 {
+				flavor.Dimension = config.Dimensions[0]
+			}
+			if match := applicationIDRegexp.FindStringSubmatch(child.body); match != nil {
+				flavor.ApplicationID = match[1]
+			}
+			if match := applicationSuffixRegexp.FindStringSubmatch(child.body); match != nil {
+				flavor.ApplicationIDSuffix = match[1]
+			}
+			config.Flavors = append(config.Flavors, flavor)
+		}
+	}
+
+	// debug and release always exist, even when build.gradle does not configure them.
+	config.BuildTypes = []string{"debug", "release"}
+	if body, ok := gradleBlock(script, "buildTypes"); ok {
+		for _, child := range gradleChildBlocks(body) {
+			if child.name != "debug" && child.name != "release" {
+				config.BuildTypes = append(config.BuildTypes, child.name)
+			}
+			if match := applicationSuffixRegexp.FindStringSubmatch(child.body); match != nil {
+				config.BuildTypeSuffixes[child.name] = match[1]
+			}
+		}
+	}
+
+	return config
+}
+
+// FlavorNames returns the names of the declared product flavors.
+func (c *GradleConfig) FlavorNames() []string {
+	names := make([]string, 0, len(c.Flavors))
+	for _, flavor := range c.Flavors {
+		names = append(names, flavor.Name)
+	}
+	return names
+}
+
+// Variants returns every combination of one flavor per dimension with every build type.
+func (c *GradleConfig) Variants() []Variant {
+	combinations := [][]string{{}}
+	dimensions := c.Dimensions
+	if len(dimensions) == 0 && len(c.Flavors) > 0 {
+		dimensions = []string{""}
+	}
+
+	for _, dimension := range dimensions {
+		var next [][]string
+		for _, combination := range combinations {
+			for _, flavor := range c.Flavors {
+				if flavor.Dimension == dimension || dimension == "" {
+					next = append(next, append(append([]string{}, combination...), flavor.Name))
+				}
+			}
+		}
+		combinations = next
+	}
+
+	var variants []Variant
+	for _, combination := range combinations {
+		for _, buildType := range c.BuildTypes {
+			variants = append(variants, Variant{Flavors: combination, BuildType: buildType})
+		}
+	}
+
+	return variants
+}
+
+// ResolveVariant validates the requested flavors and build type, suggesting the closest match for unknown names.
+// Flavors may be given as a comma separated list when the project uses several flavor dimensions.
+func (c *GradleConfig) ResolveVariant(flavor, buildType string) (Variant, error) {
+	if !contains(c.BuildTypes, buildType) {
+		return Variant{}, unknownNameError("build type", buildType, c.BuildTypes)
+	}
+
+	var flavors []string
+	if flavor != "" {
+		flavors = strings.Split(flavor, ",")
+	}
+	for _, name := range flavors {
+		if !contains(c.FlavorNames(), name) {
+			return Variant{}, unknownNameError("flavor", name, c.FlavorNames())
+		}
+	}
+
+	requested := Variant{Flavors: flavors, BuildType: buildType}
+	for _, variant := range c.Variants() {
+		if variant.BuildType == buildType && sameElements(variant.Flavors, flavors) {
+			return variant, nil
+		}
+	}
+
+	if len(c.Flavors) > 0 && len(flavors) == 0 {
+		return Variant{}, fmt.Errorf("the project declares product flavors, pick one with --flavor (%s)", strings.Join(c.FlavorNames(), ", "))
+	}
+	return Variant{}, fmt.Errorf("%q is not a variant of this project, run with --list-variants to see the available ones", requested.Name())
+}
+
+// VariantApplicationID returns the application ID the variant is installed under: the one of defaultConfig, replaced by
+// the flavors that set their own, followed by the applicationIdSuffix of the flavors and of the build type.
+func (c *GradleConfig) VariantApplicationID(v Variant) string {
+	id := c.ApplicationID
+	suffix := ""
+	for _, name := range v.Flavors {
+		for _, flavor := range c.Flavors {
+			if flavor.Name != name {
+				continue
+			}
+			if flavor.ApplicationID != "" {
+				id = flavor.ApplicationID
+			}
+			suffix += flavor.ApplicationIDSuffix
+		}
+	}
+	return id + suffix + c.BuildTypeSuffixes[v.BuildType]
+}
+
+// Name returns the variant name Gradle uses, e.g. acmeRelease.
+func (v Variant) Name() string {
+	name := ""
+	for _, flavor := range v.Flavors {
+		name += capitalize(flavor)
+	}
+	name += capitalize(v.BuildType)
+
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// Task returns the Gradle task that builds the variant in the given format, e.g. bundleAcmeRelease for aab.
+func (v Variant) Task(format string) (string, error) {
+	switch format {
+	case "apk":
+		return "assemble" + capitalize(v.Name()), nil
+	case "aab":
+		return "bundle" + capitalize(v.Name()), nil
+	default:
+		return "", fmt.Errorf("unknown format %q, expected apk or aab", format)
+	}
+}
+
+func unknownNameError(kind, name string, candidates []string) error {
+	if suggestion := utils.ClosestMatch(name, candidates); suggestion != "" {
+		return fmt.Errorf("unknown %s %q, did you mean %q?", kind, name, suggestion)
+	}
+	return fmt.Errorf("unknown %s %q, expected one of: %s", kind, name, strings.Join(candidates, ", "))
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// sameElements reports whether both lists hold the same names, in any order.
+func sameElements(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, item := range a {
+		if !contains(b, item) {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+type gradleChild struct {
+	name string
+	body string
+}
+
+// gradleBlock returns the body of the first `name { ... }` block in the script.
+func gradleBlock(script, name string) (string, bool) {
+	start, end, ok := findGradleBlock(script, name)
+	if !ok {
+		return "", false
+	}
+	return script[start:end], true
+}
+
+// FindGradleBlock returns the offsets of the body of the first `name { ... }` block in a build script, skipping
+// blocks that are commented out, e.g. to edit the defaultConfig block of an app in place.
+func FindGradleBlock(script, name string) (start, end int, ok bool) {
+	return findGradleBlock(maskGradleComments(script), name)
+}
+
+func findGradleBlock(script, name string) (start, end int, ok bool) {
+	re := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\s*\{`)
+	loc := re.FindStringIndex(script)
+	if loc == nil {
+		return 0, 0, false
+	}
+
+	end = matchingBrace(script, loc[1]-1)
+	if end < 0 {
+		return 0, 0, false
+	}
+	return loc[1], end, true
+}
+
+// gradleChildBlocks returns the named blocks directly inside a block body, such as the flavors of productFlavors.
+func gradleChildBlocks(body string) []gradleChild {
+	var children []gradleChild
+	statementStart := 0
+
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '{':
+			end := matchingBrace(body, i)
+			if end < 0 {
+				return children
+			}
+
+			header := strings.TrimSpace(body[statementStart:i])
+			if match := blockNameRegexp.FindStringSubmatch(header); match != nil {
+				children = append(children, gradleChild{name: match[1], body: body[i+1 : end]})
+			}
+
+			i = end
+			statementStart = end + 1
+		case '\n', ';':
+			statementStart = i + 1
+		}
+	}
+
+	return children
+}
+
+// matchingBrace returns the index of the brace closing the one at open, skipping braces inside strings.
+func matchingBrace(s string, open int) int {
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// stripGradleComments removes comments so that commented-out flavors are not picked up.
+func stripGradleComments(script string) string {
+	var b strings.Builder
+	last := 0
+	for _, comment := range gradleComments(script) {
+		b.WriteString(script[last:comment[0]])
+		last = comment[1]
+	}
+	b.WriteString(script[last:])
+	return b.String()
+}
+
+// maskGradleComments blanks out comments, keeping their line breaks, so that offsets into the result are offsets
+// into the script.
+func maskGradleComments(script string) string {
+	masked := []byte(script)
+	for _, comment := range gradleComments(script) {
+		for i := comment[0]; i < comment[1]; i++ {
+			if masked[i] != '\n' {
+				masked[i] = ' '
+			}
+		}
+	}
+	return string(masked)
+}
+
+// gradleComments returns the start and end offsets of the comments in a script. Strings are skipped, so that the //
+// of a URL in a buildConfigField does not start a comment.
+func gradleComments(script string) [][2]int {
+	var comments [][2]int
+	for i := 0; i < len(script); {
+		rest := script[i:]
+		switch {
+		case strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, "'''"):
+			end := len(rest)
+			if n := strings.Index(rest[3:], rest[:3]); n >= 0 {
+				end = 3 + n + 3
+			}
+			i += end
+		case rest[0] == '"' || rest[0] == '\'':
+			// A quote left open ends at the line, like the compilers do.
+			end := 1
+			for end < len(rest) && rest[end] != rest[0] && rest[end] != '\n' {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			i += min(end+1, len(rest))
+		case strings.HasPrefix(rest, "//"):
+			end := strings.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			comments = append(comments, [2]int{i, i + end})
+			i += end
+		case strings.HasPrefix(rest, "/*"):
+			end := len(rest)
+			if n := strings.Index(rest[2:], "*/"); n >= 0 {
+				end = 2 + n + 2
+			}
+			comments = append(comments, [2]int{i, i + end})
+			i += end
+		default:
+			i++
+		}
+	}
+	return comments
+}
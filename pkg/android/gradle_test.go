@@ -0,0 +1,148 @@
+package android
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func loadGradleFixture(t *testing.T, name string) *GradleConfig {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ParseGradleConfig(string(data))
+}
+
+func TestParseGradleConfig(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		dimensions []string
+		flavors    map[string]string
+		buildTypes []string
+	}{
+		{
+			fixture:    "build.gradle",
+			dimensions: []string{"store", "env"},
+			flavors:    map[string]string{"acme": "store", "globex": "store", "staging": "env", "production": "env"},
+			buildTypes: []string{"debug", "release", "qa"},
+		},
+		{
+			fixture:    "build.gradle.kts",
+			dimensions: []string{"brand"},
+			flavors:    map[string]string{"acme": "brand", "globex": "brand"},
+			buildTypes: []string{"debug", "release", "benchmark"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			config := loadGradleFixture(t, test.fixture)
+
+			if !reflect.DeepEqual(config.Dimensions, test.dimensions) {
+				t.Errorf("Dimensions = %q, want %q", config.Dimensions, test.dimensions)
+			}
+			flavors := map[string]string{}
+			for _, flavor := range config.Flavors {
+				flavors[flavor.Name] = flavor.Dimension
+			}
+			if !reflect.DeepEqual(flavors, test.flavors) {
+				t.Errorf("Flavors = %v, want %v", flavors, test.flavors)
+			}
+			if !reflect.DeepEqual(config.BuildTypes, test.buildTypes) {
+				t.Errorf("BuildTypes = %q, want %q", config.BuildTypes, test.buildTypes)
+			}
+		})
+	}
+}
+
+func TestStripGradleComments(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   string
+	}{
+		{"line comment ends at newline", "a // one\nb // two\nc", "a \nb \nc"},
+		{"block comment spans lines", "a /* one\ntwo */ b", "a  b"},
+		{"block comments are not greedy", "a /* one */ b /* two */ c", "a  b  c"},
+		{"line comment before block", "// note\nproductFlavors { acme {} }", "\nproductFlavors { acme {} }"},
+		{"URL in a string", `url "https://api.acme.com" // prod`, `url "https://api.acme.com" `},
+		{"URL in a nested string", `buildConfigField "String", "URL", '"https://acme.com"'`, `buildConfigField "String", "URL", '"https://acme.com"'`},
+		{"comment markers in a string", `a "/* not */" b`, `a "/* not */" b`},
+		{"escaped quote", `a "say \"//\"" // c`, `a "say \"//\"" `},
+		{"triple-quoted string", "a '''one // 'two'\n''' b // c", "a '''one // 'two'\n''' b "},
+		{"unclosed quote ends at the line", "a \"b\n// c\nd", "a \"b\n\nd"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := stripGradleComments(test.script); got != test.want {
+				t.Errorf("stripGradleComments(%q) = %q, want %q", test.script, got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveVariant(t *testing.T) {
+	config := loadGradleFixture(t, "build.gradle")
+
+	tests := []struct {
+		flavor, buildType string
+		want              string
+		err               string
+	}{
+		{flavor: "acme,staging", buildType: "release", want: "acmeStagingRelease"},
+		{flavor: "production,globex", buildType: "qa", want: "globexProductionQa"},
+		{flavor: "acme", buildType: "release", err: "is not a variant"},
+		{flavor: "", buildType: "debug", err: "pick one with --flavor"},
+		{flavor: "acmee,staging", buildType: "debug", err: `did you mean "acme"`},
+		{flavor: "acme,staging", buildType: "relase", err: `did you mean "release"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.flavor+"/"+test.buildType, func(t *testing.T) {
+			variant, err := config.ResolveVariant(test.flavor, test.buildType)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("ResolveVariant() error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if variant.Name() != test.want {
+				t.Errorf("Name() = %q, want %q", variant.Name(), test.want)
+			}
+		})
+	}
+}
+
+func TestVariantTask(t *testing.T) {
+	variant := Variant{Flavors: []string{"acme"}, BuildType: "release"}
+	for format, want := range map[string]string{"apk": "assembleAcmeRelease", "aab": "bundleAcmeRelease"} {
+		if task, err := variant.Task(format); err != nil || task != want {
+			t.Errorf("Task(%q) = %q, %v, want %q", format, task, err, want)
+		}
+	}
+	if _, err := variant.Task("zip"); err == nil {
+		t.Error("Task(\"zip\") succeeded, want an error")
+	}
+}
+
+func TestFindGradleBlock(t *testing.T) {
+	script := "android {\n    // defaultConfig { applicationId \"old\" }\n    defaultConfig {\n        url \"https://acme.com/{id}\"\n    }\n}\n"
+	start, end, ok := FindGradleBlock(script, "defaultConfig")
+	if !ok {
+		t.Fatal("FindGradleBlock() found no block")
+	}
+	if got, want := script[start:end], "\n        url \"https://acme.com/{id}\"\n    "; got != want {
+		t.Errorf("FindGradleBlock() body = %q, want %q", got, want)
+	}
+	if _, _, ok := FindGradleBlock(script, "productFlavors"); ok {
+		t.Error("FindGradleBlock() found a missing block")
+	}
+}
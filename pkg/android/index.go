@@ -0,0 +1,28 @@
+package android
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// RunGradle runs the Gradle wrapper of the given android directory with extra environment variables and the given arguments.
+func RunGradle(androidDir string, env []string, args ...string) error {
+	gradlew := filepath.Join(androidDir, "gradlew")
+	if _, err := os.Stat(gradlew); os.IsNotExist(err) {
+		return fmt.Errorf("no Gradle wrapper found in %s", androidDir)
+	}
+
+	// Project properties such as ORG_GRADLE_PROJECT_android.injected.signing.store.password are not valid shell
+	// names. dash, the /bin/sh of most Linux CI images, drops them before starting Java, while bash keeps them.
+	for _, variable := range env {
+		if name, _, _ := strings.Cut(variable, "="); strings.Contains(name, ".") {
+			return utils.RunCommandInDir(androidDir, env, "bash", append([]string{"./gradlew"}, args...)...)
+		}
+	}
+
+	return utils.RunCommandInDir(androidDir, env, "./gradlew", args...)
+}
@@ -0,0 +1,202 @@
+package android
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// JavaVersionFile pins the JDK of a project, the same file jenv reads.
+const JavaVersionFile = ".java-version"
+
+var (
+	releaseVersionRegexp = regexp.MustCompile(`(?m)^JAVA_VERSION="([^"]+)"`)
+	javaHomeListRegexp   = regexp.MustCompile(`(?m)\s(/\S.*/Contents/Home)\s*$`)
+)
+
+// JDK is a Java Development Kit installed on the machine.
+type JDK struct {
+	Home    string
+	Version string
+	Source  string
+}
+
+// Major returns the feature release of the JDK, e.g. 17.
+func (j JDK) Major() int {
+	return JavaMajorVersion(j.Version)
+}
+
+// Env returns the environment that makes Gradle and the Android tools run on the JDK.
+func (j JDK) Env() []string {
+	return []string{
+		"JAVA_HOME=" + j.Home,
+		"PATH=" + filepath.Join(j.Home, "bin") + string(os.PathListSeparator) + os.Getenv("PATH"),
+	}
+}
+
+func (j JDK) String() string {
+	return fmt.Sprintf("JDK %s (%s)", j.Version, j.Home)
+}
+
+// FindJDKs returns the JDKs installed by Homebrew, in the macOS JavaVirtualMachines folders that
+// /usr/libexec/java_home reads, in /usr/lib/jvm and by SDKMAN, plus the one JAVA_HOME points to. They are sorted
+// newest first and each JDK is listed once, however many of those locations link to it.
+func FindJDKs(homeDir string) []JDK {
+	var candidates []JDK
+	add := func(source string, patterns ...string) {
+		for _, pattern := range patterns {
+			homes, _ := filepath.Glob(pattern)
+			for _, home := range homes {
+				candidates = append(candidates, JDK{Home: home, Source: source})
+			}
+		}
+	}
+
+	if javaHome := os.Getenv("JAVA_HOME"); javaHome != "" {
+		candidates = append(candidates, JDK{Home: javaHome, Source: "JAVA_HOME"})
+	}
+	for _, prefix := range []string{"/opt/homebrew", "/usr/local"} {
+		add("Homebrew",
+			filepath.Join(prefix, "opt", "openjdk*", "libexec", "openjdk.jdk", "Contents", "Home"),
+			filepath.Join(prefix, "Cellar", "openjdk*", "*", "libexec", "openjdk.jdk", "Contents", "Home"),
+		)
+	}
+	add("java_home",
+		filepath.Join("/Library", "Java", "JavaVirtualMachines", "*", "Contents", "Home"),
+		filepath.Join(homeDir, "Library", "Java", "JavaVirtualMachines", "*", "Contents", "Home"),
+	)
+	if output, err := utils.RunCommandWithOutput("/usr/libexec/java_home", "-V"); err == nil {
+		for _, match := range javaHomeListRegexp.FindAllStringSubmatch(output, -1) {
+			candidates = append(candidates, JDK{Home: match[1], Source: "java_home"})
+		}
+	}
+	add("/usr/lib/jvm", filepath.Join("/usr", "lib", "jvm", "*"))
+	add("SDKMAN", filepath.Join(homeDir, ".sdkman", "candidates", "java", "*"))
+
+	var jdks []JDK
+	seen := map[string]bool{}
+	for _, jdk := range candidates {
+		resolved, err := filepath.EvalSymlinks(jdk.Home)
+		if err != nil || seen[resolved] {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(resolved, "bin", "java")); err != nil || info.IsDir() {
+			continue
+		}
+		seen[resolved] = true
+
+		jdk.Version = jdkVersion(jdk.Home)
+		if jdk.Version != "" {
+			jdks = append(jdks, jdk)
+		}
+	}
+
+	sort.SliceStable(jdks, func(i, j int) bool {
+		return utils.CompareVersions(strings.TrimPrefix(jdks[i].Version, "1."), strings.TrimPrefix(jdks[j].Version, "1.")) > 0
+	})
+	return jdks
+}
+
+// EnvironmentJDK returns the JDK that builds run on when bob does not select one: the one JAVA_HOME points to, or
+// else the one of the java command on the PATH. Its version is empty when there is no working java at all.
+func EnvironmentJDK() JDK {
+	jdk := JDK{Home: os.Getenv("JAVA_HOME"), Source: "environment"}
+	if jdk.Home == "" {
+		if java, err := exec.LookPath("java"); err == nil {
+			if resolved, err := filepath.EvalSymlinks(java); err == nil {
+				jdk.Home = filepath.Dir(filepath.Dir(resolved))
+			}
+		}
+	}
+	if jdk.Home != "" {
+		jdk.Version = jdkVersion(jdk.Home)
+	}
+	return jdk
+}
+
+// jdkVersion reads the version of the JDK from its release file, falling back to running java -version.
+func jdkVersion(home string) string {
+	if data, err := os.ReadFile(filepath.Join(home, "release")); err == nil {
+		if match := releaseVersionRegexp.FindSubmatch(data); match != nil {
+			return string(match[1])
+		}
+	}
+
+	version, err := JavaVersion(home)
+	if err != nil {
+		return ""
+	}
+	return version
+}
+
+// PinnedJDK returns the JDK feature release the project pins in .java-version, or 0 when it pins none.
+func PinnedJDK(projectDir string) int {
+	file, err := os.Open(filepath.Join(projectDir, JavaVersionFile))
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	if scanner.Scan() {
+		return JavaMajorVersion(strings.TrimSpace(scanner.Text()))
+	}
+	return 0
+}
+
+// PinJDK writes the JDK feature release to the project's .java-version.
+func PinJDK(projectDir string, major int) error {
+	path := filepath.Join(projectDir, JavaVersionFile)
+	if err := os.WriteFile(path, []byte(strconv.Itoa(major)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// FindJDK returns the newest installed JDK of the given feature release.
+func FindJDK(jdks []JDK, major int) (JDK, bool) {
+	for _, jdk := range jdks {
+		if jdk.Major() == major {
+			return jdk, true
+		}
+	}
+	return JDK{}, false
+}
+
+// SelectJDK picks the JDK to build the project with. A JDK pinned in .java-version wins. Otherwise the JDK the
+// Android Gradle plugin recommends is preferred, then the newest one that the plugin and the Gradle wrapper both accept.
+func SelectJDK(projectDir string, jdks []JDK) (JDK, error) {
+	if pinned := PinnedJDK(projectDir); pinned > 0 {
+		if jdk, ok := FindJDK(jdks, pinned); ok {
+			return jdk, nil
+		}
+		return JDK{}, fmt.Errorf("%s pins JDK %d but it is not installed, install it with brew install openjdk@%d", JavaVersionFile, pinned, pinned)
+	}
+
+	agpVersion, err := AGPVersion(projectDir)
+	if err != nil {
+		return JDK{}, err
+	}
+	recommended := RecommendedJDK(agpVersion)
+	if jdk, ok := FindJDK(jdks, recommended); ok {
+		return jdk, nil
+	}
+
+	if gradleVersion, err := GradleWrapperVersion(projectDir); err == nil {
+		for _, jdk := range jdks {
+			if CheckCompatibility(agpVersion, gradleVersion, jdk.Major()) == nil {
+				return jdk, nil
+			}
+		}
+	}
+
+	return JDK{}, fmt.Errorf("no installed JDK can build with Android Gradle plugin %s, install JDK %d (brew install openjdk@%d)", agpVersion, recommended, recommended)
+}
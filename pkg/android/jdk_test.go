@@ -0,0 +1,68 @@
+package android
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeJDK creates a JDK home with the release file and bin/java that FindJDKs looks for.
+func fakeJDK(t *testing.T, version string) string {
+	t.Helper()
+	home := filepath.Join(t.TempDir(), "jdk-"+version)
+	if err := os.MkdirAll(filepath.Join(home, "bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "bin", "java"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "release"), []byte("JAVA_VERSION=\""+version+"\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+func TestSelectJDK(t *testing.T) {
+	jdks := []JDK{
+		{Home: "/jdk/21", Version: "21.0.1"},
+		{Home: "/jdk/17", Version: "17.0.9"},
+		{Home: "/jdk/11", Version: "11.0.20"},
+	}
+
+	project := t.TempDir()
+	gradle := "buildscript {\n  dependencies {\n    classpath(\"com.android.tools.build:gradle:8.1.1\")\n  }\n}\n"
+	if err := os.MkdirAll(filepath.Join(project, "android"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "android", "build.gradle"), []byte(gradle), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if jdk, err := SelectJDK(project, jdks); err != nil || jdk.Major() != 17 {
+		t.Errorf("SelectJDK() = %v, %v, want the JDK 17 that AGP 8.1.1 recommends", jdk, err)
+	}
+
+	if err := PinJDK(project, 11); err != nil {
+		t.Fatal(err)
+	}
+	if jdk, err := SelectJDK(project, jdks); err != nil || jdk.Major() != 11 {
+		t.Errorf("SelectJDK() = %v, %v, want the pinned JDK 11", jdk, err)
+	}
+
+	// Without a readable Android Gradle plugin version no JDK is selected, builds then fall back to EnvironmentJDK.
+	if _, err := SelectJDK(t.TempDir(), jdks); err == nil {
+		t.Error("SelectJDK() without an Android project succeeded, want an error")
+	}
+}
+
+func TestEnvironmentJDK(t *testing.T) {
+	home := fakeJDK(t, "17.0.9")
+	t.Setenv("JAVA_HOME", home)
+
+	jdk := EnvironmentJDK()
+	if jdk.Home != home || jdk.Version != "17.0.9" || jdk.Source != "environment" {
+		t.Errorf("EnvironmentJDK() = %+v, want JDK 17.0.9 at %s", jdk, home)
+	}
+	if env := jdk.Env(); env[0] != "JAVA_HOME="+home {
+		t.Errorf("Env() = %q, want JAVA_HOME=%s first", env, home)
+	}
+}
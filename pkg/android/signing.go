@@ -0,0 +1,187 @@
+package android
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// SigningConfig describes the keystore used to sign release builds.
+type SigningConfig struct {
+	StoreFile     string
+	StorePassword string
+	KeyAlias      string
+	KeyPassword   string
+}
+
+// Validate checks that every field needed to sign a build is set.
+func (c SigningConfig) Validate() error {
+	var missing []string
+	if c.StoreFile == "" {
+		missing = append(missing, "store file")
+	}
+	if c.StorePassword == "" {
+		missing = append(missing, "store password")
+	}
+	if c.KeyAlias == "" {
+		missing = append(missing, "key alias")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("incomplete signing configuration, missing %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// String describes the signing configuration without revealing the passwords.
+func (c SigningConfig) String() string {
+	return fmt.Sprintf("keystore %s, alias %s, store password %s, key password %s",
+		c.StoreFile, c.KeyAlias, mask(c.StorePassword), mask(c.keyPassword()))
+}
+
+// Empty reports whether no signing value is set at all, as opposed to a partly set up configuration.
+func (c SigningConfig) Empty() bool {
+	return c.StoreFile == "" && c.StorePassword == "" && c.KeyAlias == "" && c.KeyPassword == ""
+}
+
+// Secrets returns the passwords of the configuration, for masking in build output.
+func (c SigningConfig) Secrets() []string {
+	return []string{c.StorePassword, c.keyPassword()}
+}
+
+// GradleProperties returns the -P flags and the environment variables that make the Android Gradle plugin sign
+// with this configuration. The passwords go through the environment, which Gradle reads as ORG_GRADLE_PROJECT_
+// project properties, so they never show up in the process list.
+func (c SigningConfig) GradleProperties() ([]string, []string, error) {
+	storeFile, err := filepath.Abs(c.StoreFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	args := []string{
+		"-Pandroid.injected.signing.store.file=" + storeFile,
+		"-Pandroid.injected.signing.key.alias=" + c.KeyAlias,
+	}
+	env := []string{
+		"ORG_GRADLE_PROJECT_android.injected.signing.store.password=" + c.StorePassword,
+		"ORG_GRADLE_PROJECT_android.injected.signing.key.password=" + c.keyPassword(),
+	}
+	return args, env, nil
+}
+
+// keyPassword returns the key password, defaulting to the store password as keytool does for PKCS12 stores.
+func (c SigningConfig) keyPassword() string {
+	if c.KeyPassword == "" {
+		return c.StorePassword
+	}
+	return c.KeyPassword
+}
+
+func mask(secret string) string {
+	if secret == "" {
+		return "(not set)"
+	}
+	return "********"
+}
+
+// GenerateKeystore creates a new PKCS12 keystore with a single RSA key using keytool.
+// The passwords are handed to keytool through the environment so they never show up in the process list.
+func GenerateKeystore(config SigningConfig, dname string, validityDays int) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(config.StoreFile); err == nil {
+		return fmt.Errorf("keystore %s already exists", config.StoreFile)
+	}
+	if err := os.MkdirAll(filepath.Dir(config.StoreFile), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", config.StoreFile, err)
+	}
+
+	env := []string{
+		"BOB_KEYTOOL_STORE_PASSWORD=" + config.StorePassword,
+		"BOB_KEYTOOL_KEY_PASSWORD=" + config.keyPassword(),
+	}
+	err := utils.RunCommandInDir("", env, "keytool", "-genkeypair",
+		"-storetype", "PKCS12",
+		"-keystore", config.StoreFile,
+		"-alias", config.KeyAlias,
+		"-keyalg", "RSA",
+		"-keysize", "2048",
+		"-validity", strconv.Itoa(validityDays),
+		"-dname", dname,
+		"-storepass:env", "BOB_KEYTOOL_STORE_PASSWORD",
+		"-keypass:env", "BOB_KEYTOOL_KEY_PASSWORD",
+	)
+	if err != nil {
+		return fmt.Errorf("keytool failed to generate %s: %v", config.StoreFile, err)
+	}
+
+	return nil
+}
+
+// Certificate is the signing certificate of a built APK or AAB.
+type Certificate struct {
+	Owner  string
+	SHA1   string
+	SHA256 string
+}
+
+var (
+	keytoolOwnerRegexp  = regexp.MustCompile(`(?m)^Owner: (.+)$`)
+	keytoolSHA1Regexp   = regexp.MustCompile(`(?m)^\s*SHA1: ([0-9A-F:]+)`)
+	keytoolSHA256Regexp = regexp.MustCompile(`(?m)^\s*SHA256: ([0-9A-F:]+)`)
+)
+
+// InspectCertificate reads the signing certificate of an APK or AAB using keytool.
+func InspectCertificate(artifact string) (*Certificate, error) {
+	output, err := utils.RunCommandWithOutput("keytool", "-printcert", "-jarfile", artifact)
+	if err != nil {
+		return nil, fmt.Errorf("keytool failed to read %s: %v", artifact, err)
+	}
+
+	return ParseKeytoolCertificate(output)
+}
+
+// ParseKeytoolCertificate extracts the first certificate from the output of `keytool -printcert`.
+func ParseKeytoolCertificate(output string) (*Certificate, error) {
+	cert := &Certificate{}
+	if match := keytoolOwnerRegexp.FindStringSubmatch(output); match != nil {
+		cert.Owner = strings.TrimSpace(match[1])
+	}
+	if match := keytoolSHA1Regexp.FindStringSubmatch(output); match != nil {
+		cert.SHA1 = match[1]
+	}
+	if match := keytoolSHA256Regexp.FindStringSubmatch(output); match != nil {
+		cert.SHA256 = match[1]
+	}
+
+	if cert.SHA256 == "" {
+		return nil, fmt.Errorf("artifact is not signed")
+	}
+
+	return cert, nil
+}
+
+// KeystoreCertificate reads the certificate stored under the configured alias, for comparison with a built artifact.
+func KeystoreCertificate(config SigningConfig) (*Certificate, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	env := []string{"BOB_KEYTOOL_STORE_PASSWORD=" + config.StorePassword}
+	output, err := utils.RunCommandInDirWithOutput("", env, "keytool", "-list", "-v",
+		"-keystore", config.StoreFile,
+		"-alias", config.KeyAlias,
+		"-storepass:env", "BOB_KEYTOOL_STORE_PASSWORD",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("keytool failed to read %s: %v", config.StoreFile, err)
+	}
+
+	return ParseKeytoolCertificate(output)
+}
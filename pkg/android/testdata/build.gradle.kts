@@ -0,0 +1,27 @@
+plugins {
+    id("com.android.application")
+}
+
+android {
+    namespace = "com.acme.app"
+
+    // One dimension, so flavors need not name it.
+    flavorDimensions += "brand"
+    productFlavors {
+        create("acme") {
+            applicationIdSuffix = ".acme"
+            buildConfigField("String", "API_URL", "\"https://api.acme.com\"")
+        }
+        register("globex") {
+        }
+    }
+
+    buildTypes {
+        getByName("release") {
+            isMinifyEnabled = false
+        }
+        create("benchmark") {
+            initWith(getByName("release"))
+        }
+    }
+}
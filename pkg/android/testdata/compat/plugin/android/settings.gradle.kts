@@ -0,0 +1,12 @@
+pluginManagement {
+    repositories {
+        google()
+        gradlePluginPortal()
+    }
+}
+
+plugins {
+    id("com.android.application") version "9.0.0" apply false
+}
+
+include(":app")
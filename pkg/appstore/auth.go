@@ -0,0 +1,51 @@
+package appstore
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"fmt"
+	"time"
+
+	"github.com/aman-apptile/bob/pkg/jwt"
+)
+
+const (
+	tokenAudience = "appstoreconnect-v1"
+	// tokenLifetime is the longest App Store Connect accepts.
+	tokenLifetime = 20 * time.Minute
+)
+
+// APIKey is an App Store Connect API key, the .p8 private key downloaded from Users and Access along with its
+// key ID and the issuer ID of the team.
+type APIKey struct {
+	KeyID    string
+	IssuerID string
+	key      crypto.Signer
+}
+
+// ParseAPIKey reads the content of a .p8 key file.
+func ParseAPIKey(keyID, issuerID string, data []byte) (*APIKey, error) {
+	if keyID == "" || issuerID == "" {
+		return nil, fmt.Errorf("an App Store Connect API key needs a key ID and an issuer ID")
+	}
+	key, err := jwt.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read App Store Connect API key %s: %v", keyID, err)
+	}
+	if _, ok := key.(*ecdsa.PrivateKey); !ok {
+		return nil, fmt.Errorf("App Store Connect API key %s is not an ECDSA key", keyID)
+	}
+	return &APIKey{KeyID: keyID, IssuerID: issuerID, key: key}, nil
+}
+
+// Token returns a JWT signed with ES256 that authenticates requests until it expires.
+func (k *APIKey) Token(now time.Time) (string, time.Time, error) {
+	expiry := now.Add(tokenLifetime)
+	token, err := jwt.Sign(map[string]string{"kid": k.KeyID}, map[string]any{
+		"iss": k.IssuerID,
+		"iat": now.Unix(),
+		"exp": expiry.Unix(),
+		"aud": tokenAudience,
+	}, k.key)
+	return token, expiry, err
+}
@@ -0,0 +1,253 @@
+package appstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the base URL of the App Store Connect API.
+const DefaultBaseURL = "https://api.appstoreconnect.apple.com"
+
+// resource is a JSON:API resource object as the App Store Connect API sends and receives them.
+type resource struct {
+	Type          string         `json:"type"`
+	ID            string         `json:"id,omitempty"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+	Relationships map[string]any `json:"relationships,omitempty"`
+}
+
+// relationship returns a to-one relationship to the resource.
+func relationship(kind, id string) map[string]any {
+	return map[string]any{"data": map[string]string{"type": kind, "id": id}}
+}
+
+// Client calls the App Store Connect API with an API key.
+type Client struct {
+	// BaseURL is where the API is served, DefaultBaseURL unless a test server stands in for it.
+	BaseURL string
+	HTTP    *http.Client
+	Key     *APIKey
+	// PollInterval is how often the processing state of an uploaded build is checked.
+	PollInterval time.Duration
+
+	token  string
+	expiry time.Time
+}
+
+// NewClient returns a client of the API at baseURL, or at DefaultBaseURL when it is empty.
+func NewClient(baseURL string, key *APIKey) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL:      strings.TrimSuffix(baseURL, "/"),
+		HTTP:         &http.Client{Timeout: 30 * time.Minute},
+		Key:          key,
+		PollInterval: 30 * time.Second,
+	}
+}
+
+// call sends a JSON request to the path under the base URL and decodes the JSON response into result, unless
+// it is nil.
+func (c *Client) call(method, path string, body, result any) error {
+	if c.token == "" || time.Now().After(c.expiry.Add(-time.Minute)) {
+		token, expiry, err := c.Key.Token(time.Now())
+		if err != nil {
+			return err
+		}
+		c.token, c.expiry = token, expiry
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// responseError reads the JSON:API errors of a failed response.
+func responseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var document struct {
+		Errors []struct {
+			Code   string `json:"code"`
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(data, &document) != nil || len(document.Errors) == 0 {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var messages []string
+	for _, e := range document.Errors {
+		message := e.Title
+		if e.Detail != "" {
+			message = e.Detail
+		}
+		messages = append(messages, fmt.Sprintf("%s (%s)", message, e.Code))
+	}
+	return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.Join(messages, "; "))
+}
+
+// list fetches a collection and returns its resources.
+func (c *Client) list(path string, query url.Values) ([]resource, error) {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	var document struct {
+		Data []resource `json:"data"`
+	}
+	if err := c.call(http.MethodGet, path, nil, &document); err != nil {
+		return nil, err
+	}
+	return document.Data, nil
+}
+
+// create posts a resource and returns the created one.
+func (c *Client) create(path string, r resource) (*resource, error) {
+	var document struct {
+		Data resource `json:"data"`
+	}
+	if err := c.call(http.MethodPost, path, map[string]any{"data": r}, &document); err != nil {
+		return nil, err
+	}
+	return &document.Data, nil
+}
+
+// FindApp returns the ID of the app with the bundle ID.
+func (c *Client) FindApp(bundleID string) (string, error) {
+	apps, err := c.list("/v1/apps", url.Values{"filter[bundleId]": {bundleID}})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the app %s: %v", bundleID, err)
+	}
+	for _, app := range apps {
+		if app.Attributes["bundleId"] == bundleID {
+			return app.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no app with the bundle ID %s in App Store Connect", bundleID)
+}
+
+// Build is a build uploaded to App Store Connect.
+type Build struct {
+	ID              string
+	Version         string
+	ProcessingState string
+}
+
+// Processing states of a build.
+const (
+	StateProcessing = "PROCESSING"
+	StateFailed     = "FAILED"
+	StateInvalid    = "INVALID"
+	StateValid      = "VALID"
+)
+
+// FindBuild returns the build of the app with the version and build number, or nil when App Store Connect does
+// not list it yet.
+func (c *Client) FindBuild(appID, version, buildNumber string) (*Build, error) {
+	builds, err := c.list("/v1/builds", url.Values{
+		"filter[app]":                       {appID},
+		"filter[version]":                   {buildNumber},
+		"filter[preReleaseVersion.version]": {version},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up build %s (%s): %v", version, buildNumber, err)
+	}
+	if len(builds) == 0 {
+		return nil, nil
+	}
+	state, _ := builds[0].Attributes["processingState"].(string)
+	return &Build{ID: builds[0].ID, Version: buildNumber, ProcessingState: state}, nil
+}
+
+// SetWhatToTest sets the "What to Test" notes of the build in the locale, e.g. en-US.
+func (c *Client) SetWhatToTest(buildID, locale, text string) error {
+	localizations, err := c.list("/v1/builds/"+url.PathEscape(buildID)+"/betaBuildLocalizations", nil)
+	if err != nil {
+		return fmt.Errorf("failed to read the test notes of the build: %v", err)
+	}
+	for _, localization := range localizations {
+		if localization.Attributes["locale"] != locale {
+			continue
+		}
+		update := resource{Type: "betaBuildLocalizations", ID: localization.ID, Attributes: map[string]any{"whatsNew": text}}
+		if err := c.call(http.MethodPatch, "/v1/betaBuildLocalizations/"+url.PathEscape(localization.ID), map[string]any{"data": update}, nil); err != nil {
+			return fmt.Errorf("failed to update the test notes of the build: %v", err)
+		}
+		return nil
+	}
+
+	_, err = c.create("/v1/betaBuildLocalizations", resource{
+		Type:          "betaBuildLocalizations",
+		Attributes:    map[string]any{"locale": locale, "whatsNew": text},
+		Relationships: map[string]any{"build": relationship("builds", buildID)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set the test notes of the build: %v", err)
+	}
+	return nil
+}
+
+// FindBetaGroups returns the IDs of the beta groups of the app by name. Every name must exist.
+func (c *Client) FindBetaGroups(appID string, names []string) (map[string]string, error) {
+	groups, err := c.list("/v1/betaGroups", url.Values{"filter[app]": {appID}, "limit": {"200"}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the beta groups: %v", err)
+	}
+
+	ids := map[string]string{}
+	var available []string
+	for _, group := range groups {
+		name, _ := group.Attributes["name"].(string)
+		available = append(available, name)
+		for _, wanted := range names {
+			if strings.EqualFold(name, wanted) {
+				ids[wanted] = group.ID
+			}
+		}
+	}
+	for _, name := range names {
+		if _, ok := ids[name]; !ok {
+			return nil, fmt.Errorf("no beta group named %q, the app has %s", name, strings.Join(available, ", "))
+		}
+	}
+	return ids, nil
+}
+
+// AddBuildToGroup gives the testers of the beta group access to the build.
+func (c *Client) AddBuildToGroup(groupID, buildID string) error {
+	body := map[string]any{"data": []map[string]string{{"type": "builds", "id": buildID}}}
+	return c.call(http.MethodPost, "/v1/betaGroups/"+url.PathEscape(groupID)+"/relationships/builds", body, nil)
+}
@@ -0,0 +1,111 @@
+package appstore
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxWhatToTestLength is how many characters TestFlight accepts for the "What to Test" notes of one locale.
+const MaxWhatToTestLength = 4000
+
+// Upload describes an IPA to publish to TestFlight.
+type Upload struct {
+	BundleID    string
+	IPA         string
+	Version     string
+	BuildNumber string
+	// WhatToTest are the notes shown to testers, in Locale.
+	WhatToTest string
+	Locale     string
+	// Groups are the names of the beta groups to add the build to.
+	Groups []string
+	// Timeout is how long to wait for App Store Connect to process the build.
+	Timeout time.Duration
+}
+
+// Validate checks that the upload has a version, a build number and notes TestFlight accepts.
+func (u Upload) Validate() error {
+	if u.Version == "" || u.BuildNumber == "" {
+		return fmt.Errorf("the build needs a version and a build number")
+	}
+	if length := utf8.RuneCountInString(u.WhatToTest); length > MaxWhatToTestLength {
+		return fmt.Errorf("the What to Test notes are %d characters long, TestFlight accepts at most %d", length, MaxWhatToTestLength)
+	}
+	return nil
+}
+
+// Publish uploads the IPA, waits until App Store Connect has processed the build, sets its "What to Test" notes
+// and adds it to the beta groups, writing its progress to log. It returns the processed build.
+func (c *Client) Publish(u Upload, log io.Writer) (*Build, error) {
+	if err := u.Validate(); err != nil {
+		return nil, err
+	}
+
+	appID, err := c.FindApp(u.BundleID)
+	if err != nil {
+		return nil, err
+	}
+	// Resolve the groups before uploading, so that a typo does not surface only after a long upload.
+	groups, err := c.FindBetaGroups(appID, u.Groups)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(log, "Uploading %s...\n", filepath.Base(u.IPA))
+	if err := c.UploadIPA(appID, u.Version, u.BuildNumber, u.IPA); err != nil {
+		return nil, err
+	}
+
+	build, err := c.WaitForBuild(appID, u.Version, u.BuildNumber, u.Timeout, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.WhatToTest != "" {
+		fmt.Fprintf(log, "Setting the What to Test notes (%s)...\n", u.Locale)
+		if err := c.SetWhatToTest(build.ID, u.Locale, u.WhatToTest); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range u.Groups {
+		fmt.Fprintf(log, "Adding the build to %s...\n", name)
+		if err := c.AddBuildToGroup(groups[name], build.ID); err != nil {
+			return nil, fmt.Errorf("failed to add the build to %s: %v", name, err)
+		}
+	}
+	return build, nil
+}
+
+// WaitForBuild polls App Store Connect until the build with the version and build number is processed, and
+// fails when processing fails or does not finish within the timeout.
+func (c *Client) WaitForBuild(appID, version, buildNumber string, timeout time.Duration, log io.Writer) (*Build, error) {
+	fmt.Fprintf(log, "Waiting for App Store Connect to process %s (%s)...\n", version, buildNumber)
+	deadline := time.Now().Add(timeout)
+	state := ""
+	for {
+		build, err := c.FindBuild(appID, version, buildNumber)
+		if err != nil {
+			return nil, err
+		}
+		if build != nil {
+			if build.ProcessingState != state {
+				state = build.ProcessingState
+				fmt.Fprintf(log, "  %s\n", state)
+			}
+			switch build.ProcessingState {
+			case StateValid:
+				return build, nil
+			case StateFailed, StateInvalid:
+				return nil, fmt.Errorf("App Store Connect could not process build %s (%s), it is %s, see the email Apple sent about it", version, buildNumber, build.ProcessingState)
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("build %s (%s) was not processed within %s", version, buildNumber, timeout)
+		}
+		time.Sleep(c.PollInterval)
+	}
+}
@@ -0,0 +1,330 @@
+package appstore
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConnect stands in for the App Store Connect API and the upload URLs it hands out. It records the calls
+// and their bodies, and reports the processing states in order, one per poll, repeating the last one.
+type fakeConnect struct {
+	t      *testing.T
+	url    string
+	key    *ecdsa.PublicKey
+	states []string
+	// localizations are the locales the build already has notes in.
+	localizations []string
+
+	mu     sync.Mutex
+	calls  []string
+	bodies map[string][]byte
+	parts  map[string][]byte
+}
+
+func (f *fakeConnect) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Upload operations go to pre-signed URLs, which must not get the API token.
+	if strings.HasPrefix(r.URL.Path, "/upload/") {
+		if r.Header.Get("Authorization") != "" {
+			f.t.Errorf("upload part %s was sent with the API token", r.URL.Path)
+		}
+		if r.Header.Get("Content-Type") != "application/octet-stream" {
+			f.t.Errorf("upload part %s lost its request headers", r.URL.Path)
+		}
+		f.parts[r.URL.Path] = body
+		return
+	}
+	if !f.authorized(r) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"errors": [{"status": "401", "code": "NOT_AUTHORIZED", "title": "Authentication credentials are missing or invalid."}]}`)
+		return
+	}
+
+	call := r.Method + " " + r.URL.Path
+	if r.URL.RawQuery != "" {
+		call += "?" + r.URL.RawQuery
+	}
+	f.calls = append(f.calls, call)
+	f.bodies[r.Method+" "+r.URL.Path] = body
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /v1/apps":
+		fmt.Fprint(w, `{"data": [{"type": "apps", "id": "1234567890", "attributes": {"bundleId": "com.acme.app", "name": "Acme"}}]}`)
+	case "GET /v1/betaGroups":
+		fmt.Fprint(w, `{"data": [
+			{"type": "betaGroups", "id": "group-qa", "attributes": {"name": "QA"}},
+			{"type": "betaGroups", "id": "group-beta", "attributes": {"name": "Beta Testers"}}
+		]}`)
+	case "POST /v1/buildUploads":
+		fmt.Fprint(w, `{"data": {"type": "buildUploads", "id": "upload-1"}}`)
+	case "POST /v1/buildUploadFiles":
+		fmt.Fprintf(w, `{"data": {"type": "buildUploadFiles", "id": "file-1", "attributes": {"uploadOperations": [
+			{"method": "PUT", "url": "%[1]s/upload/part-1", "offset": 0, "length": 4, "requestHeaders": [{"name": "Content-Type", "value": "application/octet-stream"}]},
+			{"method": "PUT", "url": "%[1]s/upload/part-2", "offset": 4, "length": 3, "requestHeaders": [{"name": "Content-Type", "value": "application/octet-stream"}]}
+		]}}}`, f.url)
+	case "GET /v1/builds":
+		state := f.states[0]
+		if len(f.states) > 1 {
+			f.states = f.states[1:]
+		}
+		if state == "" {
+			fmt.Fprint(w, `{"data": []}`)
+			return
+		}
+		fmt.Fprintf(w, `{"data": [{"type": "builds", "id": "build-1", "attributes": {"version": "42", "processingState": %q}}]}`, state)
+	case "GET /v1/builds/build-1/betaBuildLocalizations":
+		var data []string
+		for _, locale := range f.localizations {
+			data = append(data, fmt.Sprintf(`{"type": "betaBuildLocalizations", "id": "localization-%s", "attributes": {"locale": %q}}`, locale, locale))
+		}
+		fmt.Fprintf(w, `{"data": [%s]}`, strings.Join(data, ","))
+	case "POST /v1/betaBuildLocalizations":
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"data": {"type": "betaBuildLocalizations", "id": "localization-new"}}`)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// authorized checks the ES256 signature and the claims of the bearer token.
+func (f *fakeConnect) authorized(r *http.Request) bool {
+	parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+	if len(parts) != 3 {
+		return false
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if len(signature) != 64 || !ecdsa.Verify(f.key, digest[:], new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])) {
+		return false
+	}
+	var header struct {
+		KeyID string `json:"kid"`
+	}
+	var claims struct {
+		Issuer   string `json:"iss"`
+		Audience string `json:"aud"`
+		Issued   int64  `json:"iat"`
+		Expires  int64  `json:"exp"`
+	}
+	headerJSON, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	json.Unmarshal(headerJSON, &header)
+	json.Unmarshal(claimsJSON, &claims)
+	return header.KeyID == "2X9R4HXF34" && claims.Issuer == "57246542-96fe-1a63-e053-0824d011072a" &&
+		claims.Audience == tokenAudience && claims.Expires-claims.Issued <= int64(tokenLifetime/time.Second)
+}
+
+// newFakeConnect starts a fake App Store Connect and returns a client of it with a generated API key.
+func newFakeConnect(t *testing.T, states ...string) (*fakeConnect, *Client) {
+	t.Helper()
+	generated, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(generated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseAPIKey("2X9R4HXF34", "57246542-96fe-1a63-e053-0824d011072a", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeConnect{t: t, key: &generated.PublicKey, states: states, bodies: map[string][]byte{}, parts: map[string][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	fake.url = server.URL
+
+	client := NewClient(server.URL, key)
+	client.PollInterval = time.Millisecond
+	return fake, client
+}
+
+// testUpload writes an IPA and returns an upload of it to the groups.
+func testUpload(t *testing.T, groups ...string) Upload {
+	t.Helper()
+	ipa := filepath.Join(t.TempDir(), "Acme.ipa")
+	if err := os.WriteFile(ipa, []byte("ipadata"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Upload{
+		BundleID:    "com.acme.app",
+		IPA:         ipa,
+		Version:     "1.4.2",
+		BuildNumber: "42",
+		WhatToTest:  "Try the new checkout",
+		Locale:      "en-US",
+		Groups:      groups,
+		Timeout:     time.Minute,
+	}
+}
+
+func TestPublish(t *testing.T) {
+	fake, client := newFakeConnect(t, "", StateProcessing, StateProcessing, StateValid)
+	var log strings.Builder
+
+	build, err := client.Publish(testUpload(t, "qa", "Beta Testers"), &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if build.ID != "build-1" || build.ProcessingState != StateValid {
+		t.Errorf("Publish() = %+v, want the processed build", build)
+	}
+
+	builds := "GET /v1/builds?filter%5Bapp%5D=1234567890&filter%5BpreReleaseVersion.version%5D=1.4.2&filter%5Bversion%5D=42"
+	want := []string{
+		"GET /v1/apps?filter%5BbundleId%5D=com.acme.app",
+		"GET /v1/betaGroups?filter%5Bapp%5D=1234567890&limit=200",
+		"POST /v1/buildUploads",
+		"POST /v1/buildUploadFiles",
+		"PATCH /v1/buildUploadFiles/file-1",
+		builds, builds, builds, builds,
+		"GET /v1/builds/build-1/betaBuildLocalizations",
+		"POST /v1/betaBuildLocalizations",
+		"POST /v1/betaGroups/group-qa/relationships/builds",
+		"POST /v1/betaGroups/group-beta/relationships/builds",
+	}
+	if strings.Join(fake.calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("calls =\n%s\nwant\n%s", strings.Join(fake.calls, "\n"), strings.Join(want, "\n"))
+	}
+
+	if got := string(fake.parts["/upload/part-1"]) + "|" + string(fake.parts["/upload/part-2"]); got != "ipad|ata" {
+		t.Errorf("uploaded parts = %q, want the IPA split at the offsets", got)
+	}
+	var commit struct {
+		Data struct {
+			Attributes struct {
+				Uploaded            bool `json:"uploaded"`
+				SourceFileChecksums struct {
+					File struct {
+						Hash      string `json:"hash"`
+						Algorithm string `json:"algorithm"`
+					} `json:"file"`
+				} `json:"sourceFileChecksums"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	json.Unmarshal(fake.bodies["PATCH /v1/buildUploadFiles/file-1"], &commit)
+	sum := md5.Sum([]byte("ipadata"))
+	if file := commit.Data.Attributes.SourceFileChecksums.File; !commit.Data.Attributes.Uploaded || file.Hash != hex.EncodeToString(sum[:]) || file.Algorithm != "MD5" {
+		t.Errorf("upload commit = %+v, want it uploaded with the MD5 of the IPA", commit.Data.Attributes)
+	}
+
+	var localization struct {
+		Data resource `json:"data"`
+	}
+	json.Unmarshal(fake.bodies["POST /v1/betaBuildLocalizations"], &localization)
+	if attributes := localization.Data.Attributes; attributes["locale"] != "en-US" || attributes["whatsNew"] != "Try the new checkout" {
+		t.Errorf("What to Test notes = %v", attributes)
+	}
+	var groupBuilds struct {
+		Data []map[string]string `json:"data"`
+	}
+	json.Unmarshal(fake.bodies["POST /v1/betaGroups/group-qa/relationships/builds"], &groupBuilds)
+	if len(groupBuilds.Data) != 1 || groupBuilds.Data[0]["id"] != "build-1" {
+		t.Errorf("group assignment = %v, want build-1", groupBuilds.Data)
+	}
+	if strings.Count(log.String(), StateProcessing) != 1 {
+		t.Errorf("Publish() logged %q, want every processing state once", log.String())
+	}
+}
+
+func TestPublishUpdatesExistingNotes(t *testing.T) {
+	fake, client := newFakeConnect(t, StateValid)
+	fake.localizations = []string{"de-DE", "en-US"}
+
+	if _, err := client.Publish(testUpload(t), io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	last := fake.calls[len(fake.calls)-1]
+	if last != "PATCH /v1/betaBuildLocalizations/localization-en-US" {
+		t.Errorf("last call = %q, want the en-US notes to be updated", last)
+	}
+	var update struct {
+		Data resource `json:"data"`
+	}
+	json.Unmarshal(fake.bodies[last], &update)
+	if update.Data.ID != "localization-en-US" || update.Data.Attributes["whatsNew"] != "Try the new checkout" {
+		t.Errorf("notes update = %+v", update.Data)
+	}
+}
+
+func TestPublishProcessingFails(t *testing.T) {
+	tests := []struct {
+		name    string
+		states  []string
+		timeout time.Duration
+		err     string
+	}{
+		{name: "failed", states: []string{StateProcessing, StateFailed}, timeout: time.Minute, err: "it is FAILED"},
+		{name: "invalid", states: []string{StateInvalid}, timeout: time.Minute, err: "it is INVALID"},
+		{name: "timeout", states: []string{StateProcessing}, timeout: 20 * time.Millisecond, err: "was not processed within 20ms"},
+		{name: "never listed", states: []string{""}, timeout: 20 * time.Millisecond, err: "was not processed within 20ms"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, client := newFakeConnect(t, test.states...)
+			upload := testUpload(t, "QA")
+			upload.Timeout = test.timeout
+
+			_, err := client.Publish(upload, io.Discard)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Publish() = %v, want an error containing %q", err, test.err)
+			}
+			for _, call := range fake.calls {
+				if strings.Contains(call, "betaBuildLocalizations") || strings.Contains(call, "relationships/builds") {
+					t.Errorf("Publish() called %s for a build that was not processed", call)
+				}
+			}
+		})
+	}
+}
+
+func TestPublishUnknownGroup(t *testing.T) {
+	fake, client := newFakeConnect(t, StateValid)
+
+	_, err := client.Publish(testUpload(t, "External"), io.Discard)
+	if err == nil || !strings.Contains(err.Error(), `no beta group named "External", the app has QA, Beta Testers`) {
+		t.Errorf("Publish() = %v, want an error listing the groups", err)
+	}
+	for _, call := range fake.calls {
+		if strings.Contains(call, "buildUploads") {
+			t.Errorf("Publish() uploaded the IPA before failing on the group")
+		}
+	}
+}
+
+func TestPublishTooLongNotes(t *testing.T) {
+	fake, client := newFakeConnect(t, StateValid)
+	upload := testUpload(t)
+	upload.WhatToTest = strings.Repeat("é", MaxWhatToTestLength+1)
+
+	if _, err := client.Publish(upload, io.Discard); err == nil || !strings.Contains(err.Error(), "4001 characters") {
+		t.Errorf("Publish() = %v, want the notes to be rejected", err)
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("Publish() called the API: %q", fake.calls)
+	}
+}
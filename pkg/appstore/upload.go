@@ -0,0 +1,141 @@
+package appstore
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// uploadOperation is one part of a file upload, sent to a pre-signed URL without the API token.
+type uploadOperation struct {
+	Method         string `json:"method"`
+	URL            string `json:"url"`
+	Length         int64  `json:"length"`
+	Offset         int64  `json:"offset"`
+	RequestHeaders []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"requestHeaders"`
+}
+
+// UploadIPA uploads the IPA as the build of the app with the version and build number. App Store Connect then
+// processes the build, which FindBuild reports.
+func (c *Client) UploadIPA(appID, version, buildNumber, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	upload, err := c.create("/v1/buildUploads", resource{
+		Type: "buildUploads",
+		Attributes: map[string]any{
+			"cfBundleShortVersionString": version,
+			"cfBundleVersion":            buildNumber,
+			"platform":                   "IOS",
+		},
+		Relationships: map[string]any{"app": relationship("apps", appID)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create the build upload: %v", err)
+	}
+
+	var reservation struct {
+		Data struct {
+			ID         string `json:"id"`
+			Attributes struct {
+				UploadOperations []uploadOperation `json:"uploadOperations"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	err = c.call(http.MethodPost, "/v1/buildUploadFiles", map[string]any{"data": resource{
+		Type: "buildUploadFiles",
+		Attributes: map[string]any{
+			"assetType": "ASSET",
+			"fileName":  filepath.Base(path),
+			"fileSize":  info.Size(),
+			"uti":       "com.apple.ipa",
+		},
+		Relationships: map[string]any{"buildUpload": relationship("buildUploads", upload.ID)},
+	}}, &reservation)
+	if err != nil {
+		return fmt.Errorf("failed to reserve the upload of %s: %v", filepath.Base(path), err)
+	}
+
+	for i, operation := range reservation.Data.Attributes.UploadOperations {
+		if err := c.uploadPart(file, operation); err != nil {
+			return fmt.Errorf("failed to upload part %d of %s: %v", i+1, filepath.Base(path), err)
+		}
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	hash := md5.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return err
+	}
+	commit := resource{
+		Type: "buildUploadFiles",
+		ID:   reservation.Data.ID,
+		Attributes: map[string]any{
+			"uploaded": true,
+			"sourceFileChecksums": map[string]any{
+				"file": map[string]string{"hash": hex.EncodeToString(hash.Sum(nil)), "algorithm": "MD5"},
+			},
+		},
+	}
+	if err := c.call(http.MethodPatch, "/v1/buildUploadFiles/"+url.PathEscape(reservation.Data.ID), map[string]any{"data": commit}, nil); err != nil {
+		return fmt.Errorf("failed to commit the upload of %s: %v", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// uploadPart sends the part of the file the operation covers, trying it up to three times.
+func (c *Client) uploadPart(file io.ReaderAt, operation uploadOperation) error {
+	part := make([]byte, operation.Length)
+	if _, err := file.ReadAt(part, operation.Offset); err != nil && err != io.EOF {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= 3; attempt++ {
+		if attempt > 1 {
+			time.Sleep(time.Duration(attempt-1) * time.Second)
+		}
+
+		req, err := http.NewRequest(operation.Method, operation.URL, bytes.NewReader(part))
+		if err != nil {
+			return err
+		}
+		for _, header := range operation.RequestHeaders {
+			req.Header.Set(header.Name, header.Value)
+		}
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("HTTP %d", resp.StatusCode)
+		if resp.StatusCode < 500 {
+			break
+		}
+	}
+	return lastErr
+}
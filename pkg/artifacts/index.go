@@ -0,0 +1,405 @@
+package artifacts
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// ManifestFile is the name of the manifest written next to the collected artifacts.
+const ManifestFile = "artifacts.json"
+
+// Artifact is a single collected build output.
+type Artifact struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes the artifacts of one build. It is the contract between bob and the release tooling.
+type Manifest struct {
+	Platform        string            `json:"platform"`
+	Version         string            `json:"version"`
+	BuildNumber     int               `json:"buildNumber"`
+	GitCommit       string            `json:"gitCommit,omitempty"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	DurationSeconds float64           `json:"durationSeconds"`
+	Toolchain       map[string]string `json:"toolchain"`
+	Bundle          *Bundle           `json:"bundle,omitempty"`
+	Artifacts       []Artifact        `json:"artifacts"`
+}
+
+// Bundle describes the JavaScript bundle shipped in a build.
+type Bundle struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Hermes bool   `json:"hermes"`
+}
+
+// hermesMagic starts every Hermes bytecode file, stored little endian.
+var hermesMagic = []byte{0xc6, 0x1f, 0xbc, 0x03, 0xc1, 0x03, 0x19, 0x1f}
+
+// bundlePatterns lists where the native builds leave the JavaScript bundle they embed.
+var bundlePatterns = map[string][]string{
+	"android": {
+		"android/app/build/generated/assets/*/index.android.bundle",
+		"android/app/build/generated/assets/react/*/index.android.bundle",
+		"android/app/build/intermediates/assets/*/index.android.bundle",
+	},
+	"ios": {
+		"ios/build/*.xcarchive/Products/Applications/*.app/main.jsbundle",
+	},
+}
+
+// outputPatterns lists where Gradle and Xcode leave the outputs worth keeping, relative to the project directory.
+var outputPatterns = map[string][]struct {
+	kind string
+	glob string
+}{
+	"android": {
+		{"apk", "android/app/build/outputs/apk/*/*.apk"},
+		{"apk", "android/app/build/outputs/apk/*/*/*.apk"},
+		{"aab", "android/app/build/outputs/bundle/*/*.aab"},
+		{"mapping", "android/app/build/outputs/mapping/*/mapping.txt"},
+	},
+	"ios": {
+		{"ipa", "ios/build/*.ipa"},
+		{"ipa", "ios/build/*/*.ipa"},
+		{"dsym", "ios/build/*.xcarchive/dSYMs/*.dSYM"},
+	},
+}
+
+// Find returns the build outputs of the platform that were modified at or after since.
+func Find(projectDir, platform string, since time.Time) (map[string]string, error) {
+	patterns, ok := outputPatterns[platform]
+	if !ok {
+		return nil, fmt.Errorf("unknown platform %q", platform)
+	}
+
+	// File systems record modification times with a coarse clock, so allow for some slack.
+	since = since.Truncate(time.Second)
+
+	found := map[string]string{}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(projectDir, pattern.glob))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil || info.ModTime().Before(since) {
+				continue
+			}
+			found[match] = pattern.kind
+		}
+	}
+
+	return found, nil
+}
+
+// FindBundle returns the JavaScript bundle of the platform's native build that was modified at or after since,
+// or "" when there is none.
+func FindBundle(projectDir, platform string, since time.Time) string {
+	since = since.Truncate(time.Second)
+	for _, pattern := range bundlePatterns[platform] {
+		matches, _ := filepath.Glob(filepath.Join(projectDir, pattern))
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.ModTime().Before(since) {
+				return match
+			}
+		}
+	}
+	return ""
+}
+
+// DescribeBundle reports the size of a JavaScript bundle and whether it was compiled to Hermes bytecode.
+func DescribeBundle(path string) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(hermesMagic))
+	n, _ := io.ReadFull(f, header)
+
+	return &Bundle{
+		Name:   filepath.Base(path),
+		Size:   info.Size(),
+		Hermes: n == len(header) && string(header) == string(hermesMagic),
+	}, nil
+}
+
+// Collect copies the given outputs into distDir and writes the manifest describing them.
+// dSYM bundles are zipped so that every artifact is a single file with a checksum.
+// distDir is cleared first, which is only done when it holds the artifacts of an earlier build.
+func Collect(distDir string, outputs map[string]string, manifest *Manifest) error {
+	if manifest.Version == "" {
+		return fmt.Errorf("the build has no version, refusing to collect its artifacts")
+	}
+	if err := Clean(distDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(distDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create %s: %v", distDir, err)
+	}
+
+	sources := make([]string, 0, len(outputs))
+	for source := range outputs {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	for _, source := range sources {
+		kind := outputs[source]
+		name := artifactName(source, kind)
+		dest := filepath.Join(distDir, name)
+
+		var err error
+		if kind == "dsym" {
+			err = zipDir(source, dest)
+		} else {
+			err = copyFile(source, dest)
+		}
+		if err != nil {
+			return err
+		}
+
+		artifact, err := Describe(dest, kind)
+		if err != nil {
+			return err
+		}
+		manifest.Artifacts = append(manifest.Artifacts, *artifact)
+	}
+
+	return WriteManifest(distDir, manifest)
+}
+
+// Clean removes the artifacts of an earlier build from distDir. It refuses to remove a directory that has
+// contents but no artifacts.json, since that is not one bob collected into.
+func Clean(distDir string) error {
+	entries, err := os.ReadDir(distDir)
+	if os.IsNotExist(err) || (err == nil && len(entries) == 0) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", distDir, err)
+	}
+	if _, err := os.Stat(filepath.Join(distDir, ManifestFile)); err != nil {
+		return fmt.Errorf("%s is not empty and has no %s, refusing to replace it with build artifacts", distDir, ManifestFile)
+	}
+
+	if err := os.RemoveAll(distDir); err != nil {
+		return fmt.Errorf("failed to clean %s: %v", distDir, err)
+	}
+	return nil
+}
+
+// artifactName picks a file name that stays unique when several variants produce outputs with the same name.
+func artifactName(source, kind string) string {
+	switch kind {
+	case "mapping":
+		// android/app/build/outputs/mapping/<variant>/mapping.txt
+		return "mapping-" + filepath.Base(filepath.Dir(source)) + ".txt"
+	case "dsym":
+		return filepath.Base(source) + ".zip"
+	default:
+		return filepath.Base(source)
+	}
+}
+
+// Describe computes the size and SHA-256 checksum of an artifact.
+func Describe(path, kind string) (*Artifact, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum %s: %v", path, err)
+	}
+
+	return &Artifact{
+		Name:   filepath.Base(path),
+		Kind:   kind,
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// WriteManifest writes the manifest as artifacts.json in distDir.
+func WriteManifest(distDir string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(distDir, ManifestFile)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// ReadManifest reads the artifacts.json in distDir.
+func ReadManifest(distDir string) (*Manifest, error) {
+	path := filepath.Join(distDir, ManifestFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return manifest, nil
+}
+
+// LatestManifestDir returns the directory under root holding the most recently written artifacts.json,
+// optionally only among those of the given platform.
+func LatestManifestDir(root, platform string) (string, error) {
+	latest := ""
+	var latestTime time.Time
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || info.Name() != ManifestFile {
+			return err
+		}
+		if platform != "" {
+			if manifest, err := ReadManifest(filepath.Dir(path)); err != nil || manifest.Platform != platform {
+				return nil
+			}
+		}
+		if info.ModTime().After(latestTime) {
+			latest, latestTime = filepath.Dir(path), info.ModTime()
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to search %s for build artifacts: %v", root, err)
+	}
+	if latest == "" {
+		return "", fmt.Errorf("no %s found in %s, run bob build first", ManifestFile, root)
+	}
+	return latest, nil
+}
+
+// GitCommit returns the commit the project is checked out at, or "" outside a git repository.
+func GitCommit(projectDir string) string {
+	output, err := utils.RunCommandInDirWithOutput(projectDir, nil, "git", "rev-parse", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+var versionNumberRegexp = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// toolchainCommands lists the tools whose versions are recorded for each platform.
+var toolchainCommands = map[string][][]string{
+	"android": {
+		{"node", "--version"},
+		{"java", "-version"},
+	},
+	"ios": {
+		{"node", "--version"},
+		{"xcodebuild", "-version"},
+		{"pod", "--version"},
+	},
+}
+
+// ToolchainVersions returns the versions of the tools used to build the platform. Missing tools are left out.
+func ToolchainVersions(platform string) map[string]string {
+	versions := map[string]string{}
+	for _, command := range toolchainCommands[platform] {
+		output, err := utils.RunCommandWithOutput(command[0], command[1:]...)
+		if err != nil {
+			continue
+		}
+		if match := versionNumberRegexp.FindString(output); match != "" {
+			versions[command[0]] = match
+		}
+	}
+
+	return versions
+}
+
+func copyFile(source, dest string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", source, err)
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", dest, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %v", source, err)
+	}
+
+	return out.Close()
+}
+
+func zipDir(source, dest string) error {
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", dest, err)
+	}
+	defer out.Close()
+
+	writer := zip.NewWriter(out)
+	base := filepath.Dir(source)
+	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		name, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		entry, err := writer.Create(filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		_, err = io.Copy(entry, in)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to zip %s: %v", source, err)
+	}
+
+	return writer.Close()
+}
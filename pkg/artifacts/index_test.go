@@ -0,0 +1,76 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	apk := filepath.Join(dir, "android", "app", "build", "outputs", "apk", "release", "app-release.apk")
+	mapping := filepath.Join(dir, "android", "app", "build", "outputs", "mapping", "release", "mapping.txt")
+	writeFile(t, apk, "apk")
+	writeFile(t, mapping, "mapping")
+
+	dist := filepath.Join(dir, "dist", "android", "1.2.3")
+	writeFile(t, filepath.Join(dist, ManifestFile), "{}")
+	writeFile(t, filepath.Join(dist, "stale.apk"), "stale")
+
+	manifest := &Manifest{Platform: "android", Version: "1.2.3"}
+	err := Collect(dist, map[string]string{apk: "apk", mapping: "mapping"}, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dist, "stale.apk")); !os.IsNotExist(err) {
+		t.Error("the artifacts of the earlier build were kept")
+	}
+	written, err := ReadManifest(dist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, artifact := range written.Artifacts {
+		names = append(names, artifact.Name+":"+artifact.Kind)
+	}
+	if got := strings.Join(names, ","); got != "app-release.apk:apk,mapping-release.txt:mapping" {
+		t.Errorf("artifacts = %s", got)
+	}
+	if written.Artifacts[0].SHA256 != "dd37c2d7274f7ea982cb83390c36918fee9ce8889073c44b68cdc00bdb8c3e04" || written.Artifacts[0].Size != 3 {
+		t.Errorf("apk artifact = %+v", written.Artifacts[0])
+	}
+}
+
+func TestCollectRefuses(t *testing.T) {
+	dir := t.TempDir()
+	apk := filepath.Join(dir, "app-release.apk")
+	writeFile(t, apk, "apk")
+
+	// A directory that bob did not collect into, such as the Android project behind --dist ., is left alone.
+	project := filepath.Join(dir, "android")
+	writeFile(t, filepath.Join(project, "build.gradle"), "android {}")
+	err := Collect(project, map[string]string{apk: "apk"}, &Manifest{Platform: "android", Version: "1.2.3"})
+	if err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("Collect() into a project directory error = %v, want a refusal", err)
+	}
+	if _, err := os.Stat(filepath.Join(project, "build.gradle")); err != nil {
+		t.Errorf("build.gradle was removed: %v", err)
+	}
+
+	err = Collect(filepath.Join(dir, "dist"), map[string]string{apk: "apk"}, &Manifest{Platform: "android"})
+	if err == nil || !strings.Contains(err.Error(), "no version") {
+		t.Errorf("Collect() without a version error = %v, want a refusal", err)
+	}
+}
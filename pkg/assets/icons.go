@@ -0,0 +1,303 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/ios"
+)
+
+// Density is an Android screen density bucket and its scale relative to mdpi.
+type Density struct {
+	Name  string
+	Scale float64
+}
+
+// Densities lists the Android density buckets assets are generated for.
+var Densities = []Density{
+	{"mdpi", 1},
+	{"hdpi", 1.5},
+	{"xhdpi", 2},
+	{"xxhdpi", 3},
+	{"xxxhdpi", 4},
+}
+
+const (
+	// launcherIconSize is the size of a legacy launcher icon in dp.
+	launcherIconSize = 48
+	// adaptiveIconSize is the size of an adaptive icon layer in dp. Only the center 66dp is guaranteed to be visible.
+	adaptiveIconSize = 108
+	adaptiveSafeZone = 66.0 / 108.0
+)
+
+// iosIcon is one entry of an AppIcon.appiconset.
+type iosIcon struct {
+	Idiom string
+	Size  float64
+	Scale int
+}
+
+var iosIcons = []iosIcon{
+	{"iphone", 20, 2}, {"iphone", 20, 3},
+	{"iphone", 29, 2}, {"iphone", 29, 3},
+	{"iphone", 40, 2}, {"iphone", 40, 3},
+	{"iphone", 60, 2}, {"iphone", 60, 3},
+	{"ipad", 20, 1}, {"ipad", 20, 2},
+	{"ipad", 29, 1}, {"ipad", 29, 2},
+	{"ipad", 40, 1}, {"ipad", 40, 2},
+	{"ipad", 76, 1}, {"ipad", 76, 2},
+	{"ipad", 83.5, 2},
+	{"ios-marketing", 1024, 1},
+}
+
+// assetCatalogContents is the Contents.json of an asset catalog entry.
+type assetCatalogContents struct {
+	Images []assetCatalogImage `json:"images,omitempty"`
+	Colors []assetCatalogColor `json:"colors,omitempty"`
+	Info   assetCatalogInfo    `json:"info"`
+}
+
+type assetCatalogImage struct {
+	Filename string `json:"filename,omitempty"`
+	Idiom    string `json:"idiom"`
+	Scale    string `json:"scale"`
+	Size     string `json:"size,omitempty"`
+}
+
+type assetCatalogColor struct {
+	Color struct {
+		ColorSpace string            `json:"color-space"`
+		Components map[string]string `json:"components"`
+	} `json:"color"`
+	Idiom string `json:"idiom"`
+}
+
+type assetCatalogInfo struct {
+	Author  string `json:"author"`
+	Version int    `json:"version"`
+}
+
+// IconOptions configures icon generation.
+type IconOptions struct {
+	// Background fills the adaptive icon background layer and the transparent parts of iOS icons.
+	Background color.NRGBA
+}
+
+// AndroidResDir returns the resource directory of the app module.
+func AndroidResDir(projectDir string) string {
+	return filepath.Join(projectDir, "android", "app", "src", "main", "res")
+}
+
+// IosAssetCatalog returns the asset catalog of the iOS app, or "" when the project has no iOS app.
+func IosAssetCatalog(projectDir string) string {
+	iosDir := filepath.Join(projectDir, "ios")
+	for _, name := range []string{"Images.xcassets", "Assets.xcassets"} {
+		matches, _ := filepath.Glob(filepath.Join(iosDir, "*", name))
+		for _, match := range matches {
+			if !strings.HasPrefix(filepath.Base(filepath.Dir(match)), "Pods") {
+				return match
+			}
+		}
+	}
+
+	xcodeproj, err := ios.FindXcodeProject(iosDir)
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(iosDir, strings.TrimSuffix(filepath.Base(xcodeproj), ".xcodeproj"), "Images.xcassets")
+}
+
+// GenerateIcons writes the launcher icons of both platforms from a single square source image.
+// It returns the files it wrote.
+func GenerateIcons(projectDir, source string, options IconOptions) ([]string, error) {
+	src, err := LoadImage(source)
+	if err != nil {
+		return nil, err
+	}
+	if bounds := src.Bounds(); bounds.Dx() != bounds.Dy() {
+		return nil, fmt.Errorf("icon %s must be square, it is %dx%d", source, bounds.Dx(), bounds.Dy())
+	}
+
+	var written []string
+	if _, err := os.Stat(filepath.Join(projectDir, "android")); err == nil {
+		files, err := GenerateAndroidIcons(AndroidResDir(projectDir), src, options)
+		written = append(written, files...)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	if catalog := IosAssetCatalog(projectDir); catalog != "" {
+		files, err := GenerateIosIcons(filepath.Join(catalog, "AppIcon.appiconset"), src, options)
+		written = append(written, files...)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// GenerateAndroidIcons writes legacy, round and adaptive launcher icons into the mipmap directories of resDir.
+func GenerateAndroidIcons(resDir string, src image.Image, options IconOptions) ([]string, error) {
+	var written []string
+	save := func(path string, img image.Image) error {
+		written = append(written, path)
+		return SavePNG(path, img)
+	}
+
+	for _, density := range Densities {
+		dir := filepath.Join(resDir, "mipmap-"+density.Name)
+		size := int(launcherIconSize * density.Scale)
+
+		icon := Resize(src, size, size)
+		if err := save(filepath.Join(dir, "ic_launcher.png"), icon); err != nil {
+			return written, err
+		}
+		if err := save(filepath.Join(dir, "ic_launcher_round.png"), circle(icon)); err != nil {
+			return written, err
+		}
+
+		foreground := Fit(src, int(adaptiveIconSize*density.Scale), adaptiveSafeZone, color.Transparent)
+		if err := save(filepath.Join(dir, "ic_launcher_foreground.png"), foreground); err != nil {
+			return written, err
+		}
+	}
+
+	adaptiveIcon := `<?xml version="1.0" encoding="utf-8"?>
+<adaptive-icon xmlns:android="http://schemas.android.com/apk/res/android">
+    <background android:drawable="@color/ic_launcher_background"/>
+    <foreground android:drawable="@mipmap/ic_launcher_foreground"/>
+</adaptive-icon>
+`
+	background := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <color name="ic_launcher_background">%s</color>
+</resources>
+`, hexColor(options.Background))
+
+	files := []struct{ path, content string }{
+		{filepath.Join(resDir, "mipmap-anydpi-v26", "ic_launcher.xml"), adaptiveIcon},
+		{filepath.Join(resDir, "mipmap-anydpi-v26", "ic_launcher_round.xml"), adaptiveIcon},
+		{filepath.Join(resDir, "values", "ic_launcher_background.xml"), background},
+	}
+	for _, file := range files {
+		written = append(written, file.path)
+		if err := writeFile(file.path, file.content); err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// GenerateIosIcons writes every icon size of an AppIcon.appiconset along with its Contents.json.
+// App Store icons may not be transparent, so the icons are flattened onto the background color.
+func GenerateIosIcons(appIconSetDir string, src image.Image, options IconOptions) ([]string, error) {
+	written, err := ensureCatalog(filepath.Dir(appIconSetDir))
+	if err != nil {
+		return written, err
+	}
+	flattened := Flatten(src, options.Background)
+	contents := assetCatalogContents{Info: assetCatalogInfo{Author: "bob", Version: 1}}
+
+	for _, icon := range iosIcons {
+		size := strconv.FormatFloat(icon.Size, 'f', -1, 64)
+		scale := strconv.Itoa(icon.Scale) + "x"
+		filename := fmt.Sprintf("Icon-%s@%s.png", size, scale)
+		contents.Images = append(contents.Images, assetCatalogImage{
+			Filename: filename,
+			Idiom:    icon.Idiom,
+			Scale:    scale,
+			Size:     size + "x" + size,
+		})
+
+		path := filepath.Join(appIconSetDir, filename)
+		if contains(written, path) {
+			continue
+		}
+		pixels := int(icon.Size*float64(icon.Scale) + 0.5)
+		written = append(written, path)
+		if err := SavePNG(path, Resize(flattened, pixels, pixels)); err != nil {
+			return written, err
+		}
+	}
+
+	path := filepath.Join(appIconSetDir, "Contents.json")
+	written = append(written, path)
+	return written, writeContents(path, contents)
+}
+
+// circle masks an icon to a circle for ic_launcher_round.
+func circle(src *image.NRGBA) *image.NRGBA {
+	dst := image.NewNRGBA(src.Bounds())
+	size := float64(src.Bounds().Dx())
+	radius := size / 2
+
+	for y := 0; y < src.Bounds().Dy(); y++ {
+		for x := 0; x < src.Bounds().Dx(); x++ {
+			dx, dy := float64(x)+0.5-radius, float64(y)+0.5-radius
+			// Anti-alias the edge over one pixel.
+			coverage := min(1, max(0, radius-math.Sqrt(dx*dx+dy*dy)+0.5))
+			if coverage == 0 {
+				continue
+			}
+
+			c := src.NRGBAAt(x, y)
+			c.A = uint8(float64(c.A) * coverage)
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+
+	return dst
+}
+
+func hexColor(c color.NRGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("#%02X%02X%02X%02X", c.A, c.R, c.G, c.B)
+}
+
+// ensureCatalog creates the Contents.json of an asset catalog that does not exist yet.
+func ensureCatalog(catalog string) ([]string, error) {
+	path := filepath.Join(catalog, "Contents.json")
+	if _, err := os.Stat(path); err == nil {
+		return nil, nil
+	}
+	return []string{path}, writeContents(path, assetCatalogContents{Info: assetCatalogInfo{Author: "bob", Version: 1}})
+}
+
+func writeContents(path string, contents assetCatalogContents) error {
+	data, err := json.MarshalIndent(contents, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(path, string(data)+"\n")
+}
+
+func writeFile(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
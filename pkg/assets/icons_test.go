@@ -0,0 +1,169 @@
+package assets
+
+import (
+	"flag"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+var iconBackground = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+
+// checkGolden compares the generated file at dir/rel with testdata/golden/rel. PNGs are compared pixel by pixel,
+// so that a different zlib in another Go release does not fail the test.
+func checkGolden(t *testing.T, dir, rel string) {
+	t.Helper()
+	path := filepath.Join(dir, rel)
+	golden := filepath.Join("testdata", "golden", rel)
+	if *update {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !strings.HasSuffix(rel, ".png") {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s differs from %s, run go test -update to inspect", rel, golden)
+		}
+		return
+	}
+
+	got, err := LoadImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := LoadImage(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Bounds() != want.Bounds() {
+		t.Fatalf("%s is %v, want %v", rel, got.Bounds().Size(), want.Bounds().Size())
+	}
+	for y := got.Bounds().Min.Y; y < got.Bounds().Max.Y; y++ {
+		for x := got.Bounds().Min.X; x < got.Bounds().Max.X; x++ {
+			g := color.NRGBAModel.Convert(got.At(x, y))
+			w := color.NRGBAModel.Convert(want.At(x, y))
+			if g != w {
+				t.Fatalf("%s differs from %s at (%d, %d): %v, want %v, run go test -update to inspect", rel, golden, x, y, g, w)
+			}
+		}
+	}
+}
+
+func loadFixture(t *testing.T, name string) image.Image {
+	t.Helper()
+	img, err := LoadImage(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestGenerateAndroidIcons(t *testing.T) {
+	resDir := t.TempDir()
+	written, err := GenerateAndroidIcons(resDir, loadFixture(t, "icon.png"), IconOptions{Background: iconBackground})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Three icons per density plus the two adaptive icon XMLs and the background color.
+	if want := len(Densities)*3 + 3; len(written) != want {
+		t.Errorf("GenerateAndroidIcons() wrote %d files, want %d", len(written), want)
+	}
+
+	sizes := map[string]int{
+		"mipmap-mdpi/ic_launcher.png":               48,
+		"mipmap-xxxhdpi/ic_launcher.png":            192,
+		"mipmap-xhdpi/ic_launcher_foreground.png":   216,
+		"mipmap-xxxhdpi/ic_launcher_foreground.png": 432,
+	}
+	for rel, size := range sizes {
+		img, err := LoadImage(filepath.Join(resDir, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := img.Bounds().Size(); got != image.Pt(size, size) {
+			t.Errorf("%s is %v, want %dx%d", rel, got, size, size)
+		}
+	}
+
+	for _, rel := range []string{
+		"mipmap-mdpi/ic_launcher.png",
+		"mipmap-mdpi/ic_launcher_round.png",
+		"mipmap-mdpi/ic_launcher_foreground.png",
+		"mipmap-anydpi-v26/ic_launcher.xml",
+		"values/ic_launcher_background.xml",
+	} {
+		t.Run(rel, func(t *testing.T) {
+			checkGolden(t, resDir, rel)
+		})
+	}
+}
+
+func TestGenerateIosIcons(t *testing.T) {
+	catalog := filepath.Join(t.TempDir(), "Images.xcassets")
+	appIconSet := filepath.Join(catalog, "AppIcon.appiconset")
+	written, err := GenerateIosIcons(appIconSet, loadFixture(t, "icon.png"), IconOptions{Background: iconBackground})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(catalog, "Contents.json")); err != nil {
+		t.Errorf("the asset catalog has no Contents.json: %v", err)
+	}
+	// Icon-20@2x.png is listed for both the iPhone and the iPad but written once.
+	seen := map[string]bool{}
+	for _, path := range written {
+		if seen[path] {
+			t.Errorf("GenerateIosIcons() wrote %s twice", path)
+		}
+		seen[path] = true
+	}
+
+	marketing, err := LoadImage(filepath.Join(appIconSet, "Icon-1024@1x.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := marketing.Bounds().Size(); got != image.Pt(1024, 1024) {
+		t.Errorf("Icon-1024@1x.png is %v, want 1024x1024", got)
+	}
+	// App Store icons may not have an alpha channel, the transparent corners are flattened onto the background.
+	if c := color.NRGBAModel.Convert(marketing.At(0, 0)).(color.NRGBA); c != iconBackground {
+		t.Errorf("Icon-1024@1x.png corner = %v, want the background %v", c, iconBackground)
+	}
+
+	for _, rel := range []string{
+		"AppIcon.appiconset/Icon-20@2x.png",
+		"AppIcon.appiconset/Icon-83.5@2x.png",
+		"AppIcon.appiconset/Contents.json",
+	} {
+		t.Run(rel, func(t *testing.T) {
+			checkGolden(t, catalog, rel)
+		})
+	}
+}
+
+func TestGenerateIconsRejectsNonSquare(t *testing.T) {
+	_, err := GenerateIcons(t.TempDir(), filepath.Join("testdata", "logo.png"), IconOptions{})
+	if err == nil || !strings.Contains(err.Error(), "must be square") {
+		t.Errorf("GenerateIcons() error = %v, want a must be square error", err)
+	}
+}
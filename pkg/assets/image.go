@@ -0,0 +1,181 @@
+package assets
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadImage decodes a PNG or JPEG file.
+func LoadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+
+	return img, nil
+}
+
+// SavePNG encodes an image as PNG, creating the parent directory when needed.
+func SavePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %v", path, err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to encode %s: %v", path, err)
+	}
+
+	return f.Close()
+}
+
+// ParseHexColor parses #RGB, #RRGGBB or #RRGGBBAA colors.
+func ParseHexColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 8 || err != nil {
+		return color.NRGBA{}, fmt.Errorf("invalid color %q, expected #RRGGBB", s)
+	}
+
+	return color.NRGBA{R: uint8(value >> 24), G: uint8(value >> 16), B: uint8(value >> 8), A: uint8(value)}, nil
+}
+
+// Resize scales an image to the given size with an area-averaging filter, which gives clean results when
+// shrinking a large source icon. Averaging happens in premultiplied alpha so transparent edges do not darken.
+func Resize(src image.Image, width, height int) *image.NRGBA {
+	rgba := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, src.Bounds().Min, draw.Src)
+
+	srcWidth, srcHeight := rgba.Bounds().Dx(), rgba.Bounds().Dy()
+	pix := make([]float64, srcWidth*srcHeight*4)
+	for y := 0; y < srcHeight; y++ {
+		for x := 0; x < srcWidth*4; x++ {
+			pix[y*srcWidth*4+x] = float64(rgba.Pix[y*rgba.Stride+x])
+		}
+	}
+
+	horizontal := resample(pix, srcWidth, srcHeight, width, true)
+	vertical := resample(horizontal, width, srcHeight, height, false)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(vertical); i += 4 {
+		a := vertical[i+3]
+		if a == 0 {
+			continue
+		}
+		dst.Pix[i] = uint8(min(255, vertical[i]*255/a+0.5))
+		dst.Pix[i+1] = uint8(min(255, vertical[i+1]*255/a+0.5))
+		dst.Pix[i+2] = uint8(min(255, vertical[i+2]*255/a+0.5))
+		dst.Pix[i+3] = uint8(min(255, a+0.5))
+	}
+
+	return dst
+}
+
+// resample scales tightly packed premultiplied RGBA pixels along one axis.
+func resample(pix []float64, srcWidth, srcHeight, dstSize int, horizontal bool) []float64 {
+	at := func(x, y, c int) float64 {
+		return pix[(y*srcWidth+x)*4+c]
+	}
+
+	srcSize, lines := srcWidth, srcHeight
+	outWidth, outHeight := dstSize, srcHeight
+	if !horizontal {
+		srcSize, lines = srcHeight, srcWidth
+		outWidth, outHeight = srcWidth, dstSize
+	}
+	out := make([]float64, outWidth*outHeight*4)
+	scale := float64(srcSize) / float64(dstSize)
+
+	for d := 0; d < dstSize; d++ {
+		start, end := float64(d)*scale, float64(d+1)*scale
+		if scale < 1 {
+			// Enlarging: sample the single nearest source pixel.
+			start = float64(int(start))
+			end = start + 1
+		}
+
+		for line := 0; line < lines; line++ {
+			var sum [4]float64
+			total := 0.0
+			for s := int(start); float64(s) < end && s < srcSize; s++ {
+				weight := min(end, float64(s+1)) - max(start, float64(s))
+				total += weight
+				for c := 0; c < 4; c++ {
+					if horizontal {
+						sum[c] += at(s, line, c) * weight
+					} else {
+						sum[c] += at(line, s, c) * weight
+					}
+				}
+			}
+
+			x, y := d, line
+			if !horizontal {
+				x, y = line, d
+			}
+			for c := 0; c < 4; c++ {
+				out[(y*outWidth+x)*4+c] = sum[c] / total
+			}
+		}
+	}
+
+	return out
+}
+
+// Fit scales src to fit inside a size×size square, scaled by the given fraction of the square, centered on a
+// canvas filled with background. A transparent background keeps the alpha channel.
+func Fit(src image.Image, size int, fraction float64, background color.Color) *image.NRGBA {
+	canvas := image.NewNRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	box := float64(size) * fraction
+	width, height := float64(src.Bounds().Dx()), float64(src.Bounds().Dy())
+	scale := min(box/width, box/height)
+	w, h := max(1, int(width*scale+0.5)), max(1, int(height*scale+0.5))
+
+	scaled := Resize(src, w, h)
+	offset := image.Pt((size-w)/2, (size-h)/2)
+	draw.Draw(canvas, scaled.Bounds().Add(offset), scaled, image.Point{}, draw.Over)
+
+	return canvas
+}
+
+// Flatten composites an image onto an opaque background, as required for iOS app icons.
+func Flatten(src image.Image, background color.Color) *image.NRGBA {
+	bg := color.NRGBAModel.Convert(background).(color.NRGBA)
+	bg.A = 255
+
+	dst := image.NewNRGBA(src.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, src.Bounds().Min, draw.Over)
+
+	return dst
+}
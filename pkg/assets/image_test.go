@@ -0,0 +1,58 @@
+package assets
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestParseHexColor(t *testing.T) {
+	tests := map[string]color.NRGBA{
+		"#fff":      {R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		"#1E88E5":   {R: 0x1e, G: 0x88, B: 0xe5, A: 0xff},
+		" 1e88e5 ":  {R: 0x1e, G: 0x88, B: 0xe5, A: 0xff},
+		"#1e88e580": {R: 0x1e, G: 0x88, B: 0xe5, A: 0x80},
+	}
+	for input, want := range tests {
+		if got, err := ParseHexColor(input); err != nil || got != want {
+			t.Errorf("ParseHexColor(%q) = %v, %v, want %v", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "#ff", "#fffff", "#gggggg", "#1e88e5801"} {
+		if _, err := ParseHexColor(input); err == nil {
+			t.Errorf("ParseHexColor(%q) succeeded, want an error", input)
+		}
+	}
+}
+
+func TestResizeKeepsTransparentEdgesBright(t *testing.T) {
+	// A white pixel next to a transparent black one: averaging them must not darken the white.
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+
+	got := Resize(src, 1, 1).NRGBAAt(0, 0)
+	if want := (color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0x80}); got != want {
+		t.Errorf("Resize() = %v, want %v", got, want)
+	}
+}
+
+func TestFit(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 20, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 20; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: 0xff, A: 0xff})
+		}
+	}
+
+	// The logo is scaled to half of the 40px canvas, 20x10, and centered.
+	canvas := Fit(src, 40, 0.5, color.Transparent)
+	if got := canvas.NRGBAAt(20, 20); got.R != 0xff || got.A != 0xff {
+		t.Errorf("Fit() center = %v, want the logo", got)
+	}
+	for _, p := range []image.Point{{9, 20}, {30, 20}, {20, 14}, {20, 25}} {
+		if got := canvas.NRGBAAt(p.X, p.Y); got.A != 0 {
+			t.Errorf("Fit() at %v = %v, want transparent", p, got)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package assets
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// DefaultSplashLogoSize is the width of the splash logo in dp (points on iOS).
+const DefaultSplashLogoSize = 200
+
+// SplashOptions configures splash screen generation.
+type SplashOptions struct {
+	Background color.NRGBA
+	// LogoSize is the width of the logo in dp. The height follows the source's aspect ratio.
+	LogoSize int
+}
+
+// GenerateSplash writes splash screen assets of both platforms from a logo image. It returns the files it wrote.
+//
+// On Android this produces drawable/splash_screen.xml, a layer list that can be used as the launch theme's
+// windowBackground. On iOS it produces the SplashLogo image set and SplashBackground color set for use in
+// LaunchScreen.storyboard.
+func GenerateSplash(projectDir, source string, options SplashOptions) ([]string, error) {
+	src, err := LoadImage(source)
+	if err != nil {
+		return nil, err
+	}
+	if options.LogoSize <= 0 {
+		options.LogoSize = DefaultSplashLogoSize
+	}
+
+	var written []string
+	if _, err := os.Stat(filepath.Join(projectDir, "android")); err == nil {
+		files, err := GenerateAndroidSplash(AndroidResDir(projectDir), src, options)
+		written = append(written, files...)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	if catalog := IosAssetCatalog(projectDir); catalog != "" {
+		files, err := GenerateIosSplash(catalog, src, options)
+		written = append(written, files...)
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// GenerateAndroidSplash writes the splash logo at every density along with its background color and layer list.
+func GenerateAndroidSplash(resDir string, src image.Image, options SplashOptions) ([]string, error) {
+	var written []string
+	for _, density := range Densities {
+		width, height := logoSize(src, float64(options.LogoSize)*density.Scale)
+		path := filepath.Join(resDir, "drawable-"+density.Name, "splash_logo.png")
+		written = append(written, path)
+		if err := SavePNG(path, Resize(src, width, height)); err != nil {
+			return written, err
+		}
+	}
+
+	files := []struct{ path, content string }{
+		{filepath.Join(resDir, "drawable", "splash_screen.xml"), `<?xml version="1.0" encoding="utf-8"?>
+<layer-list xmlns:android="http://schemas.android.com/apk/res/android" android:opacity="opaque">
+    <item android:drawable="@color/splash_background"/>
+    <item>
+        <bitmap android:gravity="center" android:src="@drawable/splash_logo"/>
+    </item>
+</layer-list>
+`},
+		{filepath.Join(resDir, "values", "splash_background.xml"), fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<resources>
+    <color name="splash_background">%s</color>
+</resources>
+`, hexColor(options.Background))},
+	}
+	for _, file := range files {
+		written = append(written, file.path)
+		if err := writeFile(file.path, file.content); err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// GenerateIosSplash writes the SplashLogo image set and SplashBackground color set into an asset catalog.
+func GenerateIosSplash(catalog string, src image.Image, options SplashOptions) ([]string, error) {
+	written, err := ensureCatalog(catalog)
+	if err != nil {
+		return written, err
+	}
+	imageSet := filepath.Join(catalog, "SplashLogo.imageset")
+	logo := assetCatalogContents{Info: assetCatalogInfo{Author: "bob", Version: 1}}
+
+	for scale := 1; scale <= 3; scale++ {
+		filename := "splash_logo.png"
+		if scale > 1 {
+			filename = fmt.Sprintf("splash_logo@%dx.png", scale)
+		}
+		logo.Images = append(logo.Images, assetCatalogImage{
+			Filename: filename,
+			Idiom:    "universal",
+			Scale:    strconv.Itoa(scale) + "x",
+		})
+
+		width, height := logoSize(src, float64(options.LogoSize*scale))
+		path := filepath.Join(imageSet, filename)
+		written = append(written, path)
+		if err := SavePNG(path, Resize(src, width, height)); err != nil {
+			return written, err
+		}
+	}
+
+	path := filepath.Join(imageSet, "Contents.json")
+	written = append(written, path)
+	if err := writeContents(path, logo); err != nil {
+		return written, err
+	}
+
+	background := assetCatalogColor{Idiom: "universal"}
+	background.Color.ColorSpace = "srgb"
+	background.Color.Components = map[string]string{
+		"red":   fmt.Sprintf("0x%02X", options.Background.R),
+		"green": fmt.Sprintf("0x%02X", options.Background.G),
+		"blue":  fmt.Sprintf("0x%02X", options.Background.B),
+		"alpha": strconv.FormatFloat(float64(options.Background.A)/255, 'f', 3, 64),
+	}
+	contents := assetCatalogContents{
+		Colors: []assetCatalogColor{background},
+		Info:   assetCatalogInfo{Author: "bob", Version: 1},
+	}
+
+	path = filepath.Join(catalog, "SplashBackground.colorset", "Contents.json")
+	written = append(written, path)
+	return written, writeContents(path, contents)
+}
+
+// logoSize returns the pixel size of a logo scaled to the given width, keeping its aspect ratio.
+func logoSize(src image.Image, width float64) (int, int) {
+	bounds := src.Bounds()
+	height := width * float64(bounds.Dy()) / float64(bounds.Dx())
+	return max(1, int(width+0.5)), max(1, int(height+0.5))
+}
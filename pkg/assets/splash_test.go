@@ -0,0 +1,59 @@
+package assets
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"testing"
+)
+
+var splashBackground = color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}
+
+func TestGenerateAndroidSplash(t *testing.T) {
+	resDir := t.TempDir()
+	options := SplashOptions{Background: splashBackground, LogoSize: 100}
+	if _, err := GenerateAndroidSplash(resDir, loadFixture(t, "logo.png"), options); err != nil {
+		t.Fatal(err)
+	}
+
+	// The 2:1 logo keeps its aspect ratio at every density.
+	for _, density := range Densities {
+		rel := filepath.Join("drawable-"+density.Name, "splash_logo.png")
+		img, err := LoadImage(filepath.Join(resDir, rel))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := image.Pt(int(100*density.Scale), int(50*density.Scale))
+		if got := img.Bounds().Size(); got != want {
+			t.Errorf("%s is %v, want %v", rel, got, want)
+		}
+	}
+
+	for _, rel := range []string{
+		"drawable-mdpi/splash_logo.png",
+		"drawable/splash_screen.xml",
+		"values/splash_background.xml",
+	} {
+		t.Run(rel, func(t *testing.T) {
+			checkGolden(t, resDir, rel)
+		})
+	}
+}
+
+func TestGenerateIosSplash(t *testing.T) {
+	catalog := t.TempDir()
+	options := SplashOptions{Background: splashBackground, LogoSize: 100}
+	if _, err := GenerateIosSplash(catalog, loadFixture(t, "logo.png"), options); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rel := range []string{
+		"SplashLogo.imageset/splash_logo@2x.png",
+		"SplashLogo.imageset/Contents.json",
+		"SplashBackground.colorset/Contents.json",
+	} {
+		t.Run(rel, func(t *testing.T) {
+			checkGolden(t, catalog, rel)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package bundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+
+	"github.com/aman-apptile/bob/pkg/ios"
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+var (
+	gradleHermesPropertyRegexp = regexp.MustCompile(`(?m)^\s*hermesEnabled\s*=\s*(true|false)\s*$`)
+	gradleEnableHermesRegexp   = regexp.MustCompile(`enableHermes\s*:\s*(true|false)`)
+	podfileHermesRegexp        = regexp.MustCompile(`:hermes_enabled\s*=>\s*(true|false)`)
+)
+
+// Options describes a JavaScript bundle build.
+type Options struct {
+	ProjectDir string
+	Platform   string
+	// EntryFile is relative to the project directory. It defaults to the one the project uses.
+	EntryFile string
+	// OutputDir receives the bundle, its source map and the assets.
+	OutputDir string
+	Hermes    bool
+	// Exec runs the packager, hermesc and node. It defaults to the system executor.
+	Exec utils.Executor
+}
+
+// Result lists the files a bundle build produced.
+type Result struct {
+	Bundle    string
+	SourceMap string
+	AssetsDir string
+	Hermes    bool
+}
+
+// FileName returns the name React Native loads the bundle from on the platform.
+func FileName(platform string) string {
+	if platform == "ios" {
+		return "main.jsbundle"
+	}
+	return "index." + platform + ".bundle"
+}
+
+// EntryFile returns the entry file of the project for the platform: index.<platform>.js when it exists,
+// otherwise index.js or its TypeScript variants.
+func EntryFile(projectDir, platform string) (string, error) {
+	candidates := []string{"index." + platform + ".js", "index.js", "index.ts", "index.tsx"}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(filepath.Join(projectDir, candidate)); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no entry file found in %s, pass one with --entry-file", projectDir)
+}
+
+// IsExpo reports whether the project depends on Expo, whose CLI bundles with `expo export:embed`.
+func IsExpo(projectDir string) bool {
+	data, err := os.ReadFile(filepath.Join(projectDir, "package.json"))
+	if err != nil {
+		return false
+	}
+
+	var pkg struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return false
+	}
+	_, ok := pkg.Dependencies["expo"]
+	return ok
+}
+
+// HermesEnabled reports whether the native project of the platform runs the bundle on Hermes.
+func HermesEnabled(projectDir, platform string) bool {
+	if platform == "ios" {
+		if lock, err := ios.LoadPodfileLock(filepath.Join(projectDir, "ios", "Podfile.lock")); err == nil {
+			_, ok := lock.Pods["hermes-engine"]
+			return ok
+		}
+		data, _ := os.ReadFile(filepath.Join(projectDir, "ios", "Podfile"))
+		match := podfileHermesRegexp.FindSubmatch(data)
+		return match != nil && string(match[1]) == "true"
+	}
+
+	properties, _ := os.ReadFile(filepath.Join(projectDir, "android", "gradle.properties"))
+	if match := gradleHermesPropertyRegexp.FindSubmatch(properties); match != nil {
+		return string(match[1]) == "true"
+	}
+	buildGradle, _ := os.ReadFile(filepath.Join(projectDir, "android", "app", "build.gradle"))
+	match := gradleEnableHermesRegexp.FindSubmatch(buildGradle)
+	return match != nil && string(match[1]) == "true"
+}
+
+// Hermesc returns the Hermes compiler shipped with react-native in the project's node_modules.
+func Hermesc(projectDir string) (string, error) {
+	bin := map[string]string{"darwin": "osx-bin", "linux": "linux64-bin", "windows": "win64-bin"}[runtime.GOOS]
+	candidates := []string{
+		filepath.Join(projectDir, "node_modules", "react-native", "sdks", "hermesc", bin, "hermesc"),
+		filepath.Join(projectDir, "node_modules", "hermes-engine", bin, "hermesc"),
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("hermesc not found in node_modules, run npm install first")
+}
+
+// Run bundles the JavaScript of the project for a release build. With Hermes the bundle is compiled to bytecode
+// and the packager and compiler source maps are composed into a single map.
+func Run(options Options) (*Result, error) {
+	projectDir, err := filepath.Abs(options.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	options.ProjectDir = projectDir
+	if options.Exec == nil {
+		options.Exec = utils.SystemExecutor{}
+	}
+
+	if options.EntryFile == "" {
+		entryFile, err := EntryFile(options.ProjectDir, options.Platform)
+		if err != nil {
+			return nil, err
+		}
+		options.EntryFile = entryFile
+	}
+
+	outputDir, err := filepath.Abs(options.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{
+		Bundle:    filepath.Join(outputDir, FileName(options.Platform)),
+		AssetsDir: filepath.Join(outputDir, "assets"),
+		Hermes:    options.Hermes,
+	}
+	result.SourceMap = result.Bundle + ".map"
+	if err := os.MkdirAll(result.AssetsDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", result.AssetsDir, err)
+	}
+
+	packagerMap := result.SourceMap
+	if options.Hermes {
+		packagerMap = result.Bundle + ".packager.map"
+	}
+
+	command := []string{"react-native", "bundle"}
+	if IsExpo(options.ProjectDir) {
+		command = []string{"expo", "export:embed"}
+	}
+	args := append(command,
+		"--platform", options.Platform,
+		"--dev", "false",
+		"--entry-file", options.EntryFile,
+		"--bundle-output", result.Bundle,
+		"--assets-dest", result.AssetsDir,
+		"--sourcemap-output", packagerMap,
+		"--reset-cache",
+	)
+	if options.Hermes {
+		// Hermes minifies while compiling, and minifying twice only slows the build down.
+		args = append(args, "--minify", "false")
+	}
+
+	env := []string{"NODE_ENV=production"}
+	if err := options.Exec.Run(options.ProjectDir, env, "npx", args...); err != nil {
+		return nil, fmt.Errorf("%s %s failed: %v", command[0], command[1], err)
+	}
+
+	if options.Hermes {
+		if err := compileHermes(options.Exec, options.ProjectDir, result.Bundle, packagerMap, result.SourceMap); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// compileHermes replaces the bundle with Hermes bytecode and composes the source maps so that stack traces
+// from the bytecode map back to the original sources.
+func compileHermes(exec utils.Executor, projectDir, bundle, packagerMap, sourceMap string) error {
+	hermesc, err := Hermesc(projectDir)
+	if err != nil {
+		return err
+	}
+
+	bytecode := bundle + ".hbc"
+	if err := exec.Run(projectDir, nil, hermesc, "-emit-binary", "-O", "-output-source-map", "-out", bytecode, bundle); err != nil {
+		return fmt.Errorf("hermesc failed: %v", err)
+	}
+
+	composeScript := filepath.Join(projectDir, "node_modules", "react-native", "scripts", "compose-source-maps.js")
+	if err := exec.Run(projectDir, nil, "node", composeScript, packagerMap, bytecode+".map", "-o", sourceMap); err != nil {
+		return fmt.Errorf("failed to compose source maps: %v", err)
+	}
+
+	if err := os.Rename(bytecode, bundle); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", bundle, err)
+	}
+	os.Remove(bytecode + ".map")
+	os.Remove(packagerMap)
+
+	return nil
+}
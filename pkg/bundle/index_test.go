@@ -0,0 +1,245 @@
+package bundle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeExecutor stands in for the packager, hermesc and node. It records the commands it is asked to run, as the
+// base name of the tool followed by its arguments with the project directory replaced by $PROJECT, and writes the
+// files the tools would write.
+type fakeExecutor struct {
+	projectDir string
+	calls      []string
+	env        [][]string
+	// fail makes the commands of the tool with this base name fail.
+	fail string
+}
+
+func (f *fakeExecutor) Run(dir string, env []string, command string, args ...string) error {
+	call := strings.Join(append([]string{filepath.Base(command)}, args...), " ")
+	f.calls = append(f.calls, strings.ReplaceAll(call, f.projectDir, "$PROJECT"))
+	f.env = append(f.env, env)
+	if filepath.Base(command) == f.fail {
+		return fmt.Errorf("exit status 1")
+	}
+
+	outputs := map[string]string{}
+	for i := 0; i < len(args)-1; i++ {
+		switch args[i] {
+		case "--bundle-output":
+			outputs[args[i+1]] = "bundle"
+		case "--sourcemap-output":
+			outputs[args[i+1]] = "packager map"
+		case "-out":
+			outputs[args[i+1]] = "bytecode"
+			outputs[args[i+1]+".map"] = "hermesc map"
+		case "-o":
+			outputs[args[i+1]] = "composed map"
+		}
+	}
+	for path, content := range outputs {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeExecutor) Output(dir string, env []string, command string, args ...string) (string, error) {
+	return "", f.Run(dir, env, command, args...)
+}
+
+func (f *fakeExecutor) Capture(dir string, env []string, command string, args ...string) (string, error) {
+	return "", f.Run(dir, env, command, args...)
+}
+
+func (f *fakeExecutor) Start(dir string, env []string, logFile string, command string, args ...string) error {
+	return f.Run(dir, env, command, args...)
+}
+
+// newProject creates a project with the given package.json and entry file, and hermesc in node_modules.
+func newProject(t *testing.T, packageJSON, entryFile string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{"package.json": packageJSON, entryFile: "export {};\n"}
+	for _, bin := range []string{"osx-bin", "linux64-bin", "win64-bin"} {
+		files[filepath.Join("node_modules", "react-native", "sdks", "hermesc", bin, "hermesc")] = ""
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRunHermes(t *testing.T) {
+	projectDir := newProject(t, `{"dependencies": {"react-native": "0.74.1"}}`, "index.js")
+	exec := &fakeExecutor{projectDir: projectDir}
+	result, err := Run(Options{
+		ProjectDir: projectDir,
+		Platform:   "android",
+		OutputDir:  filepath.Join(projectDir, "dist", "bundle", "android"),
+		Hermes:     true,
+		Exec:       exec,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := "$PROJECT/dist/bundle/android/"
+	want := []string{
+		"npx react-native bundle --platform android --dev false --entry-file index.js" +
+			" --bundle-output " + out + "index.android.bundle --assets-dest " + out + "assets" +
+			" --sourcemap-output " + out + "index.android.bundle.packager.map --reset-cache --minify false",
+		"hermesc -emit-binary -O -output-source-map -out " + out + "index.android.bundle.hbc " + out + "index.android.bundle",
+		"node $PROJECT/node_modules/react-native/scripts/compose-source-maps.js " + out + "index.android.bundle.packager.map " +
+			out + "index.android.bundle.hbc.map -o " + out + "index.android.bundle.map",
+	}
+	if !reflect.DeepEqual(exec.calls, want) {
+		t.Errorf("ran\n%s\nwant\n%s", strings.Join(exec.calls, "\n"), strings.Join(want, "\n"))
+	}
+	if !reflect.DeepEqual(exec.env[0], []string{"NODE_ENV=production"}) {
+		t.Errorf("the packager ran with %q, want NODE_ENV=production", exec.env[0])
+	}
+
+	if got := readFile(t, result.Bundle); got != "bytecode" {
+		t.Errorf("the bundle holds %q, want the bytecode", got)
+	}
+	if got := readFile(t, result.SourceMap); got != "composed map" {
+		t.Errorf("the source map holds %q, want the composed map", got)
+	}
+	for _, leftover := range []string{".hbc", ".hbc.map", ".packager.map"} {
+		if _, err := os.Stat(result.Bundle + leftover); !os.IsNotExist(err) {
+			t.Errorf("%s was left behind", filepath.Base(result.Bundle+leftover))
+		}
+	}
+	if info, err := os.Stat(result.AssetsDir); err != nil || !info.IsDir() {
+		t.Errorf("the assets directory was not created: %v", err)
+	}
+}
+
+func TestRunExpo(t *testing.T) {
+	projectDir := newProject(t, `{"dependencies": {"expo": "~51.0.8"}}`, "index.ts")
+	exec := &fakeExecutor{projectDir: projectDir}
+	result, err := Run(Options{ProjectDir: projectDir, Platform: "ios", OutputDir: filepath.Join(projectDir, "out"), Exec: exec})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"npx expo export:embed --platform ios --dev false --entry-file index.ts --bundle-output $PROJECT/out/main.jsbundle" +
+			" --assets-dest $PROJECT/out/assets --sourcemap-output $PROJECT/out/main.jsbundle.map --reset-cache",
+	}
+	if !reflect.DeepEqual(exec.calls, want) {
+		t.Errorf("ran\n%s\nwant\n%s", strings.Join(exec.calls, "\n"), strings.Join(want, "\n"))
+	}
+	if got := readFile(t, result.SourceMap); got != "packager map" {
+		t.Errorf("the source map holds %q, want the one of the packager", got)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    string
+		hermesc bool
+		err     string
+	}{
+		{name: "packager fails", fail: "npx", hermesc: true, err: "react-native bundle failed: exit status 1"},
+		{name: "hermesc fails", fail: "hermesc", hermesc: true, err: "hermesc failed: exit status 1"},
+		{name: "composing fails", fail: "node", hermesc: true, err: "failed to compose source maps"},
+		{name: "no hermesc", hermesc: false, err: "hermesc not found in node_modules"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			projectDir := newProject(t, `{"dependencies": {"react-native": "0.74.1"}}`, "index.js")
+			if !test.hermesc {
+				os.RemoveAll(filepath.Join(projectDir, "node_modules"))
+			}
+			exec := &fakeExecutor{projectDir: projectDir, fail: test.fail}
+			_, err := Run(Options{ProjectDir: projectDir, Platform: "android", OutputDir: filepath.Join(projectDir, "out"), Hermes: true, Exec: exec})
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Run() = %v, want an error containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestEntryFile(t *testing.T) {
+	tests := []struct {
+		project, platform string
+		want              string
+	}{
+		{project: "react-native", platform: "ios", want: "index.ios.js"},
+		{project: "react-native", platform: "android", want: "index.js"},
+		{project: "expo", platform: "android", want: "index.ts"},
+	}
+	for _, test := range tests {
+		if got, err := EntryFile(filepath.Join("testdata", test.project), test.platform); err != nil || got != test.want {
+			t.Errorf("EntryFile(%s, %s) = %q, %v, want %q", test.project, test.platform, got, err, test.want)
+		}
+	}
+	if _, err := EntryFile(filepath.Join("testdata", "legacy"), "android"); err == nil || !strings.Contains(err.Error(), "--entry-file") {
+		t.Errorf("EntryFile() without an entry file = %v, want an error suggesting --entry-file", err)
+	}
+}
+
+func TestHermesEnabled(t *testing.T) {
+	tests := []struct {
+		project, platform string
+		want              bool
+	}{
+		// gradle.properties and the hermes-engine pod in Podfile.lock.
+		{project: "react-native", platform: "android", want: true},
+		{project: "react-native", platform: "ios", want: true},
+		// gradle.properties wins over the enableHermes of older projects.
+		{project: "expo", platform: "android", want: false},
+		{project: "expo", platform: "ios", want: false},
+		// enableHermes in build.gradle and the Podfile when there is no Podfile.lock yet.
+		{project: "legacy", platform: "android", want: true},
+		{project: "legacy", platform: "ios", want: true},
+		{project: "missing", platform: "android", want: false},
+	}
+	for _, test := range tests {
+		if got := HermesEnabled(filepath.Join("testdata", test.project), test.platform); got != test.want {
+			t.Errorf("HermesEnabled(%s, %s) = %t, want %t", test.project, test.platform, got, test.want)
+		}
+	}
+}
+
+func TestIsExpo(t *testing.T) {
+	// The package.json of the legacy fixture is broken.
+	for project, want := range map[string]bool{"expo": true, "react-native": false, "legacy": false, "missing": false} {
+		if got := IsExpo(filepath.Join("testdata", project)); got != want {
+			t.Errorf("IsExpo(%s) = %t, want %t", project, got, want)
+		}
+	}
+}
+
+func TestFileName(t *testing.T) {
+	for platform, want := range map[string]string{"android": "index.android.bundle", "ios": "main.jsbundle"} {
+		if got := FileName(platform); got != want {
+			t.Errorf("FileName(%s) = %q, want %q", platform, got, want)
+		}
+	}
+}
@@ -0,0 +1 @@
+import { registerRootComponent } from 'expo';
@@ -0,0 +1 @@
+import {AppRegistry} from 'react-native';
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates the files, given as relative path and content, under dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readFiles returns the files under dir by their slash separated relative path.
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+var artifactFiles = map[string]string{
+	"artifacts.json":          `{"platform": "android", "version": "1.4.2"}`,
+	"app-release.apk":         "apk",
+	"HelloWorld.app.dSYM.zip": "dsym",
+	"nested/mapping.txt":      "mapping",
+}
+
+func TestPackUnpack(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, artifactFiles)
+	if err := os.Chmod(filepath.Join(src, "app-release.apk"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var archive bytes.Buffer
+	if err := Pack(src, &archive); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "dist", "android", "1.4.2")
+	if err := Unpack(&archive, dest); err != nil {
+		t.Fatal(err)
+	}
+	got := readFiles(t, dest)
+	if len(got) != len(artifactFiles) {
+		t.Errorf("Unpack() restored %d files, want %d: %v", len(got), len(artifactFiles), got)
+	}
+	for name, content := range artifactFiles {
+		if got[name] != content {
+			t.Errorf("Unpack() restored %s as %q, want %q", name, got[name], content)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(dest, "app-release.apk")); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Unpack() lost the file mode of app-release.apk: %v, %v", info.Mode(), err)
+	}
+}
+
+// tarball builds a gzipped tarball with a single regular file entry.
+func tarball(t *testing.T, name, content string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestUnpackRejectsPathsOutsideDir(t *testing.T) {
+	root := t.TempDir()
+	dest := filepath.Join(root, "dist")
+
+	for _, name := range []string{"../escaped.txt", "nested/../../escaped.txt", "../dist-other/escaped.txt"} {
+		err := Unpack(tarball(t, name, "evil"), dest)
+		if err == nil || !strings.Contains(err.Error(), "points outside") {
+			t.Errorf("Unpack(%s) = %v, want a points outside error", name, err)
+		}
+	}
+	for _, path := range []string{filepath.Join(root, "escaped.txt"), filepath.Join(root, "dist-other", "escaped.txt")} {
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("Unpack() wrote %s outside of %s", path, dest)
+		}
+	}
+
+	// Names that stay inside the directory after cleaning are fine.
+	if err := Unpack(tarball(t, "nested/../inside.txt", "ok"), dest); err != nil {
+		t.Errorf("Unpack() of a path inside the directory = %v", err)
+	}
+}
+
+func TestUnpackRejectsGarbage(t *testing.T) {
+	if err := Unpack(strings.NewReader("not a tarball"), t.TempDir()); err == nil {
+		t.Error("Unpack() of garbage succeeded")
+	}
+}
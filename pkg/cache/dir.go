@@ -0,0 +1,54 @@
+package cache
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DirBackend stores entries as files in a directory, which may be on a shared filesystem that several machines mount.
+type DirBackend struct {
+	Root string
+}
+
+// path spreads the entries over subdirectories named after the first two characters of the key.
+func (b *DirBackend) path(key string) string {
+	return filepath.Join(b.Root, key[:2], key+".tar.gz")
+}
+
+func (b *DirBackend) Open(key string) (io.ReadCloser, error) {
+	file, err := os.Open(b.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrMiss
+	}
+	return file, err
+}
+
+// Store writes the entry to a temporary file first and renames it into place, so that readers on other
+// machines never see a partly written entry.
+func (b *DirBackend) Store(key string, r io.Reader) error {
+	path := b.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), ".tmp-"+key+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := io.Copy(temp, r); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+func (b *DirBackend) String() string {
+	return fmt.Sprintf("directory %s", b.Root)
+}
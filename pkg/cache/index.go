@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrMiss is returned by Backend.Open when nothing is stored under the key.
+var ErrMiss = errors.New("cache miss")
+
+// Backend stores cache entries, each a gzipped tarball of the artifacts of one build, by build key.
+type Backend interface {
+	// Open returns the entry stored under the key, or ErrMiss.
+	Open(key string) (io.ReadCloser, error)
+	// Store stores the entry read from r under the key, replacing any entry stored under it.
+	Store(key string, r io.Reader) error
+	// String describes where the entries are stored, for messages.
+	String() string
+}
+
+// Factory creates the back end for a cache location URL.
+type Factory func(location *url.URL) (Backend, error)
+
+var factories = map[string]Factory{
+	"file": func(location *url.URL) (Backend, error) {
+		return &DirBackend{Root: location.Path}, nil
+	},
+}
+
+// Register makes a back end available for cache locations with the URL scheme, e.g. s3.
+func Register(scheme string, factory Factory) {
+	factories[scheme] = factory
+}
+
+// DefaultDir returns the local cache directory, bob under the user's cache directory.
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user cache directory: %v", err)
+	}
+	return filepath.Join(dir, "bob", "builds"), nil
+}
+
+// Open returns the back end for the cache location: a directory path, a URL of a registered scheme such as
+// file:///mnt/shared/bob-cache, or the default local directory when location is empty.
+func Open(location string) (Backend, error) {
+	if location == "" {
+		dir, err := DefaultDir()
+		if err != nil {
+			return nil, err
+		}
+		return &DirBackend{Root: dir}, nil
+	}
+	if !strings.Contains(location, "://") {
+		return &DirBackend{Root: location}, nil
+	}
+
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache location %q: %v", location, err)
+	}
+	factory, ok := factories[parsed.Scheme]
+	if !ok {
+		var schemes []string
+		for scheme := range factories {
+			schemes = append(schemes, scheme)
+		}
+		sort.Strings(schemes)
+		return nil, fmt.Errorf("unsupported cache location %q, expected a directory or a URL with one of: %s", location, strings.Join(schemes, ", "))
+	}
+	return factory(parsed)
+}
+
+// Restore unpacks the entry stored under the key into dir. It reports false when nothing is stored under the key.
+func Restore(backend Backend, key, dir string) (bool, error) {
+	entry, err := backend.Open(key)
+	if errors.Is(err, ErrMiss) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read cache entry %s from %s: %v", key, backend, err)
+	}
+	defer entry.Close()
+
+	if err := Unpack(entry, dir); err != nil {
+		return false, fmt.Errorf("failed to restore cache entry %s: %v", key, err)
+	}
+	return true, nil
+}
+
+// Save packs dir and stores it under the key.
+func Save(backend Backend, key, dir string) error {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(Pack(dir, writer))
+	}()
+
+	err := backend.Store(key, reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to store cache entry %s in %s: %v", key, backend, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKey = "3f7a9c0e5b1d2468ace0f13579bdf02468ace13579bdf02468ace13579bdf024"
+
+func TestSaveRestore(t *testing.T) {
+	backend := &DirBackend{Root: t.TempDir()}
+	src := t.TempDir()
+	writeFiles(t, src, artifactFiles)
+
+	dest := filepath.Join(t.TempDir(), "1.4.2")
+	if restored, err := Restore(backend, testKey, dest); err != nil || restored {
+		t.Errorf("Restore() before Save() = %t, %v, want a miss", restored, err)
+	}
+
+	if err := Save(backend, testKey, src); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(backend.Root, testKey[:2], testKey+".tar.gz")); err != nil {
+		t.Errorf("Save() did not store the entry under the key prefix: %v", err)
+	}
+	entries, _ := os.ReadDir(filepath.Join(backend.Root, testKey[:2]))
+	if len(entries) != 1 {
+		t.Errorf("Save() left %d files behind, want only the entry", len(entries))
+	}
+
+	restored, err := Restore(backend, testKey, dest)
+	if err != nil || !restored {
+		t.Fatalf("Restore() = %t, %v, want a hit", restored, err)
+	}
+	got := readFiles(t, dest)
+	for name, content := range artifactFiles {
+		if got[name] != content {
+			t.Errorf("Restore() restored %s as %q, want %q", name, got[name], content)
+		}
+	}
+}
+
+func TestSaveMissingDir(t *testing.T) {
+	backend := &DirBackend{Root: t.TempDir()}
+	if err := Save(backend, testKey, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Save() of a missing directory succeeded")
+	}
+	if _, err := os.Stat(filepath.Join(backend.Root, testKey[:2], testKey+".tar.gz")); err == nil {
+		t.Error("Save() of a missing directory stored an entry")
+	}
+}
+
+func TestRestoreCorruptEntry(t *testing.T) {
+	backend := &DirBackend{Root: t.TempDir()}
+	if err := backend.Store(testKey, strings.NewReader("truncated")); err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := Restore(backend, testKey, t.TempDir()); err == nil || restored {
+		t.Errorf("Restore() of a corrupt entry = %t, %v, want an error", restored, err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tests := map[string]string{
+		"/mnt/shared/bob-cache":        "/mnt/shared/bob-cache",
+		"file:///mnt/shared/bob-cache": "/mnt/shared/bob-cache",
+	}
+	for location, root := range tests {
+		backend, err := Open(location)
+		if err != nil {
+			t.Errorf("Open(%q) = %v", location, err)
+			continue
+		}
+		if dir, ok := backend.(*DirBackend); !ok || dir.Root != root {
+			t.Errorf("Open(%q) = %v, want a directory back end at %s", location, backend, root)
+		}
+	}
+
+	if _, err := Open("gs://bucket/cache"); err == nil || !strings.Contains(err.Error(), "file") {
+		t.Errorf("Open() of an unregistered scheme = %v, want an error listing the supported ones", err)
+	}
+}
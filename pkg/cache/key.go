@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// keyVersion changes whenever the way keys are computed or entries are packed changes.
+const keyVersion = "bob-build-cache-v1"
+
+// Inputs are what decides the outcome of a build. Builds with the same inputs produce the same artifacts.
+type Inputs struct {
+	// ProjectDir is the git checkout the build comes from.
+	ProjectDir string
+	// Exclude lists top level paths of the checkout that do not affect the build, e.g. ios for an Android build.
+	Exclude []string
+	// Lockfiles are paths relative to ProjectDir whose content is part of the key.
+	Lockfiles []string
+	// Files are paths outside of git whose content is part of the key, such as a white-label app config.
+	// Directories are hashed file by file.
+	Files     []string
+	Toolchain map[string]string
+	Options   map[string]string
+}
+
+// DirtyError is returned by Key when tracked files of the checkout have uncommitted changes, which the git tree
+// hash does not cover.
+type DirtyError struct {
+	Changes []string
+}
+
+func (e *DirtyError) Error() string {
+	return fmt.Sprintf("the project has uncommitted changes (%s)", strings.Join(e.Changes, ", "))
+}
+
+// Key computes the build key from the git tree hashes of the top level paths of the checkout, the lockfiles,
+// the extra files, the toolchain versions and the build options.
+func Key(inputs Inputs) (string, error) {
+	pathspec := []string{"--", "."}
+	for _, exclude := range inputs.Exclude {
+		pathspec = append(pathspec, ":!"+exclude)
+	}
+	// Untracked files are left out, they are mostly build outputs such as the dist directory.
+	args := append([]string{"status", "--porcelain", "--untracked-files=no"}, pathspec...)
+	status, err := utils.RunCommandInDirWithOutput(inputs.ProjectDir, nil, "git", args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the git status of %s: %v", inputs.ProjectDir, strings.TrimSpace(status))
+	}
+	if status = strings.TrimRight(status, "\n"); status != "" {
+		dirty := &DirtyError{}
+		for _, line := range strings.Split(status, "\n") {
+			// XY <path>
+			if len(line) > 3 {
+				dirty.Changes = append(dirty.Changes, line[3:])
+			}
+		}
+		return "", dirty
+	}
+
+	tree, err := utils.RunCommandInDirWithOutput(inputs.ProjectDir, nil, "git", "ls-tree", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to read the git tree of %s: %v", inputs.ProjectDir, strings.TrimSpace(tree))
+	}
+
+	hash := sha256.New()
+	fmt.Fprintln(hash, keyVersion)
+	for _, line := range strings.Split(strings.TrimSpace(tree), "\n") {
+		// <mode> <type> <object>\t<path>
+		entry, path, ok := strings.Cut(line, "\t")
+		if !ok || contains(inputs.Exclude, path) {
+			continue
+		}
+		fields := strings.Fields(entry)
+		fmt.Fprintf(hash, "tree\t%s\t%s\n", path, fields[len(fields)-1])
+	}
+
+	for _, lockfile := range inputs.Lockfiles {
+		sum, err := fileHash(filepath.Join(inputs.ProjectDir, lockfile))
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(hash, "lockfile\t%s\t%s\n", lockfile, sum)
+	}
+
+	for _, root := range inputs.Files {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			sum, err := fileHash(path)
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(root, path)
+			fmt.Fprintf(hash, "file\t%s\t%s\t%s\n", filepath.Base(root), filepath.ToSlash(rel), sum)
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to hash %s: %v", root, err)
+		}
+	}
+
+	writeSorted(hash, "toolchain", inputs.Toolchain)
+	writeSorted(hash, "option", inputs.Options)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writeSorted(w io.Writer, kind string, values map[string]string) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", kind, key, values[key])
+	}
+}
+
+func fileHash(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
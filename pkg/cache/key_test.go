@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// gitRepo creates a git repository with an Android and an iOS directory and a lockfile, committed.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"package.json":             `{"name": "HelloWorld"}`,
+		"package-lock.json":        `{"lockfileVersion": 3}`,
+		"android/app/build.gradle": "android {}",
+		"ios/Podfile":              "platform :ios, '13.4'",
+		".gitignore":               "dist/\n",
+	})
+	git(t, dir, "init", "-q")
+	git(t, dir, "add", ".")
+	git(t, dir, "commit", "-q", "-m", "Initial commit")
+	return dir
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+}
+
+func TestKey(t *testing.T) {
+	dir := gitRepo(t)
+	inputs := Inputs{
+		ProjectDir: dir,
+		Exclude:    []string{"dist", "ios"},
+		Lockfiles:  []string{"package-lock.json", "yarn.lock"},
+		Toolchain:  map[string]string{"node": "18.19.0"},
+		Options:    map[string]string{"platform": "android", "variant": "release"},
+	}
+	key, err := Key(inputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != 64 {
+		t.Errorf("Key() = %q, want a SHA-256 hex digest", key)
+	}
+	if again, err := Key(inputs); err != nil || again != key {
+		t.Errorf("Key() is not stable: %q, then %q, %v", key, again, err)
+	}
+
+	// Untracked build outputs and commits to excluded paths leave the key alone.
+	writeFiles(t, dir, map[string]string{"dist/android/1.0/app-release.apk": "apk", "android/app/build/out.apk": "apk"})
+	writeFiles(t, dir, map[string]string{"ios/Podfile": "platform :ios, '14.0'"})
+	git(t, dir, "commit", "-q", "-am", "Raise the iOS deployment target")
+	if got, err := Key(inputs); err != nil || got != key {
+		t.Errorf("Key() after an iOS commit = %q, %v, want %q", got, err, key)
+	}
+
+	changed := map[string]Inputs{}
+	withToolchain := inputs
+	withToolchain.Toolchain = map[string]string{"node": "20.11.0"}
+	changed["toolchain"] = withToolchain
+	withOptions := inputs
+	withOptions.Options = map[string]string{"platform": "android", "variant": "debug"}
+	changed["options"] = withOptions
+	config := filepath.Join(t.TempDir(), "acme")
+	writeFiles(t, config, map[string]string{"app.json": `{"id": "acme"}`, "icon.png": "png"})
+	withFiles := inputs
+	withFiles.Files = []string{config}
+	changed["files"] = withFiles
+	for name, changedInputs := range changed {
+		if got, err := Key(changedInputs); err != nil || got == key {
+			t.Errorf("Key() with other %s = %q, %v, want a different key", name, got, err)
+		}
+	}
+
+	writeFiles(t, dir, map[string]string{"android/app/build.gradle": "android { namespace 'com.helloworld' }"})
+	git(t, dir, "commit", "-q", "-am", "Set the namespace")
+	if got, err := Key(inputs); err != nil || got == key {
+		t.Errorf("Key() after an Android commit = %q, %v, want a different key", got, err)
+	}
+}
+
+func TestKeyDirty(t *testing.T) {
+	dir := gitRepo(t)
+	writeFiles(t, dir, map[string]string{"android/app/build.gradle": "android { changed }"})
+
+	_, err := Key(Inputs{ProjectDir: dir, Exclude: []string{"ios"}})
+	var dirty *DirtyError
+	if !errors.As(err, &dirty) {
+		t.Fatalf("Key() with uncommitted changes = %v, want a DirtyError", err)
+	}
+	if want := []string{"android/app/build.gradle"}; !reflect.DeepEqual(dirty.Changes, want) {
+		t.Errorf("DirtyError.Changes = %q, want %q", dirty.Changes, want)
+	}
+
+	// Changes to excluded paths do not matter.
+	if _, err := Key(Inputs{ProjectDir: dir, Exclude: []string{"android"}}); err != nil {
+		t.Errorf("Key() with changes only in an excluded path = %v", err)
+	}
+
+	if _, err := Key(Inputs{ProjectDir: t.TempDir()}); err == nil {
+		t.Error("Key() outside a git repository succeeded")
+	}
+}
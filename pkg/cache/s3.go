@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/aman-apptile/bob/pkg/s3"
+)
+
+// S3Backend stores entries as objects in an S3 compatible bucket, shared by every machine that can reach it.
+type S3Backend struct {
+	Client *s3.Client
+	Bucket string
+	Prefix string
+}
+
+func (b *S3Backend) key(key string) string {
+	return path.Join(b.Prefix, key+".tar.gz")
+}
+
+func (b *S3Backend) Open(key string) (io.ReadCloser, error) {
+	body, err := b.Client.GetObject(b.Bucket, b.key(key))
+	if errors.Is(err, s3.ErrNotFound) {
+		return nil, ErrMiss
+	}
+	return body, err
+}
+
+// Store spools the entry to a temporary file first, since uploads need to know the size up front.
+func (b *S3Backend) Store(key string, r io.Reader) error {
+	temp, err := os.CreateTemp("", "bob-cache-*.tar.gz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := io.Copy(temp, r); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return b.Client.UploadFile(b.Bucket, b.key(key), temp.Name(), "application/gzip")
+}
+
+func (b *S3Backend) String() string {
+	return fmt.Sprintf("bucket s3://%s", path.Join(b.Bucket, b.Prefix))
+}
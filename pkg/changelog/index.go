@@ -0,0 +1,217 @@
+package changelog
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// Section titles, in the order they are written.
+const (
+	Features = "Features"
+	Fixes    = "Fixes"
+	Other    = "Other"
+)
+
+// conventionalCommit matches the subject of a Conventional Commit, e.g. "feat(cart)!: pay with Apple Pay".
+var conventionalCommit = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?:\s+(.+)$`)
+
+// Entry is one commit of the changelog.
+type Entry struct {
+	Hash        string
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+}
+
+// Section returns the section the entry belongs to.
+func (e Entry) Section() string {
+	switch e.Type {
+	case "feat":
+		return Features
+	case "fix", "perf":
+		return Fixes
+	default:
+		return Other
+	}
+}
+
+// ParseCommit reads the type, scope and description of a commit following Conventional Commits. Other commits
+// become entries without a type, described by their subject.
+func ParseCommit(hash, subject, body string) Entry {
+	entry := Entry{Hash: hash, Description: strings.TrimSpace(subject)}
+	if match := conventionalCommit.FindStringSubmatch(entry.Description); match != nil {
+		entry.Type = strings.ToLower(match[1])
+		entry.Scope = match[2]
+		entry.Breaking = match[3] == "!"
+		entry.Description = match[4]
+	}
+	if strings.Contains(body, "BREAKING CHANGE:") || strings.Contains(body, "BREAKING-CHANGE:") {
+		entry.Breaking = true
+	}
+	return entry
+}
+
+// Changelog lists the commits between two revisions by section.
+type Changelog struct {
+	// From is the revision the changelog starts after, "" when it covers the whole history.
+	From     string
+	To       string
+	Sections map[string][]Entry
+}
+
+// Generate reads the commits after from up to to, skipping merges. When from is empty, the changelog starts
+// after the most recent tag before to, or covers the whole history when there is no such tag.
+func Generate(projectDir, from, to string) (*Changelog, error) {
+	if to == "" {
+		to = "HEAD"
+	}
+	if from == "" {
+		// The tag of to itself is skipped, so that a tagged release lists what changed since the one before.
+		if tag, err := utils.RunCommandInDirWithOutput(projectDir, nil, "git", "describe", "--tags", "--abbrev=0", to+"^"); err == nil {
+			from = strings.TrimSpace(tag)
+		}
+	}
+
+	revisions := to
+	if from != "" {
+		revisions = from + ".." + to
+	}
+	// Fields are separated by the unit separator and commits by the record separator, neither occurs in messages.
+	output, err := utils.RunCommandInDirWithOutput(projectDir, nil, "git", "log", "--no-merges", "--format=%h%x1f%s%x1f%b%x1e", revisions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the git history %s: %v", revisions, strings.TrimSpace(output))
+	}
+
+	changelog := &Changelog{From: from, To: to, Sections: map[string][]Entry{}}
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		entry := ParseCommit(fields[0], fields[1], fields[2])
+		changelog.Sections[entry.Section()] = append(changelog.Sections[entry.Section()], entry)
+	}
+	return changelog, nil
+}
+
+// Empty reports whether the changelog has no entries.
+func (c *Changelog) Empty() bool {
+	return len(c.Sections) == 0
+}
+
+// Markdown renders the changelog with a heading per section, breaking changes first within a section.
+func (c *Changelog) Markdown() string {
+	var b strings.Builder
+	if c.From != "" {
+		fmt.Fprintf(&b, "## Changes from %s to %s\n", c.From, c.To)
+	} else {
+		fmt.Fprintf(&b, "## Changes up to %s\n", c.To)
+	}
+	if c.Empty() {
+		b.WriteString("\nNo changes.\n")
+		return b.String()
+	}
+
+	for _, section := range []string{Features, Fixes, Other} {
+		entries := c.Sections[section]
+		if len(entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", section)
+		for _, entry := range breakingFirst(entries) {
+			b.WriteString("- ")
+			if entry.Breaking {
+				b.WriteString("**BREAKING** ")
+			}
+			if entry.Scope != "" {
+				fmt.Fprintf(&b, "**%s:** ", entry.Scope)
+			}
+			fmt.Fprintf(&b, "%s (%s)\n", entry.Description, entry.Hash)
+		}
+	}
+	return b.String()
+}
+
+// PlainText renders the features and fixes as release notes for store listings, at most limit characters long.
+// Other changes are only listed when there are no features or fixes. When the notes do not fit, the last lines
+// are replaced with a count of the changes left out.
+func (c *Changelog) PlainText(limit int) string {
+	sections := []string{Features, Fixes}
+	if len(c.Sections[Features]) == 0 && len(c.Sections[Fixes]) == 0 {
+		sections = []string{Other}
+	}
+
+	type line struct {
+		heading string
+		text    string
+	}
+	var lines []line
+	for _, section := range sections {
+		for _, entry := range breakingFirst(c.Sections[section]) {
+			lines = append(lines, line{heading: section, text: "• " + capitalize(entry.Description)})
+		}
+	}
+
+	render := func(count int) string {
+		var b strings.Builder
+		heading := ""
+		for _, l := range lines[:count] {
+			if l.heading != heading {
+				if heading != "" {
+					b.WriteString("\n")
+				}
+				heading = l.heading
+				b.WriteString(heading + "\n")
+			}
+			b.WriteString(l.text + "\n")
+		}
+		if left := len(lines) - count; left > 0 {
+			if count > 0 {
+				b.WriteString("\n")
+			}
+			if left == 1 {
+				b.WriteString("…and 1 more change\n")
+			} else {
+				fmt.Fprintf(&b, "…and %d more changes\n", left)
+			}
+		}
+		return strings.TrimRight(b.String(), "\n")
+	}
+
+	for count := len(lines); count >= 0; count-- {
+		if text := render(count); utf8.RuneCountInString(text) <= limit {
+			return text
+		}
+	}
+	return ""
+}
+
+// breakingFirst returns the entries with breaking changes moved to the front, keeping their order otherwise.
+func breakingFirst(entries []Entry) []Entry {
+	sorted := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Breaking {
+			sorted = append(sorted, entry)
+		}
+	}
+	for _, entry := range entries {
+		if !entry.Breaking {
+			sorted = append(sorted, entry)
+		}
+	}
+	return sorted
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
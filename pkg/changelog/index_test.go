@@ -0,0 +1,265 @@
+package changelog
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseCommit(t *testing.T) {
+	tests := []struct {
+		subject string
+		body    string
+		want    Entry
+	}{
+		{subject: "feat: pay with Apple Pay", want: Entry{Type: "feat", Description: "pay with Apple Pay"}},
+		{subject: "fix(cart): keep the coupon", want: Entry{Type: "fix", Scope: "cart", Description: "keep the coupon"}},
+		{subject: "Feat(ios)!: drop iOS 12", want: Entry{Type: "feat", Scope: "ios", Description: "drop iOS 12", Breaking: true}},
+		{subject: "refactor!: rename the config keys", want: Entry{Type: "refactor", Description: "rename the config keys", Breaking: true}},
+		{
+			subject: "feat(api): paginate the orders",
+			body:    "The orders endpoint returns pages of 50.\n\nBREAKING CHANGE: clients must follow the next link.",
+			want:    Entry{Type: "feat", Scope: "api", Description: "paginate the orders", Breaking: true},
+		},
+		{subject: "perf: cache the catalog", body: "BREAKING-CHANGE: the cache needs 50 MB", want: Entry{Type: "perf", Description: "cache the catalog", Breaking: true}},
+		{subject: "fix: mention BREAKING CHANGE in passing", body: "Not a breaking change.", want: Entry{Type: "fix", Description: "mention BREAKING CHANGE in passing"}},
+		{subject: "  Update the README  ", want: Entry{Description: "Update the README"}},
+		{subject: "feat:no space after the colon", want: Entry{Description: "feat:no space after the colon"}},
+		{subject: "Merge branch 'main' into release", want: Entry{Description: "Merge branch 'main' into release"}},
+	}
+	for _, test := range tests {
+		test.want.Hash = "a1b2c3d"
+		if got := ParseCommit("a1b2c3d", test.subject, test.body); got != test.want {
+			t.Errorf("ParseCommit(%q) = %+v, want %+v", test.subject, got, test.want)
+		}
+	}
+}
+
+func TestSection(t *testing.T) {
+	for kind, want := range map[string]string{"feat": Features, "fix": Fixes, "perf": Fixes, "chore": Other, "": Other} {
+		if got := (Entry{Type: kind}).Section(); got != want {
+			t.Errorf("Section() of %q = %s, want %s", kind, got, want)
+		}
+	}
+}
+
+// gitRepo creates a repository with a tagged release 1.0.0, a tagged release 1.1.0 with a merged branch, and
+// a commit after it.
+func gitRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	commit(t, dir, "chore: initial commit", "")
+	git(t, dir, "tag", "v1.0.0")
+
+	commit(t, dir, "feat(cart): pay with Apple Pay", "")
+	git(t, dir, "checkout", "-q", "-b", "crash")
+	commit(t, dir, "fix: crash on launch", "")
+	git(t, dir, "checkout", "-q", "-")
+	commit(t, dir, "Update the README", "")
+	git(t, dir, "merge", "-q", "--no-ff", "-m", "Merge branch 'crash'", "crash")
+	commit(t, dir, "feat(api): paginate the orders", "BREAKING CHANGE: clients must follow the next link.")
+	git(t, dir, "tag", "-a", "v1.1.0", "-m", "1.1.0")
+
+	commit(t, dir, "fix(android): ask for the notification permission", "")
+	return dir
+}
+
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+}
+
+// commit commits a change to a file named after the subject.
+func commit(t *testing.T, dir, subject, body string) {
+	t.Helper()
+	name := strings.NewReplacer(" ", "-", ":", "", "(", "", ")", "").Replace(subject) + ".txt"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(subject), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git(t, dir, "add", name)
+	message := subject
+	if body != "" {
+		message += "\n\n" + body
+	}
+	git(t, dir, "commit", "-q", "-m", message)
+}
+
+// descriptions returns the descriptions of the entries by section, sorted, marking breaking changes with a !.
+func descriptions(c *Changelog) map[string][]string {
+	got := map[string][]string{}
+	for section, entries := range c.Sections {
+		for _, entry := range entries {
+			description := entry.Description
+			if entry.Breaking {
+				description += "!"
+			}
+			got[section] = append(got[section], description)
+		}
+		sort.Strings(got[section])
+	}
+	return got
+}
+
+func TestGenerate(t *testing.T) {
+	dir := gitRepo(t)
+	release := map[string][]string{
+		Features: {"paginate the orders!", "pay with Apple Pay"},
+		Fixes:    {"crash on launch"},
+		Other:    {"Update the README"},
+	}
+	tests := []struct {
+		name     string
+		from, to string
+		wantFrom string
+		want     map[string][]string
+	}{
+		{name: "since the last tag", wantFrom: "v1.1.0", want: map[string][]string{Fixes: {"ask for the notification permission"}}},
+		{name: "tagged release", to: "v1.1.0", wantFrom: "v1.0.0", want: release},
+		{name: "range", from: "v1.0.0", to: "v1.1.0", wantFrom: "v1.0.0", want: release},
+		{name: "first release", to: "v1.0.0", want: map[string][]string{Other: {"initial commit"}}},
+		{name: "empty range", from: "v1.1.0", to: "v1.1.0", wantFrom: "v1.1.0", want: map[string][]string{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changelog, err := Generate(dir, test.from, test.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changelog.From != test.wantFrom {
+				t.Errorf("Generate() starts after %q, want %q", changelog.From, test.wantFrom)
+			}
+			if got := descriptions(changelog); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Generate() = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if _, err := Generate(dir, "v0.9.0", ""); err == nil {
+		t.Error("Generate() from a missing tag succeeded")
+	}
+}
+
+func TestGenerateWithoutTags(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	commit(t, dir, "chore: initial commit", "")
+	commit(t, dir, "feat: scan barcodes", "")
+
+	changelog, err := Generate(dir, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{Features: {"scan barcodes"}, Other: {"initial commit"}}
+	if got := descriptions(changelog); changelog.From != "" || changelog.To != "HEAD" || !reflect.DeepEqual(got, want) {
+		t.Errorf("Generate() = %q..%q %v, want the whole history %v", changelog.From, changelog.To, got, want)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	changelog := &Changelog{From: "v1.0.0", To: "v1.1.0", Sections: map[string][]Entry{
+		Features: {
+			{Hash: "a1b2c3d", Type: "feat", Scope: "cart", Description: "pay with Apple Pay"},
+			{Hash: "e4f5a6b", Type: "feat", Scope: "api", Description: "paginate the orders", Breaking: true},
+		},
+		Other: {{Hash: "c7d8e9f", Description: "Update the README"}},
+	}}
+	want := `## Changes from v1.0.0 to v1.1.0
+
+### Features
+
+- **BREAKING** **api:** paginate the orders (e4f5a6b)
+- **cart:** pay with Apple Pay (a1b2c3d)
+
+### Other
+
+- Update the README (c7d8e9f)
+`
+	if got := changelog.Markdown(); got != want {
+		t.Errorf("Markdown() =\n%s\nwant\n%s", got, want)
+	}
+
+	empty := &Changelog{To: "HEAD", Sections: map[string][]Entry{}}
+	if got, want := empty.Markdown(), "## Changes up to HEAD\n\nNo changes.\n"; got != want {
+		t.Errorf("Markdown() of an empty changelog = %q, want %q", got, want)
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	changelog := &Changelog{Sections: map[string][]Entry{
+		Fixes:    {{Type: "fix", Description: "keep the coupon"}},
+		Features: {{Type: "feat", Description: "pay with Apple Pay"}, {Type: "feat", Description: "drop iOS 12", Breaking: true}},
+		Other:    {{Description: "Update the README"}},
+	}}
+	want := "Features\n• Drop iOS 12\n• Pay with Apple Pay\n\nFixes\n• Keep the coupon"
+	if got := changelog.PlainText(500); got != want {
+		t.Errorf("PlainText() =\n%s\nwant\n%s", got, want)
+	}
+
+	other := &Changelog{Sections: map[string][]Entry{Other: {{Description: "update the README"}}}}
+	if got, want := other.PlainText(500), "Other\n• Update the README"; got != want {
+		t.Errorf("PlainText() without features or fixes = %q, want %q", got, want)
+	}
+	if got, want := changelog.PlainText(45), "Features\n• Drop iOS 12\n\n…and 2 more changes"; got != want {
+		t.Errorf("PlainText(45) = %q, want %q", got, want)
+	}
+	if got, want := changelog.PlainText(65), "Features\n• Drop iOS 12\n• Pay with Apple Pay\n\n…and 1 more change"; got != want {
+		t.Errorf("PlainText(65) = %q, want %q", got, want)
+	}
+	if got := changelog.PlainText(5); got != "" {
+		t.Errorf("PlainText(5) = %q, want nothing when not even the count fits", got)
+	}
+}
+
+// TestPlainTextLimits truncates long changelogs to the limits of Google Play and TestFlight.
+func TestPlainTextLimits(t *testing.T) {
+	changelog := &Changelog{Sections: map[string][]Entry{}}
+	for i := 1; i <= 200; i++ {
+		// Multibyte characters make sure the limit counts characters rather than bytes.
+		changelog.Sections[Features] = append(changelog.Sections[Features], Entry{Type: "feat", Description: fmt.Sprintf("résumé feature number %d", i)})
+		changelog.Sections[Fixes] = append(changelog.Sections[Fixes], Entry{Type: "fix", Description: fmt.Sprintf("naïve fix number %d", i)})
+	}
+
+	for _, limit := range []int{500, 4000} {
+		text := changelog.PlainText(limit)
+		length := utf8.RuneCountInString(text)
+		if length > limit || length < limit-40 {
+			t.Errorf("PlainText(%d) is %d characters long, want it to fill the limit", limit, length)
+		}
+
+		lines := strings.Split(text, "\n")
+		listed := 0
+		for _, line := range lines {
+			if strings.HasPrefix(line, "• ") {
+				listed++
+			}
+		}
+		var left int
+		if _, err := fmt.Sscanf(lines[len(lines)-1], "…and %d more changes", &left); err != nil {
+			t.Fatalf("PlainText(%d) ends with %q, want the count of the changes left out", limit, lines[len(lines)-1])
+		}
+		if listed+left != 400 {
+			t.Errorf("PlainText(%d) lists %d changes and leaves out %d, want 400 in all", limit, listed, left)
+		}
+	}
+}
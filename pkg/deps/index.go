@@ -0,0 +1,146 @@
+package deps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// stampFile records the hash of the inputs of the last successful install. It lives inside node_modules
+// so that deleting node_modules also forces a fresh install.
+const stampFile = ".bob-install-hash"
+
+// PackageManager is the JavaScript package manager a project is locked with.
+type PackageManager struct {
+	Name     string
+	Lockfile string
+	// InstallArgs install exactly what the lockfile records and fail when it is out of date.
+	InstallArgs []string
+}
+
+// DetectPackageManager picks the package manager from the lockfile in the project directory.
+func DetectPackageManager(projectDir string) (*PackageManager, error) {
+	yarnArgs := []string{"install", "--frozen-lockfile"}
+	if _, err := os.Stat(filepath.Join(projectDir, ".yarnrc.yml")); err == nil {
+		// Yarn 2 and later renamed the flag.
+		yarnArgs = []string{"install", "--immutable"}
+	}
+
+	candidates := []PackageManager{
+		{"npm", "package-lock.json", []string{"ci"}},
+		{"yarn", "yarn.lock", yarnArgs},
+		{"pnpm", "pnpm-lock.yaml", []string{"install", "--frozen-lockfile"}},
+		{"bun", "bun.lockb", []string{"install", "--frozen-lockfile"}},
+		{"bun", "bun.lock", []string{"install", "--frozen-lockfile"}},
+	}
+
+	var found []PackageManager
+	for _, candidate := range candidates {
+		if _, err := os.Stat(filepath.Join(projectDir, candidate.Lockfile)); err == nil {
+			found = append(found, candidate)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("no lockfile found in %s, expected package-lock.json, yarn.lock, pnpm-lock.yaml or bun.lockb", projectDir)
+	case 1:
+		return &found[0], nil
+	default:
+		lockfiles := make([]string, len(found))
+		for i, pm := range found {
+			lockfiles[i] = pm.Lockfile
+		}
+		return nil, fmt.Errorf("several lockfiles found in %s (%s), delete the ones that are not used", projectDir, strings.Join(lockfiles, ", "))
+	}
+}
+
+// NodeBinDir returns the bin directory of the newest Node.js installed by nvm that matches version,
+// where version is a prefix such as 16 or 16.5.
+func NodeBinDir(homeDir, version string) (string, error) {
+	nvmDir := os.Getenv("NVM_DIR")
+	if nvmDir == "" {
+		nvmDir = filepath.Join(homeDir, ".nvm")
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(nvmDir, "versions", "node", "v*"))
+	var versions []string
+	for _, match := range matches {
+		name := strings.TrimPrefix(filepath.Base(match), "v")
+		if name == version || strings.HasPrefix(name, version+".") {
+			versions = append(versions, name)
+		}
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("Node.js %s is not installed with nvm, run bob setup", version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return utils.CompareVersions(versions[i], versions[j]) > 0
+	})
+	return filepath.Join(nvmDir, "versions", "node", "v"+versions[0], "bin"), nil
+}
+
+// NodeEnv returns the environment that puts the pinned Node.js version first on the PATH. When nvm does not
+// have it, the Node.js on the PATH is used as long as it is the pinned version.
+func NodeEnv(homeDir, version string) ([]string, error) {
+	binDir, err := NodeBinDir(homeDir, version)
+	if err == nil {
+		return []string{"PATH=" + binDir + string(os.PathListSeparator) + os.Getenv("PATH")}, nil
+	}
+
+	output, nodeErr := utils.RunCommandWithOutput("node", "--version")
+	current := strings.TrimPrefix(strings.TrimSpace(output), "v")
+	if nodeErr == nil && (current == version || strings.HasPrefix(current, version+".")) {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// InstallHash hashes everything that decides the contents of node_modules.
+func InstallHash(projectDir string, pm *PackageManager, env []string) (string, error) {
+	hash := sha256.New()
+	for _, name := range []string{"package.json", pm.Lockfile} {
+		f, err := os.Open(filepath.Join(projectDir, name))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %v", name, err)
+		}
+		_, err = io.Copy(hash, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %v", name, err)
+		}
+	}
+
+	node, _ := utils.RunCommandInDirWithOutput(projectDir, env, "node", "--version")
+	fmt.Fprintf(hash, "%s\n%s\n", pm.Name, strings.TrimSpace(node))
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// UpToDate reports whether node_modules was installed from inputs with the given hash.
+func UpToDate(projectDir, hash string) bool {
+	data, err := os.ReadFile(filepath.Join(projectDir, "node_modules", stampFile))
+	return err == nil && strings.TrimSpace(string(data)) == hash
+}
+
+// Install runs a frozen-lockfile install and records its hash on success.
+func Install(projectDir string, pm *PackageManager, env []string, hash string) error {
+	if err := utils.RunCommandInDir(projectDir, env, pm.Name, pm.InstallArgs...); err != nil {
+		return fmt.Errorf("%s %s failed: %v", pm.Name, strings.Join(pm.InstallArgs, " "), err)
+	}
+
+	path := filepath.Join(projectDir, "node_modules", stampFile)
+	if err := os.WriteFile(path, []byte(hash+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package deps
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates the files, given relative to dir, with their contents.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// fakeTools puts shell scripts standing in for the named tools first on the PATH, in place of the real ones.
+func fakeTools(t *testing.T, scripts map[string]string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake tools are shell scripts")
+	}
+	bin := t.TempDir()
+	for name, script := range scripts {
+		writeFiles(t, bin, map[string]string{name: "#!/bin/sh\n" + script + "\n"})
+	}
+	t.Setenv("PATH", bin)
+}
+
+func TestDetectPackageManager(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  string
+		args  []string
+		err   string
+	}{
+		{name: "npm", files: []string{"package-lock.json"}, want: "npm", args: []string{"ci"}},
+		{name: "yarn classic", files: []string{"yarn.lock"}, want: "yarn", args: []string{"install", "--frozen-lockfile"}},
+		{name: "yarn berry", files: []string{"yarn.lock", ".yarnrc.yml"}, want: "yarn", args: []string{"install", "--immutable"}},
+		{name: "pnpm", files: []string{"pnpm-lock.yaml"}, want: "pnpm", args: []string{"install", "--frozen-lockfile"}},
+		{name: "bun binary lockfile", files: []string{"bun.lockb"}, want: "bun", args: []string{"install", "--frozen-lockfile"}},
+		{name: "bun text lockfile", files: []string{"bun.lock"}, want: "bun", args: []string{"install", "--frozen-lockfile"}},
+		{name: "no lockfile", files: []string{"package.json"}, err: "no lockfile found"},
+		{name: "several lockfiles", files: []string{"package-lock.json", "yarn.lock"}, err: "several lockfiles found in %s (package-lock.json, yarn.lock)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range test.files {
+				writeFiles(t, dir, map[string]string{file: ""})
+			}
+
+			pm, err := DetectPackageManager(dir)
+			if test.err != "" {
+				want := strings.ReplaceAll(test.err, "%s", dir)
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("DetectPackageManager() = %v, want an error containing %q", err, want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pm.Name != test.want || !reflect.DeepEqual(pm.InstallArgs, test.args) {
+				t.Errorf("DetectPackageManager() = %s %q, want %s %q", pm.Name, pm.InstallArgs, test.want, test.args)
+			}
+		})
+	}
+}
+
+func TestInstallHash(t *testing.T) {
+	fakeTools(t, map[string]string{"node": "echo v18.20.4"})
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"package.json": `{"name": "acme"}`, "yarn.lock": "# yarn lockfile v1\n"})
+	yarn := &PackageManager{Name: "yarn", Lockfile: "yarn.lock"}
+
+	hash := func() string {
+		t.Helper()
+		hash, err := InstallHash(dir, yarn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	first := hash()
+	if again := hash(); again != first {
+		t.Errorf("InstallHash() = %s, then %s for the same inputs", first, again)
+	}
+
+	// Each of the inputs changes the hash.
+	changes := []struct {
+		name   string
+		change func()
+	}{
+		{"package.json", func() { writeFiles(t, dir, map[string]string{"package.json": `{"name": "acme", "version": "2.0.0"}`}) }},
+		{"lockfile", func() { writeFiles(t, dir, map[string]string{"yarn.lock": "# yarn lockfile v1\nreact@18.2.0\n"}) }},
+		{"package manager", func() { yarn = &PackageManager{Name: "yarn-berry", Lockfile: "yarn.lock"} }},
+		{"Node.js version", func() { fakeTools(t, map[string]string{"node": "echo v20.11.0"}) }},
+	}
+	previous := first
+	for _, change := range changes {
+		change.change()
+		if next := hash(); next == previous {
+			t.Errorf("InstallHash() did not change with the %s", change.name)
+		} else {
+			previous = next
+		}
+	}
+
+	if _, err := InstallHash(dir, &PackageManager{Name: "npm", Lockfile: "package-lock.json"}, nil); err == nil || !strings.Contains(err.Error(), "package-lock.json") {
+		t.Errorf("InstallHash() without the lockfile = %v, want an error naming it", err)
+	}
+}
+
+func TestInstall(t *testing.T) {
+	fakeTools(t, map[string]string{"npm": `[ "$1" = ci ]`})
+	dir := t.TempDir()
+	// node_modules is where npm would put it.
+	if err := os.Mkdir(filepath.Join(dir, "node_modules"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	npm := &PackageManager{Name: "npm", Lockfile: "package-lock.json", InstallArgs: []string{"ci"}}
+
+	if UpToDate(dir, "abc123") {
+		t.Error("UpToDate() before the first install = true")
+	}
+	if err := Install(dir, npm, nil, "abc123"); err != nil {
+		t.Fatal(err)
+	}
+	if !UpToDate(dir, "abc123") {
+		t.Error("UpToDate() after an install with the same hash = false")
+	}
+	if UpToDate(dir, "def456") {
+		t.Error("UpToDate() with another hash = true")
+	}
+
+	failing := &PackageManager{Name: "npm", Lockfile: "package-lock.json", InstallArgs: []string{"install", "--frozen"}}
+	if err := Install(dir, failing, nil, "def456"); err == nil || !strings.Contains(err.Error(), "npm install --frozen failed") {
+		t.Errorf("Install() of a failing install = %v, want an error naming the command", err)
+	}
+	if !UpToDate(dir, "abc123") {
+		t.Error("a failed install replaced the hash of the last successful one")
+	}
+}
+
+// nvmHome returns a home directory with the Node.js versions installed by nvm.
+func nvmHome(t *testing.T, versions ...string) string {
+	t.Helper()
+	t.Setenv("NVM_DIR", "")
+	home := t.TempDir()
+	for _, version := range versions {
+		writeFiles(t, home, map[string]string{filepath.Join(".nvm", "versions", "node", "v"+version, "bin", "node"): ""})
+	}
+	return home
+}
+
+func TestNodeBinDir(t *testing.T) {
+	home := nvmHome(t, "16.20.2", "18.19.0", "18.20.4", "1.8.0")
+	tests := map[string]string{
+		"18":      "v18.20.4",
+		"18.19":   "v18.19.0",
+		"16.20.2": "v16.20.2",
+		"1":       "v1.8.0",
+	}
+	for version, want := range tests {
+		got, err := NodeBinDir(home, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := filepath.Join(home, ".nvm", "versions", "node", want, "bin"); got != want {
+			t.Errorf("NodeBinDir(%s) = %s, want %s", version, got, want)
+		}
+	}
+
+	if _, err := NodeBinDir(home, "20"); err == nil || !strings.Contains(err.Error(), "Node.js 20 is not installed with nvm") {
+		t.Errorf("NodeBinDir() of a missing version = %v", err)
+	}
+
+	t.Setenv("NVM_DIR", filepath.Join(home, ".nvm"))
+	if got, err := NodeBinDir(t.TempDir(), "18.19"); err != nil || !strings.HasSuffix(got, filepath.Join("v18.19.0", "bin")) {
+		t.Errorf("NodeBinDir() with NVM_DIR = %s, %v, want the version installed there", got, err)
+	}
+}
+
+func TestNodeEnv(t *testing.T) {
+	t.Run("installed with nvm", func(t *testing.T) {
+		fakeTools(t, map[string]string{"node": "echo v20.11.0"})
+		home := nvmHome(t, "18.20.4")
+		env, err := NodeEnv(home, "18")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "PATH=" + filepath.Join(home, ".nvm", "versions", "node", "v18.20.4", "bin") + string(os.PathListSeparator)
+		if len(env) != 1 || !strings.HasPrefix(env[0], want) {
+			t.Errorf("NodeEnv() = %q, want the nvm version first on the PATH", env)
+		}
+	})
+
+	t.Run("pinned version on the PATH", func(t *testing.T) {
+		fakeTools(t, map[string]string{"node": "echo v20.11.0"})
+		if env, err := NodeEnv(nvmHome(t), "20"); err != nil || env != nil {
+			t.Errorf("NodeEnv() = %q, %v, want the PATH left alone", env, err)
+		}
+	})
+
+	t.Run("other version on the PATH", func(t *testing.T) {
+		fakeTools(t, map[string]string{"node": "echo v20.11.0"})
+		if _, err := NodeEnv(nvmHome(t, "16.20.2"), "18"); err == nil || !strings.Contains(err.Error(), "Node.js 18 is not installed with nvm, run bob setup") {
+			t.Errorf("NodeEnv() = %v, want an error suggesting bob setup", err)
+		}
+	})
+
+	t.Run("no node", func(t *testing.T) {
+		fakeTools(t, nil)
+		if _, err := NodeEnv(nvmHome(t), "18"); err == nil {
+			t.Error("NodeEnv() without any Node.js succeeded")
+		}
+	})
+
+	t.Run("failing node", func(t *testing.T) {
+		fakeTools(t, map[string]string{"node": "echo v18.20.4; exit 1"})
+		if _, err := NodeEnv(nvmHome(t), "18"); err == nil {
+			t.Error("NodeEnv() with a node that fails succeeded")
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package deps
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/aman-apptile/bob/pkg/ios"
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// repoUpdateRegexp matches the pod install errors that go away once the local spec repos are updated.
+var repoUpdateRegexp = regexp.MustCompile(`(?i)(could not find compatible versions for pod|none of your spec sources contain a spec satisfying|out-of-date source repos|pod repo update)`)
+
+// PodChange is a pod that was added, removed or changed version by an install.
+type PodChange struct {
+	Name string
+	// From is empty for added pods.
+	From string
+	// To is empty for removed pods.
+	To string
+}
+
+func (c PodChange) String() string {
+	switch {
+	case c.From == "":
+		return fmt.Sprintf("+ %s %s", c.Name, c.To)
+	case c.To == "":
+		return fmt.Sprintf("- %s %s", c.Name, c.From)
+	default:
+		return fmt.Sprintf("~ %s %s -> %s", c.Name, c.From, c.To)
+	}
+}
+
+// PodsUpToDate reports whether the installed pods match the Podfile.lock: the lockfile and Pods/Manifest.lock
+// are identical and the Podfile has not changed since the lockfile was written.
+func PodsUpToDate(iosDir string) bool {
+	lock, err := os.ReadFile(filepath.Join(iosDir, "Podfile.lock"))
+	if err != nil {
+		return false
+	}
+	manifest, err := os.ReadFile(filepath.Join(iosDir, "Pods", "Manifest.lock"))
+	if err != nil || !bytes.Equal(fileHash(lock), fileHash(manifest)) {
+		return false
+	}
+
+	podfile, err := os.ReadFile(filepath.Join(iosDir, "Podfile"))
+	if err != nil {
+		return false
+	}
+	checksum := sha1.Sum(podfile)
+	return ios.ParsePodfileLock(lock).PodfileChecksum == hex.EncodeToString(checksum[:])
+}
+
+// InstallPods runs pod install in the project's ios directory through exec, retrying with --repo-update only when
+// CocoaPods reports that its spec repos are out of date. It returns the pods that changed since the previous install.
+func InstallPods(exec utils.Executor, projectDir string) ([]PodChange, error) {
+	iosDir := filepath.Join(projectDir, "ios")
+	before := map[string]string{}
+	if manifest, err := ios.LoadPodfileLock(filepath.Join(iosDir, "Pods", "Manifest.lock")); err == nil {
+		before = manifest.Pods
+	}
+
+	command, args, env := ios.PodCommand(projectDir)
+	args = append(args, "install")
+	output, err := exec.Capture(iosDir, env, command, args...)
+	if err != nil && repoUpdateRegexp.MatchString(output) {
+		fmt.Println("Spec repos are out of date, retrying with --repo-update...")
+		_, err = exec.Capture(iosDir, env, command, append(args, "--repo-update")...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("pod install failed: %v", err)
+	}
+
+	after, err := ios.LoadPodfileLock(filepath.Join(iosDir, "Pods", "Manifest.lock"))
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffPods(before, after.Pods), nil
+}
+
+// DiffPods lists the pods that differ between two installs, sorted by name.
+func DiffPods(before, after map[string]string) []PodChange {
+	var changes []PodChange
+	for name, version := range after {
+		if before[name] != version {
+			changes = append(changes, PodChange{Name: name, From: before[name], To: version})
+		}
+	}
+	for name, version := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, PodChange{Name: name, From: version})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+func fileHash(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
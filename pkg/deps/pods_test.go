@@ -0,0 +1,228 @@
+package deps
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// podsProject returns a project whose ios directory holds the fixture Podfile and Podfile.lock, and the given
+// fixture as Pods/Manifest.lock when it is not empty.
+func podsProject(t *testing.T, manifest string) string {
+	t.Helper()
+	projectDir := t.TempDir()
+	files := map[string]string{"Podfile": "Podfile", "Podfile.lock": "Podfile.lock"}
+	if manifest != "" {
+		files[filepath.Join("Pods", "Manifest.lock")] = manifest
+	}
+	for name, fixture := range files {
+		data, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, filepath.Join(projectDir, "ios"), map[string]string{name: string(data)})
+	}
+	return projectDir
+}
+
+func TestPodsUpToDate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(iosDir string)
+		want   bool
+	}{
+		{name: "installed from the lockfile", want: true},
+		{name: "never installed", change: func(iosDir string) { os.RemoveAll(filepath.Join(iosDir, "Pods")) }},
+		{name: "no lockfile", change: func(iosDir string) { os.Remove(filepath.Join(iosDir, "Podfile.lock")) }},
+		{name: "no Podfile", change: func(iosDir string) { os.Remove(filepath.Join(iosDir, "Podfile")) }},
+		{name: "installed from another lockfile", change: func(iosDir string) {
+			data, _ := os.ReadFile(filepath.Join("testdata", "Manifest-0.73.lock"))
+			os.WriteFile(filepath.Join(iosDir, "Pods", "Manifest.lock"), data, 0644)
+		}},
+		{name: "Podfile changed", change: func(iosDir string) {
+			f, _ := os.OpenFile(filepath.Join(iosDir, "Podfile"), os.O_APPEND|os.O_WRONLY, 0644)
+			f.WriteString("pod 'Firebase/Analytics'\n")
+			f.Close()
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			iosDir := filepath.Join(podsProject(t, "Podfile.lock"), "ios")
+			if test.change != nil {
+				test.change(iosDir)
+			}
+			if got := PodsUpToDate(iosDir); got != test.want {
+				t.Errorf("PodsUpToDate() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+// fakePod stands in for CocoaPods. It records the commands it is asked to run and answers them in order with
+// the given outputs and errors, writing Pods/Manifest.lock from the fixture lockfile on success.
+type fakePod struct {
+	iosDir  string
+	calls   []string
+	answers []podAnswer
+}
+
+type podAnswer struct {
+	output string
+	err    error
+}
+
+func (f *fakePod) Capture(dir string, env []string, command string, args ...string) (string, error) {
+	f.calls = append(f.calls, strings.Join(append([]string{filepath.Base(command)}, args...), " "))
+	answer := f.answers[0]
+	if len(f.answers) > 1 {
+		f.answers = f.answers[1:]
+	}
+	if answer.err == nil {
+		data, err := os.ReadFile(filepath.Join(f.iosDir, "Podfile.lock"))
+		if err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(filepath.Join(f.iosDir, "Pods", "Manifest.lock"), data, 0644); err != nil {
+			return "", err
+		}
+	}
+	return answer.output, answer.err
+}
+
+func (f *fakePod) Run(dir string, env []string, command string, args ...string) error {
+	_, err := f.Capture(dir, env, command, args...)
+	return err
+}
+
+func (f *fakePod) Output(dir string, env []string, command string, args ...string) (string, error) {
+	return f.Capture(dir, env, command, args...)
+}
+
+func (f *fakePod) Start(dir string, env []string, logFile string, command string, args ...string) error {
+	_, err := f.Capture(dir, env, command, args...)
+	return err
+}
+
+var outdatedRepos = podAnswer{
+	output: "[!] CocoaPods could not find compatible versions for pod \"hermes-engine\":\n  In Podfile:\n    hermes-engine (from `../node_modules/react-native/sdks/hermes-engine/hermes-engine.podspec`)\n",
+	err:    fmt.Errorf("exit status 1"),
+}
+
+func TestInstallPods(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []podAnswer
+		calls   []string
+		err     string
+	}{
+		{name: "install", answers: []podAnswer{{output: "Pod installation complete!"}}, calls: []string{"pod install"}},
+		{
+			name:    "spec repos out of date",
+			answers: []podAnswer{outdatedRepos, {output: "Pod installation complete!"}},
+			calls:   []string{"pod install", "pod install --repo-update"},
+		},
+		{
+			name:    "still failing after the repo update",
+			answers: []podAnswer{outdatedRepos},
+			calls:   []string{"pod install", "pod install --repo-update"},
+			err:     "pod install failed: exit status 1",
+		},
+		{
+			name:    "other failure",
+			answers: []podAnswer{{output: "[!] Invalid `Podfile` file: syntax error", err: fmt.Errorf("exit status 1")}},
+			calls:   []string{"pod install"},
+			err:     "pod install failed: exit status 1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			projectDir := podsProject(t, "Manifest-0.73.lock")
+			pod := &fakePod{iosDir: filepath.Join(projectDir, "ios"), answers: test.answers}
+
+			changes, err := InstallPods(pod, projectDir)
+			if !reflect.DeepEqual(pod.calls, test.calls) {
+				t.Errorf("ran %q, want %q", pod.calls, test.calls)
+			}
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("InstallPods() = %v, want an error containing %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, change := range changes {
+				got = append(got, change.String())
+			}
+			want := []string{
+				"~ FBLazyVector 0.73.8 -> 0.74.1",
+				"+ RNScreens 3.31.1",
+				"- glog 0.3.5",
+				"~ hermes-engine 0.73.8 -> 0.74.1",
+				"~ hermes-engine/Pre-built 0.73.8 -> 0.74.1",
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("InstallPods() changed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+			}
+		})
+	}
+}
+
+func TestInstallPodsFirstInstall(t *testing.T) {
+	projectDir := podsProject(t, "")
+	iosDir := filepath.Join(projectDir, "ios")
+	if err := os.Mkdir(filepath.Join(iosDir, "Pods"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := InstallPods(&fakePod{iosDir: iosDir, answers: []podAnswer{{}}}, projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every pod of the lockfile is new.
+	if len(changes) != 6 {
+		t.Errorf("InstallPods() = %v, want the 6 pods of the lockfile added", changes)
+	}
+	for _, change := range changes {
+		if change.From != "" {
+			t.Errorf("InstallPods() = %v, want only added pods", change)
+		}
+	}
+}
+
+func TestDiffPods(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after map[string]string
+		want          []PodChange
+	}{
+		{name: "unchanged", before: map[string]string{"boost": "1.83.0"}, after: map[string]string{"boost": "1.83.0"}},
+		{
+			name:   "added, removed and updated",
+			before: map[string]string{"boost": "1.83.0", "glog": "0.3.5", "RNScreens": "3.30.0"},
+			after:  map[string]string{"boost": "1.83.0", "RNScreens": "3.31.1", "RNSVG": "15.2.0"},
+			want: []PodChange{
+				{Name: "RNSVG", To: "15.2.0"},
+				{Name: "RNScreens", From: "3.30.0", To: "3.31.1"},
+				{Name: "glog", From: "0.3.5"},
+			},
+		},
+		{name: "all removed", before: map[string]string{"boost": "1.83.0"}, after: map[string]string{}, want: []PodChange{{Name: "boost", From: "1.83.0"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := DiffPods(test.before, test.after); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("DiffPods() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
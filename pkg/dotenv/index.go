@@ -0,0 +1,133 @@
+package dotenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretKeyRegexp matches variable names that hold secrets even when bob.yaml does not list them.
+var secretKeyRegexp = regexp.MustCompile(`(?i)(SECRET|TOKEN|PASSWORD|PASSWD|PRIVATE|CREDENTIAL|(^|_)KEY$|_DSN$)`)
+
+// Env is a set of variables loaded from a .env file.
+type Env struct {
+	// File is the path the variables were loaded from.
+	File string
+	Vars map[string]string
+}
+
+// FileName returns the .env file for an environment: .env for the default environment, .env.<name> otherwise.
+func FileName(name string) string {
+	if name == "" {
+		return ".env"
+	}
+	return ".env." + name
+}
+
+// Load reads the .env file of the named environment from projectDir. The error for a missing file wraps
+// os.ErrNotExist.
+func Load(projectDir, name string) (*Env, error) {
+	path := filepath.Join(projectDir, FileName(name))
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no %s found in %s: %w", FileName(name), projectDir, os.ErrNotExist)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	vars, err := Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	return &Env{File: path, Vars: vars}, nil
+}
+
+// Parse reads KEY=value lines the way react-native-config does. Blank lines and # comments are ignored,
+// an export prefix is allowed and values may be wrapped in single or double quotes.
+func Parse(content string) (map[string]string, error) {
+	vars := map[string]string{}
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=value", i+1)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			quote := value[0]
+			value = value[1 : len(value)-1]
+			if quote == '"' {
+				value = strings.ReplaceAll(value, `\n`, "\n")
+			}
+		} else if comment := strings.Index(value, " #"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+
+		vars[key] = value
+	}
+
+	return vars, nil
+}
+
+// Keys returns the variable names in sorted order.
+func (e *Env) Keys() []string {
+	keys := make([]string, 0, len(e.Vars))
+	for key := range e.Vars {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Missing returns the required keys that are not set or are empty.
+func (e *Env) Missing(required []string) []string {
+	var missing []string
+	for _, key := range required {
+		if e.Vars[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// Secrets returns the values of the variables that are listed in secrets or whose names look like secrets.
+func (e *Env) Secrets(secrets []string) []string {
+	var values []string
+	for _, key := range e.Keys() {
+		if e.Vars[key] != "" && IsSecret(key, secrets) {
+			values = append(values, e.Vars[key])
+		}
+	}
+	return values
+}
+
+// Environ returns the variables as KEY=value pairs for a child process, along with ENVFILE, which tells
+// react-native-config which file to read from Gradle and from the Xcode build phase.
+func (e *Env) Environ() []string {
+	environ := []string{"ENVFILE=" + filepath.Base(e.File)}
+	for _, key := range e.Keys() {
+		environ = append(environ, key+"="+e.Vars[key])
+	}
+	return environ
+}
+
+// IsSecret reports whether a variable should be masked in logs.
+func IsSecret(key string, secrets []string) bool {
+	for _, secret := range secrets {
+		if secret == key {
+			return true
+		}
+	}
+	return secretKeyRegexp.MatchString(key)
+}
@@ -0,0 +1,151 @@
+package dotenv
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	content := `# Backend
+API_URL=https://api.acme.com
+export SENTRY_DSN=https://key@sentry.io/1
+
+GREETING="Hello # not a comment"
+SINGLE='it''s \n literal'
+MULTILINE="one\ntwo"
+THEME=dark # the default theme
+ANCHOR=page#top
+  SPACED  =  value with spaces
+EMPTY=
+EQUALS=a=b
+`
+	want := map[string]string{
+		"API_URL":    "https://api.acme.com",
+		"SENTRY_DSN": "https://key@sentry.io/1",
+		"GREETING":   "Hello # not a comment",
+		"SINGLE":     `it''s \n literal`,
+		"MULTILINE":  "one\ntwo",
+		"THEME":      "dark",
+		"ANCHOR":     "page#top",
+		"SPACED":     "value with spaces",
+		"EMPTY":      "",
+		"EQUALS":     "a=b",
+	}
+
+	got, err := Parse(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %q, want %q", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"no equals":     "API_URL=https://api.acme.com\nTHEME\n",
+		"space in key":  "API_URL=https://api.acme.com\nTHE ME=dark\n",
+		"no key":        "API_URL=https://api.acme.com\n=dark\n",
+		"export no key": "API_URL=https://api.acme.com\nexport =dark\n",
+	}
+	for name, content := range tests {
+		if _, err := Parse(content); err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("Parse() with %s = %v, want an error on line 2", name, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env.staging"), []byte("API_URL=https://staging.acme.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := Load(dir, "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.File != filepath.Join(dir, ".env.staging") || env.Vars["API_URL"] != "https://staging.acme.com" {
+		t.Errorf("Load() = %+v, want the variables of .env.staging", env)
+	}
+
+	if _, err := Load(dir, ""); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() of a missing .env = %v, want an error wrapping os.ErrNotExist", err)
+	}
+
+	broken := t.TempDir()
+	if err := os.WriteFile(filepath.Join(broken, ".env"), []byte("API_URL\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(broken, ""); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() of a .env with a bad line = %v, want a parse error", err)
+	}
+
+	unreadable := t.TempDir()
+	if err := os.Mkdir(filepath.Join(unreadable, ".env"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(unreadable, ""); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Load() of a .env that cannot be read = %v, want a read error", err)
+	}
+}
+
+func TestMissing(t *testing.T) {
+	env := &Env{Vars: map[string]string{"API_URL": "https://api.acme.com", "SENTRY_DSN": ""}}
+	got := env.Missing([]string{"API_URL", "SENTRY_DSN", "STRIPE_KEY"})
+	if want := []string{"SENTRY_DSN", "STRIPE_KEY"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Missing() = %q, want %q", got, want)
+	}
+	if got := env.Missing(nil); len(got) != 0 {
+		t.Errorf("Missing() without required keys = %q, want none", got)
+	}
+}
+
+func TestIsSecret(t *testing.T) {
+	secrets := []string{"ALGOLIA_APP"}
+	tests := map[string]bool{
+		"API_URL":            false,
+		"ALGOLIA_APP":        true,
+		"STRIPE_SECRET":      true,
+		"GITHUB_TOKEN":       true,
+		"KEYSTORE_PASSWORD":  true,
+		"PRIVATE_FEED":       true,
+		"GOOGLE_CREDENTIALS": true,
+		"MAPS_API_KEY":       true,
+		"KEY":                true,
+		"KEYBOARD_LAYOUT":    false,
+		"MONKEY_MODE":        false,
+		"SENTRY_DSN":         true,
+		"api_token":          true,
+	}
+	for key, want := range tests {
+		if got := IsSecret(key, secrets); got != want {
+			t.Errorf("IsSecret(%q) = %t, want %t", key, got, want)
+		}
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	env := &Env{Vars: map[string]string{
+		"API_URL":       "https://api.acme.com",
+		"ALGOLIA_APP":   "ALG123",
+		"STRIPE_SECRET": "sk_live_123",
+		"EMPTY_TOKEN":   "",
+	}}
+	got := env.Secrets([]string{"ALGOLIA_APP"})
+	if want := []string{"ALG123", "sk_live_123"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Secrets() = %q, want %q", got, want)
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	env := &Env{File: "/project/.env.staging", Vars: map[string]string{"THEME": "dark", "API_URL": "https://staging.acme.com"}}
+	want := []string{"ENVFILE=.env.staging", "API_URL=https://staging.acme.com", "THEME=dark"}
+	if got := env.Environ(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Environ() = %q, want %q", got, want)
+	}
+}
@@ -1,15 +1,29 @@
 package pkg
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/aman-apptile/bob/pkg/android"
+	"github.com/aman-apptile/bob/pkg/ios"
 	"github.com/aman-apptile/bob/pkg/utils"
 )
 
-// CheckCocoapods checks if CocoaPods is installed or not.
-func CheckCocoapods() bool {
-	return utils.IsGemInstalled("cocoapods")
+// CheckCocoapods checks if CocoaPods runs for the project, through Bundler when it has a Gemfile, and that it is
+// the version pinned in Gemfile.lock. It returns the version that runs.
+func CheckCocoapods(projectDir string) (string, error) {
+	installed, err := ios.CocoapodsVersion(projectDir)
+	if err != nil {
+		return "", err
+	}
+
+	if pinned := ios.PinnedCocoapodsVersion(projectDir); pinned != "" && utils.CompareVersions(installed, pinned) != 0 {
+		return installed, fmt.Errorf("CocoaPods %s runs but Gemfile.lock pins %s, run bob setup", installed, pinned)
+	}
+
+	return installed, nil
 }
 
 // CheckHomebrew checks if Homebrew is installed or not.
@@ -60,6 +74,119 @@ func CheckAndroidEnvironment(homeDir string) bool {
 }
 
 // CheckIosEnvironment checks if iOS environment is setup or not.
+// CocoaPods is checked separately since it may only be available through Bundler.
 func CheckIosEnvironment() bool {
-	return utils.IsCommandAvailable("xcodebuild") && utils.IsCommandAvailable("pod")
+	return utils.IsCommandAvailable("xcodebuild")
+}
+
+// CheckXcodeProject parses the Xcode project of the React Native app and describes its main target.
+func CheckXcodeProject(projectDir string) (string, error) {
+	xcodeproj, err := ios.FindXcodeProject(filepath.Join(projectDir, "ios"))
+	if err != nil {
+		return "", err
+	}
+
+	project, err := ios.LoadProject(xcodeproj)
+	if err != nil {
+		return "", err
+	}
+
+	target := project.MainTarget()
+	if target == nil {
+		return "", fmt.Errorf("%s has no application target", filepath.Base(xcodeproj))
+	}
+
+	bundleID, _ := project.ResolvedBundleIdentifier("Release")
+	return fmt.Sprintf("%s (%s, iOS %s, version %s, schemes: %s)",
+		target.Name,
+		bundleID,
+		project.DeploymentTarget("Release"),
+		project.MarketingVersion("Release"),
+		strings.Join(project.Schemes, ", "),
+	), nil
+}
+
+// CheckCocoapodsVersion checks if the installed CocoaPods matches the version recorded in Podfile.lock.
+func CheckCocoapodsVersion(projectDir string) error {
+	lock, err := ios.LoadPodfileLock(filepath.Join(projectDir, "ios", "Podfile.lock"))
+	if err != nil {
+		return err
+	}
+
+	installed, err := ios.CocoapodsVersion(projectDir)
+	if err != nil {
+		return err
+	}
+
+	if lock.CocoapodsVersion != "" && utils.CompareVersions(installed, lock.CocoapodsVersion) != 0 {
+		return fmt.Errorf("CocoaPods %s is installed but Podfile.lock was generated with %s", installed, lock.CocoapodsVersion)
+	}
+
+	return nil
+}
+
+// CheckJDK returns the JDK the project builds with, see android.SelectJDK. Outside of an Android project it looks
+// for defaultJDK, the JDK bob setup installs.
+func CheckJDK(projectDir, homeDir string, defaultJDK int) (android.JDK, error) {
+	jdks := android.FindJDKs(homeDir)
+	if _, err := android.AGPVersion(projectDir); err != nil && android.PinnedJDK(projectDir) == 0 {
+		if jdk, ok := android.FindJDK(jdks, defaultJDK); ok {
+			return jdk, nil
+		}
+		return android.JDK{}, fmt.Errorf("JDK %d is not installed, install it with brew install openjdk@%d", defaultJDK, defaultJDK)
+	}
+	return android.SelectJDK(projectDir, jdks)
+}
+
+// CheckGradleCompatibility checks that the Gradle wrapper, the Android Gradle plugin and the JDK bob builds with,
+// picked from the installed JDKs, can build the project together. It returns a summary of the versions.
+func CheckGradleCompatibility(projectDir, homeDir string) (string, error) {
+	gradleVersion, err := android.GradleWrapperVersion(projectDir)
+	if err != nil {
+		return "", err
+	}
+	agpVersion, err := android.AGPVersion(projectDir)
+	if err != nil {
+		return "", err
+	}
+
+	jdk, err := android.SelectJDK(projectDir, android.FindJDKs(homeDir))
+	if err != nil {
+		return "", err
+	}
+
+	summary := fmt.Sprintf("Gradle %s, Android Gradle plugin %s, %s", gradleVersion, agpVersion, jdk)
+	return summary, android.CheckCompatibility(agpVersion, gradleVersion, jdk.Major())
+}
+
+// CheckXcodeDeploymentTarget checks if the active Xcode can build for the deployment target of the project.
+func CheckXcodeDeploymentTarget(projectDir string) error {
+	iosDir := filepath.Join(projectDir, "ios")
+	deploymentTarget := ""
+
+	if xcodeproj, err := ios.FindXcodeProject(iosDir); err == nil {
+		if project, err := ios.LoadProject(xcodeproj); err == nil {
+			deploymentTarget = project.DeploymentTarget("Release")
+		}
+	}
+	if podfile, err := ios.LoadPodfile(filepath.Join(iosDir, "Podfile")); err == nil {
+		if utils.CompareVersions(podfile.Platform, deploymentTarget) > 0 {
+			deploymentTarget = podfile.Platform
+		}
+	}
+	if deploymentTarget == "" {
+		return fmt.Errorf("could not determine the iOS deployment target")
+	}
+
+	xcodeVersion, err := ios.XcodeVersion()
+	if err != nil {
+		return err
+	}
+
+	required := ios.MinimumXcodeVersion(deploymentTarget)
+	if utils.MajorVersion(xcodeVersion) < required {
+		return fmt.Errorf("deployment target iOS %s needs Xcode %d or newer, found Xcode %s", deploymentTarget, required, xcodeVersion)
+	}
+
+	return nil
 }
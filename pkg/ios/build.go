@@ -0,0 +1,233 @@
+package ios
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// ExportMethods lists the distribution methods understood by `xcodebuild -exportArchive`.
+var ExportMethods = []string{"app-store", "ad-hoc", "enterprise", "development"}
+
+// FindWorkspace returns the path of the first .xcworkspace inside the given iOS directory, or "" when there is none.
+// React Native projects using CocoaPods must be built through the workspace.
+func FindWorkspace(iosDir string) string {
+	matches, _ := filepath.Glob(filepath.Join(iosDir, "*.xcworkspace"))
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return matches[0]
+}
+
+// ListSchemes returns the shared schemes of the Xcode project and workspace in the given iOS directory.
+func ListSchemes(iosDir string) []string {
+	return schemesIn(iosDir, "xcshareddata")
+}
+
+// CheckScheme verifies that the scheme is shared, so that xcodebuild can see it on any machine.
+func CheckScheme(iosDir, scheme string) error {
+	shared := ListSchemes(iosDir)
+	for _, name := range shared {
+		if name == scheme {
+			return nil
+		}
+	}
+
+	for _, name := range schemesIn(iosDir, filepath.Join("xcuserdata", "*.xcuserdatad")) {
+		if name == scheme {
+			return fmt.Errorf("scheme %q is not shared, tick \"Shared\" for it in Xcode's Manage Schemes and commit the .xcscheme file", scheme)
+		}
+	}
+
+	if suggestion := utils.ClosestMatch(scheme, shared); suggestion != "" {
+		return fmt.Errorf("unknown scheme %q, did you mean %q?", scheme, suggestion)
+	}
+	return fmt.Errorf("unknown scheme %q, run with --list-schemes to see the shared schemes", scheme)
+}
+
+func schemesIn(iosDir, dataDir string) []string {
+	seen := map[string]bool{}
+	var schemes []string
+
+	for _, container := range []string{"*.xcodeproj", "*.xcworkspace"} {
+		matches, _ := filepath.Glob(filepath.Join(iosDir, container, dataDir, "xcschemes", "*.xcscheme"))
+		for _, match := range matches {
+			name := strings.TrimSuffix(filepath.Base(match), ".xcscheme")
+			if !seen[name] {
+				seen[name] = true
+				schemes = append(schemes, name)
+			}
+		}
+	}
+	sort.Strings(schemes)
+
+	return schemes
+}
+
+// ExportOptions configures how an archive is exported to an IPA.
+type ExportOptions struct {
+	Method string
+	TeamID string
+	// Profiles maps bundle identifiers to provisioning profile names. When empty, Xcode signs automatically.
+	Profiles map[string]string
+}
+
+// ValidateExportMethod checks the export method before a long archive is started.
+func ValidateExportMethod(method string) error {
+	for _, valid := range ExportMethods {
+		if method == valid {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown export method %q, expected one of: %s", method, strings.Join(ExportMethods, ", "))
+}
+
+// Plist renders the ExportOptions.plist for `xcodebuild -exportArchive`.
+func (o ExportOptions) Plist() ([]byte, error) {
+	if err := ValidateExportMethod(o.Method); err != nil {
+		return nil, err
+	}
+
+	options := map[string]interface{}{
+		"method":            o.Method,
+		"compileBitcode":    false,
+		"stripSwiftSymbols": true,
+	}
+	if o.TeamID != "" {
+		options["teamID"] = o.TeamID
+	}
+	if o.Method == "app-store" {
+		options["uploadSymbols"] = true
+	}
+	if o.Method == "ad-hoc" || o.Method == "enterprise" {
+		options["thinning"] = "<none>"
+	}
+
+	if len(o.Profiles) > 0 {
+		options["signingStyle"] = "manual"
+		options["provisioningProfiles"] = o.Profiles
+	} else {
+		options["signingStyle"] = "automatic"
+	}
+
+	return MarshalXMLPlist(options)
+}
+
+// ArchiveOptions describes an `xcodebuild archive` invocation.
+type ArchiveOptions struct {
+	IosDir        string
+	Scheme        string
+	Configuration string
+	ArchivePath   string
+	// Env holds extra environment variables for xcodebuild, which build phase scripts can read.
+	Env []string
+}
+
+// Archive builds an .xcarchive of the scheme, going through the workspace when there is one.
+func Archive(options ArchiveOptions) error {
+	args := []string{}
+	if workspace := FindWorkspace(options.IosDir); workspace != "" {
+		args = append(args, "-workspace", workspace)
+	} else {
+		project, err := FindXcodeProject(options.IosDir)
+		if err != nil {
+			return err
+		}
+		args = append(args, "-project", project)
+	}
+
+	args = append(args,
+		"-scheme", options.Scheme,
+		"-configuration", options.Configuration,
+		"-sdk", "iphoneos",
+		"-destination", "generic/platform=iOS",
+		"-archivePath", options.ArchivePath,
+		"archive",
+	)
+	if err := utils.RunCommandInDir("", options.Env, "xcodebuild", args...); err != nil {
+		return fmt.Errorf("xcodebuild archive failed: %v", err)
+	}
+
+	return nil
+}
+
+// ExportArchive writes the export options next to the archive and exports an IPA into exportPath.
+func ExportArchive(archivePath, exportPath string, options ExportOptions) error {
+	plist, err := options.Plist()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(exportPath, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create %s: %v", exportPath, err)
+	}
+	optionsPath := filepath.Join(exportPath, "ExportOptions.plist")
+	if err := os.WriteFile(optionsPath, plist, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", optionsPath, err)
+	}
+
+	err = utils.RunCommand("xcodebuild", "-exportArchive",
+		"-archivePath", archivePath,
+		"-exportPath", exportPath,
+		"-exportOptionsPlist", optionsPath,
+	)
+	if err != nil {
+		return fmt.Errorf("xcodebuild -exportArchive failed: %v", err)
+	}
+
+	return nil
+}
+
+// SimulatorBuildOptions describes an `xcodebuild build` for a simulator.
+type SimulatorBuildOptions struct {
+	IosDir          string
+	Scheme          string
+	Configuration   string
+	UDID            string
+	DerivedDataPath string
+	Env             []string
+}
+
+// BuildForSimulator builds the scheme for the simulator and returns the path of the built .app.
+func BuildForSimulator(options SimulatorBuildOptions) (string, error) {
+	args := []string{}
+	if workspace := FindWorkspace(options.IosDir); workspace != "" {
+		args = append(args, "-workspace", workspace)
+	} else {
+		project, err := FindXcodeProject(options.IosDir)
+		if err != nil {
+			return "", err
+		}
+		args = append(args, "-project", project)
+	}
+
+	args = append(args,
+		"-scheme", options.Scheme,
+		"-configuration", options.Configuration,
+		"-sdk", "iphonesimulator",
+		"-destination", "id="+options.UDID,
+		"-derivedDataPath", options.DerivedDataPath,
+		"build",
+	)
+	if err := utils.RunCommandInDir("", options.Env, "xcodebuild", args...); err != nil {
+		return "", fmt.Errorf("xcodebuild build failed: %v", err)
+	}
+
+	return FindSimulatorApp(options.DerivedDataPath, options.Configuration)
+}
+
+// FindSimulatorApp returns the .app a simulator build of the configuration wrote into the derived data.
+func FindSimulatorApp(derivedDataPath, configuration string) (string, error) {
+	productsDir := filepath.Join(derivedDataPath, "Build", "Products", configuration+"-iphonesimulator")
+	apps, _ := filepath.Glob(filepath.Join(productsDir, "*.app"))
+	if len(apps) == 0 {
+		return "", fmt.Errorf("no .app found in %s", productsDir)
+	}
+	return apps[0], nil
+}
@@ -0,0 +1,80 @@
+package ios
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckScheme(t *testing.T) {
+	tests := []struct {
+		scheme string
+		err    string
+	}{
+		{scheme: "HelloWorld"},
+		{scheme: "HelloWorld-Staging", err: `scheme "HelloWorld-Staging" is not shared`},
+		{scheme: "HeloWorld", err: `did you mean "HelloWorld"?`},
+		{scheme: "Acme", err: "run with --list-schemes"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scheme, func(t *testing.T) {
+			err := CheckScheme("testdata", test.scheme)
+			if test.err == "" && err != nil {
+				t.Errorf("CheckScheme() = %v", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("CheckScheme() = %v, want an error containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestListSchemes(t *testing.T) {
+	// The user-only scheme is left out, xcodebuild does not see it on other machines.
+	if got, want := ListSchemes("testdata"), []string{"HelloWorld"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListSchemes() = %q, want %q", got, want)
+	}
+	if got := ListSchemes(t.TempDir()); len(got) != 0 {
+		t.Errorf("ListSchemes() of a directory without a project = %q, want none", got)
+	}
+}
+
+func TestExportOptionsPlist(t *testing.T) {
+	profiles := map[string]string{
+		"com.acme.app":           "Acme App Store",
+		"com.acme.app.extension": "Acme Notification Extension",
+	}
+	tests := []struct {
+		golden  string
+		options ExportOptions
+	}{
+		{golden: "app-store-manual.plist", options: ExportOptions{Method: "app-store", TeamID: "ABCDE12345", Profiles: profiles}},
+		{golden: "app-store-automatic.plist", options: ExportOptions{Method: "app-store", TeamID: "ABCDE12345"}},
+		{golden: "ad-hoc-manual.plist", options: ExportOptions{Method: "ad-hoc", TeamID: "ABCDE12345", Profiles: profiles}},
+		{golden: "enterprise-automatic.plist", options: ExportOptions{Method: "enterprise", TeamID: "ABCDE12345"}},
+		{golden: "development-automatic.plist", options: ExportOptions{Method: "development"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.golden, func(t *testing.T) {
+			got, err := test.options.Plist()
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(filepath.Join("testdata", "export-options", test.golden))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Plist() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+
+	if _, err := (ExportOptions{Method: "appstore"}).Plist(); err == nil || !strings.Contains(err.Error(), "unknown export method") {
+		t.Errorf("Plist() with an unknown method = %v, want an error", err)
+	}
+}
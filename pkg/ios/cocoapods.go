@@ -0,0 +1,125 @@
+package ios
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+var gemSpecRegexp = regexp.MustCompile(`^    ([^\s(]+) \(([^)]+)\)$`)
+
+// GemfileLock holds the gems resolved in a Gemfile.lock and the Bundler version that resolved them.
+type GemfileLock struct {
+	Gems           map[string]string
+	BundlerVersion string
+}
+
+// FindGemfile returns the Gemfile of the project, in the project root as in the React Native template or in
+// the ios directory, or "" when there is none.
+func FindGemfile(projectDir string) string {
+	for _, gemfile := range []string{filepath.Join(projectDir, "Gemfile"), filepath.Join(projectDir, "ios", "Gemfile")} {
+		if _, err := os.Stat(gemfile); err == nil {
+			absolute, err := filepath.Abs(gemfile)
+			if err != nil {
+				return gemfile
+			}
+			return absolute
+		}
+	}
+	return ""
+}
+
+// LoadGemfileLock reads and parses the Gemfile.lock at the given path.
+func LoadGemfileLock(path string) (*GemfileLock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return ParseGemfileLock(data), nil
+}
+
+// ParseGemfileLock extracts the top level gem versions and the BUNDLED WITH version from a Gemfile.lock.
+func ParseGemfileLock(data []byte) *GemfileLock {
+	lock := &GemfileLock{Gems: map[string]string{}}
+
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") {
+			section = strings.TrimSpace(line)
+			continue
+		}
+
+		switch section {
+		case "GEM":
+			if match := gemSpecRegexp.FindStringSubmatch(line); match != nil {
+				lock.Gems[match[1]] = match[2]
+			}
+		case "BUNDLED WITH":
+			lock.BundlerVersion = strings.TrimSpace(line)
+		}
+	}
+
+	return lock
+}
+
+// RubyEnv returns the environment that runs Ruby tools under rbenv, which picks the Ruby version from the
+// project's .ruby-version, rather than under the system Ruby.
+func RubyEnv() []string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	shims := filepath.Join(homeDir, ".rbenv", "shims")
+	if _, err := os.Stat(shims); err != nil {
+		return nil
+	}
+	return []string{"PATH=" + shims + string(os.PathListSeparator) + os.Getenv("PATH")}
+}
+
+// PodCommand returns how to run CocoaPods for the project: through Bundler when the project has a Gemfile,
+// so that the CocoaPods version it pins is used, and directly otherwise. Both run under the rbenv Ruby.
+func PodCommand(projectDir string) (string, []string, []string) {
+	env := RubyEnv()
+	if gemfile := FindGemfile(projectDir); gemfile != "" {
+		return "bundle", []string{"exec", "pod"}, append(env, "BUNDLE_GEMFILE="+gemfile)
+	}
+	return "pod", nil, env
+}
+
+// CocoapodsVersion returns the version of CocoaPods the project runs.
+func CocoapodsVersion(projectDir string) (string, error) {
+	command, args, env := PodCommand(projectDir)
+	output, err := utils.RunCommandInDirWithOutput(projectDir, env, command, append(args, "--version")...)
+	if err != nil {
+		return "", fmt.Errorf("failed to get CocoaPods version: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), nil
+}
+
+// PinnedCocoapodsVersion returns the CocoaPods version the project's Gemfile.lock pins, or "" without one.
+func PinnedCocoapodsVersion(projectDir string) string {
+	gemfile := FindGemfile(projectDir)
+	if gemfile == "" {
+		return ""
+	}
+	lock, err := LoadGemfileLock(gemfile + ".lock")
+	if err != nil {
+		return ""
+	}
+	return lock.Gems["cocoapods"]
+}
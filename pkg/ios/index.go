@@ -0,0 +1,285 @@
+package ios
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Project describes the parts of an Xcode project that bob cares about.
+type Project struct {
+	Path    string
+	Name    string
+	Targets []Target
+	Schemes []string
+
+	// Configurations holds the project-level build configurations, which targets inherit from.
+	Configurations []BuildConfiguration
+}
+
+// Target is a native target of an Xcode project.
+type Target struct {
+	Name           string
+	ProductType    string
+	Configurations []BuildConfiguration
+}
+
+// BuildConfiguration is a named set of build settings such as Debug or Release.
+type BuildConfiguration struct {
+	Name          string
+	BuildSettings map[string]string
+}
+
+const applicationProductType = "com.apple.product-type.application"
+
+// FindXcodeProject returns the path of the first .xcodeproj inside the given iOS directory.
+func FindXcodeProject(iosDir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(iosDir, "*.xcodeproj"))
+	if err != nil {
+		return "", err
+	}
+	for _, match := range matches {
+		if filepath.Base(match) != "Pods.xcodeproj" {
+			return match, nil
+		}
+	}
+
+	return "", fmt.Errorf("no Xcode project found in %s", iosDir)
+}
+
+// LoadProject parses the project.pbxproj of the given .xcodeproj along with its shared schemes.
+func LoadProject(xcodeprojPath string) (*Project, error) {
+	data, err := os.ReadFile(filepath.Join(xcodeprojPath, "project.pbxproj"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project.pbxproj: %v", err)
+	}
+
+	project, err := ParsePbxproj(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", xcodeprojPath, err)
+	}
+	project.Path = xcodeprojPath
+	project.Name = strings.TrimSuffix(filepath.Base(xcodeprojPath), ".xcodeproj")
+
+	schemes, err := filepath.Glob(filepath.Join(xcodeprojPath, "xcshareddata", "xcschemes", "*.xcscheme"))
+	if err != nil {
+		return nil, err
+	}
+	for _, scheme := range schemes {
+		project.Schemes = append(project.Schemes, strings.TrimSuffix(filepath.Base(scheme), ".xcscheme"))
+	}
+	sort.Strings(project.Schemes)
+
+	return project, nil
+}
+
+// ParsePbxproj builds a Project from the contents of a project.pbxproj file.
+func ParsePbxproj(data []byte) (*Project, error) {
+	root, err := ParseOldStylePlist(data)
+	if err != nil {
+		return nil, err
+	}
+
+	rootDict, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("pbxproj root is not a dictionary")
+	}
+	objects, ok := rootDict["objects"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("pbxproj has no objects")
+	}
+	pbxProject, ok := objects[stringValue(rootDict["rootObject"])].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("pbxproj has no root object")
+	}
+
+	project := &Project{
+		Configurations: configurationList(objects, stringValue(pbxProject["buildConfigurationList"])),
+	}
+
+	targetIDs, _ := pbxProject["targets"].([]interface{})
+	for _, id := range targetIDs {
+		target, ok := objects[stringValue(id)].(map[string]interface{})
+		if !ok || stringValue(target["isa"]) != "PBXNativeTarget" {
+			continue
+		}
+
+		project.Targets = append(project.Targets, Target{
+			Name:           stringValue(target["name"]),
+			ProductType:    stringValue(target["productType"]),
+			Configurations: configurationList(objects, stringValue(target["buildConfigurationList"])),
+		})
+	}
+
+	return project, nil
+}
+
+// MainTarget returns the first application target of the project.
+func (p *Project) MainTarget() *Target {
+	for i := range p.Targets {
+		if p.Targets[i].ProductType == applicationProductType {
+			return &p.Targets[i]
+		}
+	}
+
+	return nil
+}
+
+// Setting returns a build setting of the given target and configuration, falling back to the project-level value.
+func (p *Project) Setting(target *Target, configuration, key string) string {
+	if target != nil {
+		if value := findSetting(target.Configurations, configuration, key); value != "" {
+			return value
+		}
+	}
+
+	return findSetting(p.Configurations, configuration, key)
+}
+
+// BundleIdentifier returns the bundle identifier of the main target for the given configuration.
+func (p *Project) BundleIdentifier(configuration string) string {
+	return p.Setting(p.MainTarget(), configuration, "PRODUCT_BUNDLE_IDENTIFIER")
+}
+
+// ResolvedBundleIdentifier returns the bundle identifier of the main target with the build settings it refers to
+// expanded, e.g. org.reactjs.native.example.HelloWorld for the React Native template's
+// org.reactjs.native.example.$(PRODUCT_NAME:rfc1034identifier). It reports false when a reference cannot be resolved.
+func (p *Project) ResolvedBundleIdentifier(configuration string) (string, bool) {
+	target := p.MainTarget()
+	return p.ResolveSetting(target, configuration, p.Setting(target, configuration, "PRODUCT_BUNDLE_IDENTIFIER"))
+}
+
+// ResolveSetting expands the $(NAME) and ${NAME} references in value with the build settings of the target and
+// configuration, applying the rfc1034identifier, c99extidentifier, lower and upper modifiers. It reports false
+// when a reference names a setting that is not in the project, such as one that only Xcode or an .xcconfig sets.
+func (p *Project) ResolveSetting(target *Target, configuration, value string) (string, bool) {
+	return p.resolveSetting(target, configuration, value, 0)
+}
+
+func (p *Project) resolveSetting(target *Target, configuration, value string, depth int) (string, bool) {
+	if depth > 10 {
+		return "", false
+	}
+
+	resolved := true
+	value = settingReferenceRegexp.ReplaceAllStringFunc(value, func(reference string) string {
+		match := settingReferenceRegexp.FindStringSubmatch(reference)
+		name, modifier := match[1]+match[3], match[2]+match[4]
+
+		var setting string
+		switch {
+		case name == "inherited":
+			return ""
+		case name == "TARGET_NAME" && target != nil:
+			setting = target.Name
+		case name == "PROJECT_NAME":
+			setting = p.Name
+		default:
+			setting = p.Setting(target, configuration, name)
+			// Xcode names the product after the target unless PRODUCT_NAME says otherwise.
+			if setting == "" && name == "PRODUCT_NAME" && target != nil {
+				setting = target.Name
+			}
+		}
+		if setting == "" {
+			resolved = false
+			return reference
+		}
+
+		setting, ok := p.resolveSetting(target, configuration, setting, depth+1)
+		resolved = resolved && ok
+		return applySettingModifier(setting, strings.TrimPrefix(modifier, ":"))
+	})
+
+	return strings.TrimSpace(value), resolved
+}
+
+var (
+	settingReferenceRegexp = regexp.MustCompile(`\$\((\w+)(:[\w,]+)?\)|\$\{(\w+)(:[\w,]+)?\}`)
+	rfc1034Regexp          = regexp.MustCompile(`[^A-Za-z0-9.-]`)
+	c99ExtRegexp           = regexp.MustCompile(`[^A-Za-z0-9_]`)
+)
+
+func applySettingModifier(value, modifiers string) string {
+	for _, modifier := range strings.Split(modifiers, ",") {
+		switch modifier {
+		case "rfc1034identifier":
+			value = rfc1034Regexp.ReplaceAllString(value, "-")
+		case "c99extidentifier":
+			value = c99ExtRegexp.ReplaceAllString(value, "_")
+		case "lower":
+			value = strings.ToLower(value)
+		case "upper":
+			value = strings.ToUpper(value)
+		}
+	}
+	return value
+}
+
+// DeploymentTarget returns the iOS deployment target of the main target for the given configuration.
+func (p *Project) DeploymentTarget(configuration string) string {
+	return p.Setting(p.MainTarget(), configuration, "IPHONEOS_DEPLOYMENT_TARGET")
+}
+
+// MarketingVersion returns the marketing version of the main target for the given configuration.
+func (p *Project) MarketingVersion(configuration string) string {
+	return p.Setting(p.MainTarget(), configuration, "MARKETING_VERSION")
+}
+
+func findSetting(configurations []BuildConfiguration, configuration, key string) string {
+	for _, c := range configurations {
+		if c.Name == configuration {
+			return c.BuildSettings[key]
+		}
+	}
+
+	return ""
+}
+
+func configurationList(objects map[string]interface{}, id string) []BuildConfiguration {
+	list, ok := objects[id].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var configurations []BuildConfiguration
+	ids, _ := list["buildConfigurations"].([]interface{})
+	for _, configID := range ids {
+		config, ok := objects[stringValue(configID)].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		settings := map[string]string{}
+		rawSettings, _ := config["buildSettings"].(map[string]interface{})
+		for key, value := range rawSettings {
+			settings[key] = stringValue(value)
+		}
+
+		configurations = append(configurations, BuildConfiguration{
+			Name:          stringValue(config["name"]),
+			BuildSettings: settings,
+		})
+	}
+
+	return configurations
+}
+
+// stringValue flattens a plist value into a string, joining arrays with spaces.
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, stringValue(item))
+		}
+		return strings.Join(parts, " ")
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,104 @@
+package ios
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadProject(t *testing.T) {
+	project, err := LoadProject(filepath.Join("testdata", "HelloWorld.xcodeproj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if project.Name != "HelloWorld" {
+		t.Errorf("Name = %q, want HelloWorld", project.Name)
+	}
+	if want := []string{"HelloWorld"}; !reflect.DeepEqual(project.Schemes, want) {
+		t.Errorf("Schemes = %q, want %q", project.Schemes, want)
+	}
+
+	var targets []string
+	for _, target := range project.Targets {
+		targets = append(targets, target.Name)
+	}
+	if want := []string{"HelloWorld", "HelloWorldTests"}; !reflect.DeepEqual(targets, want) {
+		t.Errorf("Targets = %q, want %q", targets, want)
+	}
+	if target := project.MainTarget(); target == nil || target.Name != "HelloWorld" {
+		t.Fatalf("MainTarget() = %v, want HelloWorld", target)
+	}
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"BundleIdentifier", project.BundleIdentifier("Release"), "org.reactjs.native.example.$(PRODUCT_NAME:rfc1034identifier)"},
+		{"DeploymentTarget", project.DeploymentTarget("Release"), "13.4"},
+		{"MarketingVersion", project.MarketingVersion("Debug"), "1.0"},
+		{"target setting", project.Setting(project.MainTarget(), "Debug", "PRODUCT_NAME"), "HelloWorld"},
+		{"project setting", project.Setting(project.MainTarget(), "Release", "SDKROOT"), "iphoneos"},
+		{"array setting", project.Setting(project.MainTarget(), "Release", "OTHER_LDFLAGS"), "$(inherited) -ObjC -lc++"},
+		{"quoted key", project.Setting(nil, "Debug", "CODE_SIGN_IDENTITY[sdk=iphoneos*]"), "iPhone Developer"},
+		{"unknown configuration", project.Setting(project.MainTarget(), "Staging", "PRODUCT_NAME"), ""},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %q, want %q", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestParsePbxprojErrors(t *testing.T) {
+	tests := map[string]string{
+		"not a dictionary": "( a, b )",
+		"no objects":       "{ rootObject = A; }",
+		"no root object":   "{ objects = { }; rootObject = A; }",
+		"syntax error":     "{ objects = ",
+	}
+
+	for name, input := range tests {
+		if _, err := ParsePbxproj([]byte(input)); err == nil {
+			t.Errorf("%s: ParsePbxproj() succeeded, want an error", name)
+		}
+	}
+}
+
+func TestResolveSetting(t *testing.T) {
+	project, err := LoadProject(filepath.Join("testdata", "HelloWorld.xcodeproj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := project.MainTarget()
+	unitTests := &project.Targets[1]
+
+	cases := []struct {
+		target *Target
+		value  string
+		want   string
+		ok     bool
+	}{
+		{app, "org.reactjs.native.example.$(PRODUCT_NAME:rfc1034identifier)", "org.reactjs.native.example.HelloWorld", true},
+		{unitTests, "org.reactjs.native.example.$(PRODUCT_NAME:rfc1034identifier)", "org.reactjs.native.example.HelloWorldTests", true},
+		{app, "com.acme.${TARGET_NAME:lower}", "com.acme.helloworld", true},
+		{app, "$(PROJECT_NAME:upper)", "HELLOWORLD", true},
+		{app, "$(inherited) -ObjC", "-ObjC", true},
+		{app, "$(SRCROOT)/HelloWorld", "$(SRCROOT)/HelloWorld", false},
+		{app, "plain", "plain", true},
+	}
+	for _, c := range cases {
+		got, ok := project.ResolveSetting(c.target, "Release", c.value)
+		if got != c.want || ok != c.ok {
+			t.Errorf("ResolveSetting(%s, %q) = %q, %v, want %q, %v", c.target.Name, c.value, got, ok, c.want, c.ok)
+		}
+	}
+
+	if got := applySettingModifier("My App+1", "rfc1034identifier"); got != "My-App-1" {
+		t.Errorf("rfc1034identifier of %q = %q, want My-App-1", "My App+1", got)
+	}
+	if got := applySettingModifier("My App-1", "c99extidentifier"); got != "My_App_1" {
+		t.Errorf("c99extidentifier of %q = %q, want My_App_1", "My App-1", got)
+	}
+}
@@ -0,0 +1,212 @@
+package ios
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseOldStylePlist parses an OpenStep (old-style) property list such as a project.pbxproj file.
+// Dictionaries are returned as map[string]interface{}, arrays as []interface{} and everything else as string.
+func ParseOldStylePlist(data []byte) (interface{}, error) {
+	p := &plistParser{data: data}
+
+	// Skip the optional "// !$*UTF8*$!" encoding marker along with any other leading comments.
+	p.skipWhitespace()
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipWhitespace()
+	if p.pos < len(p.data) {
+		return nil, p.errorf("unexpected trailing data")
+	}
+
+	return value, nil
+}
+
+type plistParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *plistParser) errorf(format string, args ...interface{}) error {
+	line := 1 + strings.Count(string(p.data[:p.pos]), "\n")
+	return fmt.Errorf("plist: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *plistParser) skipWhitespace() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case c == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '/':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '/' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '*':
+			end := strings.Index(string(p.data[p.pos+2:]), "*/")
+			if end < 0 {
+				p.pos = len(p.data)
+				return
+			}
+			p.pos += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func (p *plistParser) parseValue() (interface{}, error) {
+	p.skipWhitespace()
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of data")
+	}
+
+	switch p.data[p.pos] {
+	case '{':
+		return p.parseDict()
+	case '(':
+		return p.parseArray()
+	case '"', '\'':
+		return p.parseQuotedString()
+	case '<':
+		return p.parseData()
+	default:
+		return p.parseUnquotedString()
+	}
+}
+
+func (p *plistParser) parseDict() (map[string]interface{}, error) {
+	dict := map[string]interface{}{}
+	p.pos++ // consume '{'
+
+	for {
+		p.skipWhitespace()
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unterminated dictionary")
+		}
+		if p.data[p.pos] == '}' {
+			p.pos++
+			return dict, nil
+		}
+
+		key, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		keyString, ok := key.(string)
+		if !ok {
+			return nil, p.errorf("dictionary key must be a string")
+		}
+
+		p.skipWhitespace()
+		if p.pos >= len(p.data) || p.data[p.pos] != '=' {
+			return nil, p.errorf("expected '=' after key %q", keyString)
+		}
+		p.pos++
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		dict[keyString] = value
+
+		p.skipWhitespace()
+		if p.pos >= len(p.data) || p.data[p.pos] != ';' {
+			return nil, p.errorf("expected ';' after value of %q", keyString)
+		}
+		p.pos++
+	}
+}
+
+func (p *plistParser) parseArray() ([]interface{}, error) {
+	array := []interface{}{}
+	p.pos++ // consume '('
+
+	for {
+		p.skipWhitespace()
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.data[p.pos] == ')' {
+			p.pos++
+			return array, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+
+		p.skipWhitespace()
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+		} else if p.pos < len(p.data) && p.data[p.pos] != ')' {
+			return nil, p.errorf("expected ',' or ')' in array")
+		}
+	}
+}
+
+func (p *plistParser) parseQuotedString() (string, error) {
+	quote := p.data[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\\' && p.pos+1 < len(p.data):
+			p.pos++
+			switch e := p.data[p.pos]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			default:
+				sb.WriteByte(e)
+			}
+			p.pos++
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+func (p *plistParser) parseUnquotedString() (string, error) {
+	start := p.pos
+	for p.pos < len(p.data) && isUnquotedChar(p.data[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("unexpected character %q", p.data[p.pos])
+	}
+
+	return string(p.data[start:p.pos]), nil
+}
+
+func (p *plistParser) parseData() (string, error) {
+	end := strings.IndexByte(string(p.data[p.pos:]), '>')
+	if end < 0 {
+		return "", p.errorf("unterminated data")
+	}
+
+	value := string(p.data[p.pos : p.pos+end+1])
+	p.pos += end + 1
+	return value, nil
+}
+
+func isUnquotedChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') ||
+		strings.IndexByte("_$/:.-+", c) >= 0
+}
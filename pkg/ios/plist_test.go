@@ -0,0 +1,85 @@
+package ios
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOldStylePlist(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  interface{}
+	}{
+		{
+			name:  "dictionary with comments",
+			input: "// !$*UTF8*$!\n{ a = b; /* note */ c = \"d e\"; }",
+			want:  map[string]interface{}{"a": "b", "c": "d e"},
+		},
+		{
+			name:  "array with trailing comma",
+			input: "( one, \"two\", three, )",
+			want:  []interface{}{"one", "two", "three"},
+		},
+		{
+			name:  "nested values",
+			input: "{ list = ( { x = 1; } ); empty = { }; }",
+			want: map[string]interface{}{
+				"list":  []interface{}{map[string]interface{}{"x": "1"}},
+				"empty": map[string]interface{}{},
+			},
+		},
+		{
+			name:  "escapes in quoted strings",
+			input: `{ script = "echo \"hi\"\n"; single = 'it\'s'; }`,
+			want:  map[string]interface{}{"script": "echo \"hi\"\n", "single": "it's"},
+		},
+		{
+			name:  "quoted keys and unquoted paths",
+			input: `{ "CODE_SIGN_IDENTITY[sdk=iphoneos*]" = "iPhone Developer"; path = HelloWorld/main.m; }`,
+			want:  map[string]interface{}{"CODE_SIGN_IDENTITY[sdk=iphoneos*]": "iPhone Developer", "path": "HelloWorld/main.m"},
+		},
+		{
+			name:  "data",
+			input: "{ data = <0fbd 7770>; }",
+			want:  map[string]interface{}{"data": "<0fbd 7770>"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseOldStylePlist([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseOldStylePlist() = %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseOldStylePlistErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"{ a = b; ", "unterminated dictionary"},
+		{"{ a = b }", `expected ';' after value of "a"`},
+		{"{ a b; }", `expected '=' after key "a"`},
+		{"( a b )", "expected ',' or ')'"},
+		{"{ a = \"b; }", "unterminated string"},
+		{"{ a = b; } }", "unexpected trailing data"},
+		{"{\n\n a = ; }", "line 3: unexpected character ';'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			_, err := ParseOldStylePlist([]byte(test.input))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("ParseOldStylePlist() error = %v, want %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package ios
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Podfile holds the settings bob reads from an ios/Podfile.
+type Podfile struct {
+	// Platform is the minimum iOS version declared with `platform :ios, '...'`.
+	// It is empty when the Podfile computes the version instead of using a literal.
+	Platform string
+}
+
+// PodfileLock holds the contents of a Podfile.lock (or Pods/Manifest.lock).
+type PodfileLock struct {
+	CocoapodsVersion string
+	PodfileChecksum  string
+	Pods             map[string]string
+}
+
+var podfilePlatformRegexp = regexp.MustCompile(`(?m)^\s*platform\s+:ios\s*,\s*['"]([^'"]+)['"]`)
+
+// podEntryRegexp matches a resolved pod such as `  - React-Core (0.74.1):`. Some entries are quoted as a
+// whole, e.g. `  - "RCT-Folly (2024.01.01.00)":`.
+var podEntryRegexp = regexp.MustCompile(`^  - "?([^\s"]+) \(([^)]+)\)"?:?$`)
+
+// LoadPodfile reads and parses the Podfile at the given path.
+func LoadPodfile(path string) (*Podfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Podfile: %v", err)
+	}
+
+	return ParsePodfile(data), nil
+}
+
+// ParsePodfile extracts the settings bob needs from the contents of a Podfile.
+func ParsePodfile(data []byte) *Podfile {
+	podfile := &Podfile{}
+	if match := podfilePlatformRegexp.FindSubmatch(data); match != nil {
+		podfile.Platform = string(match[1])
+	}
+
+	return podfile
+}
+
+// LoadPodfileLock reads and parses the Podfile.lock at the given path.
+func LoadPodfileLock(path string) (*PodfileLock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	return ParsePodfileLock(data), nil
+}
+
+// ParsePodfileLock extracts the resolved pods and the CocoaPods version from the contents of a Podfile.lock.
+func ParsePodfileLock(data []byte) *PodfileLock {
+	lock := &PodfileLock{Pods: map[string]string{}}
+
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") {
+			key, value, _ := strings.Cut(line, ":")
+			section = key
+			switch key {
+			case "COCOAPODS":
+				lock.CocoapodsVersion = strings.TrimSpace(value)
+			case "PODFILE CHECKSUM":
+				lock.PodfileChecksum = strings.TrimSpace(value)
+			}
+			continue
+		}
+
+		if section == "PODS" {
+			if match := podEntryRegexp.FindStringSubmatch(line); match != nil {
+				lock.Pods[match[1]] = match[2]
+			}
+		}
+	}
+
+	return lock
+}
@@ -0,0 +1,58 @@
+package ios
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPodfile(t *testing.T) {
+	podfile, err := LoadPodfile(filepath.Join("testdata", "Podfile"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if podfile.Platform != "13.4" {
+		t.Errorf("Platform = %q, want 13.4", podfile.Platform)
+	}
+}
+
+func TestParsePodfile(t *testing.T) {
+	tests := map[string]string{
+		`platform :ios, "15.0"`:                       "15.0",
+		"  platform :ios,'12.4'":                      "12.4",
+		"platform :ios, min_ios_version_supported":    "",
+		"# platform :ios, '9.0'\nplatform :osx, '11'": "",
+	}
+
+	for input, want := range tests {
+		if got := ParsePodfile([]byte(input)).Platform; got != want {
+			t.Errorf("ParsePodfile(%q).Platform = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestLoadPodfileLock(t *testing.T) {
+	lock, err := LoadPodfileLock(filepath.Join("testdata", "Podfile.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lock.CocoapodsVersion != "1.15.2" {
+		t.Errorf("CocoapodsVersion = %q, want 1.15.2", lock.CocoapodsVersion)
+	}
+	if lock.PodfileChecksum != "0e23d9d1b8d2e4c1bd80c2d3e7c5d2f1a9f3b6c7" {
+		t.Errorf("PodfileChecksum = %q", lock.PodfileChecksum)
+	}
+	want := map[string]string{
+		"boost":                   "1.83.0",
+		"DoubleConversion":        "1.1.6",
+		"FBLazyVector":            "0.74.1",
+		"hermes-engine":           "0.74.1",
+		"hermes-engine/Pre-built": "0.74.1",
+		"RCT-Folly":               "2024.01.01.00",
+		"React-Core":              "0.74.1",
+	}
+	if !reflect.DeepEqual(lock.Pods, want) {
+		t.Errorf("Pods = %v, want %v", lock.Pods, want)
+	}
+}
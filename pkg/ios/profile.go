@@ -0,0 +1,282 @@
+package ios
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// ProvisioningProfile is the decoded payload of a .mobileprovision file.
+type ProvisioningProfile struct {
+	Path                  string
+	Name                  string
+	UUID                  string
+	TeamID                string
+	TeamName              string
+	AppIDName             string
+	ApplicationIdentifier string
+	CreationDate          time.Time
+	ExpirationDate        time.Time
+	Entitlements          map[string]interface{}
+	ProvisionedDevices    []string
+	ProvisionsAllDevices  bool
+	Certificates          []*x509.Certificate
+}
+
+// signedData mirrors the parts of a CMS SignedData structure (RFC 5652) needed to reach its content.
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	ContentInfo      struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+	}
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// DefaultProfileDirs returns the directories where Xcode keeps installed provisioning profiles.
+func DefaultProfileDirs(homeDir string) []string {
+	return []string{
+		filepath.Join(homeDir, "Library", "MobileDevice", "Provisioning Profiles"),
+		filepath.Join(homeDir, "Library", "Developer", "Xcode", "UserData", "Provisioning Profiles"),
+	}
+}
+
+// LoadProfiles decodes every .mobileprovision file found in the given directories, sorted by expiry date.
+// Files that cannot be decoded are reported through the returned errors and skipped.
+func LoadProfiles(dirs ...string) ([]*ProvisioningProfile, []error) {
+	var profiles []*ProvisioningProfile
+	var errs []error
+
+	for _, dir := range dirs {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.mobileprovision"))
+		for _, match := range matches {
+			profile, err := LoadProfile(match)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			profiles = append(profiles, profile)
+		}
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].ExpirationDate.After(profiles[j].ExpirationDate)
+	})
+
+	return profiles, errs
+}
+
+// LoadProfile reads and decodes a single .mobileprovision file.
+func LoadProfile(path string) (*ProvisioningProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	profile, err := ParseProfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", filepath.Base(path), err)
+	}
+	profile.Path = path
+
+	return profile, nil
+}
+
+// ParseProfile decodes the contents of a .mobileprovision file.
+func ParseProfile(data []byte) (*ProvisioningProfile, error) {
+	payload, err := cmsContent(data)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := ParseXMLPlist(payload)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("profile payload is not a dictionary")
+	}
+
+	profile := &ProvisioningProfile{
+		Name:      plistString(dict["Name"]),
+		UUID:      plistString(dict["UUID"]),
+		TeamName:  plistString(dict["TeamName"]),
+		AppIDName: plistString(dict["AppIDName"]),
+	}
+	if teams := plistStrings(dict["TeamIdentifier"]); len(teams) > 0 {
+		profile.TeamID = teams[0]
+	}
+	profile.CreationDate, _ = dict["CreationDate"].(time.Time)
+	profile.ExpirationDate, _ = dict["ExpirationDate"].(time.Time)
+	profile.Entitlements, _ = dict["Entitlements"].(map[string]interface{})
+	profile.ProvisionedDevices = plistStrings(dict["ProvisionedDevices"])
+	profile.ProvisionsAllDevices, _ = dict["ProvisionsAllDevices"].(bool)
+	profile.ApplicationIdentifier = plistString(profile.Entitlements["application-identifier"])
+
+	certificates, _ := dict["DeveloperCertificates"].([]interface{})
+	for _, raw := range certificates {
+		der, ok := raw.([]byte)
+		if !ok {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(der); err == nil {
+			profile.Certificates = append(profile.Certificates, cert)
+		}
+	}
+
+	return profile, nil
+}
+
+// cmsContent extracts the signed content of a CMS (PKCS#7) envelope.
+// Some profiles are BER-encoded, which encoding/asn1 rejects, so the XML payload is also searched for directly.
+func cmsContent(data []byte) ([]byte, error) {
+	var info contentInfo
+	if _, err := asn1.Unmarshal(data, &info); err == nil {
+		var sd signedData
+		if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err == nil {
+			var content []byte
+			if _, err := asn1.Unmarshal(sd.ContentInfo.Content.Bytes, &content); err == nil {
+				return content, nil
+			}
+		}
+	}
+
+	start := bytes.Index(data, []byte("<?xml"))
+	end := bytes.LastIndex(data, []byte("</plist>"))
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no property list found in CMS envelope")
+	}
+
+	return data[start : end+len("</plist>")], nil
+}
+
+// BundleIdentifier returns the application identifier without the team prefix, e.g. io.apptile.* .
+func (p *ProvisioningProfile) BundleIdentifier() string {
+	_, bundleID, found := strings.Cut(p.ApplicationIdentifier, ".")
+	if !found {
+		return p.ApplicationIdentifier
+	}
+	return bundleID
+}
+
+// Matches reports whether the profile can sign the given bundle identifier, honouring wildcard app IDs.
+func (p *ProvisioningProfile) Matches(bundleID string) bool {
+	pattern := p.BundleIdentifier()
+	if pattern == "*" {
+		return true
+	}
+	if strings.HasSuffix(pattern, ".*") {
+		return strings.HasPrefix(bundleID, strings.TrimSuffix(pattern, "*"))
+	}
+	return pattern == bundleID
+}
+
+// Expired reports whether the profile has expired at the given time.
+func (p *ProvisioningProfile) Expired(now time.Time) bool {
+	return !p.ExpirationDate.IsZero() && now.After(p.ExpirationDate)
+}
+
+// ExportMethod returns the distribution method the profile was created for.
+func (p *ProvisioningProfile) ExportMethod() string {
+	switch {
+	case p.Entitlements["get-task-allow"] == true:
+		return "development"
+	case p.ProvisionsAllDevices:
+		return "enterprise"
+	case len(p.ProvisionedDevices) > 0:
+		return "ad-hoc"
+	default:
+		return "app-store"
+	}
+}
+
+// CertificateFingerprint returns the upper-case SHA-1 fingerprint of a certificate, as printed by the security tool.
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha1.Sum(cert.Raw)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+var codesigningIdentityRegexp = regexp.MustCompile(`^\s*\d+\) ([0-9A-F]{40}) "(.+)"`)
+
+// CodesigningIdentities returns the valid code signing identities in the keychain, keyed by SHA-1 fingerprint.
+func CodesigningIdentities() (map[string]string, error) {
+	output, err := utils.RunCommandWithOutput("security", "find-identity", "-v", "-p", "codesigning")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list code signing identities: %v", err)
+	}
+
+	return ParseCodesigningIdentities(output), nil
+}
+
+// ParseCodesigningIdentities parses the output of `security find-identity -v -p codesigning`.
+func ParseCodesigningIdentities(output string) map[string]string {
+	identities := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		if match := codesigningIdentityRegexp.FindStringSubmatch(line); match != nil {
+			identities[match[1]] = match[2]
+		}
+	}
+
+	return identities
+}
+
+func plistString(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+func plistStrings(value interface{}) []string {
+	array, _ := value.([]interface{})
+	strs := make([]string, 0, len(array))
+	for _, item := range array {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+
+	return strs
+}
+
+// SelectProfile returns the newest unexpired profile that can sign the bundle identifier for the given team and
+// export method. Empty team or method values match any profile.
+func SelectProfile(profiles []*ProvisioningProfile, bundleID, teamID, method string, now time.Time) (*ProvisioningProfile, error) {
+	candidates := 0
+	for _, profile := range profiles {
+		if !profile.Matches(bundleID) {
+			continue
+		}
+		if teamID != "" && profile.TeamID != teamID {
+			continue
+		}
+		if method != "" && profile.ExportMethod() != method {
+			continue
+		}
+
+		candidates++
+		if !profile.Expired(now) {
+			return profile, nil
+		}
+	}
+
+	if candidates > 0 {
+		return nil, fmt.Errorf("all %d matching provisioning profiles for %s have expired", candidates, bundleID)
+	}
+	return nil, fmt.Errorf("no provisioning profile matches %s", bundleID)
+}
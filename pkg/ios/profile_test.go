@@ -0,0 +1,156 @@
+package ios
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// now is a fixed point in time at which the app-store fixture profile has expired and the others have not.
+var now = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func loadFixtureProfiles(t *testing.T) []*ProvisioningProfile {
+	t.Helper()
+	profiles, errs := LoadProfiles(filepath.Join("testdata", "profiles"))
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "broken.mobileprovision") {
+		t.Fatalf("LoadProfiles() errors = %v, want one for broken.mobileprovision", errs)
+	}
+	if len(profiles) != 4 {
+		t.Fatalf("LoadProfiles() returned %d profiles, want 4", len(profiles))
+	}
+	return profiles
+}
+
+func TestLoadProfiles(t *testing.T) {
+	profiles := loadFixtureProfiles(t)
+
+	tests := []struct {
+		name     string
+		bundleID string
+		method   string
+		expired  bool
+		devices  int
+	}{
+		// Sorted by expiry date, newest first.
+		{"Acme Enterprise", "com.acme.*", "enterprise", false, 0},
+		{"Example Ad Hoc", "org.reactjs.native.example.*", "ad-hoc", false, 2},
+		{"HelloWorld Development", "org.reactjs.native.example.HelloWorld", "development", false, 2},
+		{"HelloWorld App Store", "org.reactjs.native.example.HelloWorld", "app-store", true, 0},
+	}
+	for i, test := range tests {
+		profile := profiles[i]
+		if profile.Name != test.name {
+			t.Errorf("profiles[%d].Name = %q, want %q", i, profile.Name, test.name)
+			continue
+		}
+		if got := profile.BundleIdentifier(); got != test.bundleID {
+			t.Errorf("%s: BundleIdentifier() = %q, want %q", test.name, got, test.bundleID)
+		}
+		if got := profile.ExportMethod(); got != test.method {
+			t.Errorf("%s: ExportMethod() = %q, want %q", test.name, got, test.method)
+		}
+		if got := profile.Expired(now); got != test.expired {
+			t.Errorf("%s: Expired() = %v, want %v", test.name, got, test.expired)
+		}
+		if len(profile.ProvisionedDevices) != test.devices {
+			t.Errorf("%s: %d provisioned devices, want %d", test.name, len(profile.ProvisionedDevices), test.devices)
+		}
+		if profile.TeamID != "ABCDE12345" || profile.TeamName != "Acme Inc" {
+			t.Errorf("%s: team = %q (%s), want Acme Inc (ABCDE12345)", test.name, profile.TeamName, profile.TeamID)
+		}
+		if len(profile.Certificates) != 1 {
+			t.Errorf("%s: %d certificates, want 1", test.name, len(profile.Certificates))
+		}
+	}
+}
+
+func TestProfileMatches(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		bundleID string
+		want     bool
+	}{
+		{"TEAM.com.acme.app", "com.acme.app", true},
+		{"TEAM.com.acme.app", "com.acme.app.widget", false},
+		{"TEAM.com.acme.*", "com.acme.app", true},
+		{"TEAM.com.acme.*", "com.acmecorp.app", false},
+		{"TEAM.*", "org.example", true},
+	}
+
+	for _, test := range tests {
+		profile := &ProvisioningProfile{ApplicationIdentifier: test.pattern}
+		if got := profile.Matches(test.bundleID); got != test.want {
+			t.Errorf("%s Matches(%q) = %v, want %v", test.pattern, test.bundleID, got, test.want)
+		}
+	}
+}
+
+func TestSelectProfile(t *testing.T) {
+	profiles := loadFixtureProfiles(t)
+	bundleID := "org.reactjs.native.example.HelloWorld"
+
+	tests := []struct {
+		bundleID, team, method string
+		want                   string
+		err                    string
+	}{
+		{bundleID: bundleID, want: "Example Ad Hoc"},
+		{bundleID: bundleID, method: "development", want: "HelloWorld Development"},
+		{bundleID: bundleID, team: "ABCDE12345", method: "ad-hoc", want: "Example Ad Hoc"},
+		{bundleID: "com.acme.shop", want: "Acme Enterprise"},
+		{bundleID: bundleID, method: "app-store", err: "all 1 matching provisioning profiles"},
+		{bundleID: bundleID, team: "ZZZZZ99999", err: "no provisioning profile matches"},
+		{bundleID: "com.globex.app", err: "no provisioning profile matches"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.bundleID+"/"+test.method, func(t *testing.T) {
+			profile, err := SelectProfile(profiles, test.bundleID, test.team, test.method, now)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("SelectProfile() error = %v, want %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profile.Name != test.want {
+				t.Errorf("SelectProfile() = %q, want %q", profile.Name, test.want)
+			}
+		})
+	}
+}
+
+// TestDoctorFixture runs the checks of bob ios signing doctor against the React Native template project.
+func TestDoctorFixture(t *testing.T) {
+	project, err := LoadProject(filepath.Join("testdata", "HelloWorld.xcodeproj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundleID, resolved := project.ResolvedBundleIdentifier("Release")
+	if !resolved {
+		t.Fatalf("ResolvedBundleIdentifier() did not resolve %q", bundleID)
+	}
+
+	profile, err := SelectProfile(loadFixtureProfiles(t), bundleID, "", "development", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := os.ReadFile(filepath.Join("testdata", "find-identity.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	identities := ParseCodesigningIdentities(string(output))
+	if len(identities) != 2 {
+		t.Fatalf("ParseCodesigningIdentities() = %v, want 2 identities", identities)
+	}
+
+	name := identities[CertificateFingerprint(profile.Certificates[0])]
+	if name != "Apple Development: Jane Doe (ABCDE12345)" {
+		t.Errorf("signing identity of %q = %q, want the development certificate", profile.Name, name)
+	}
+}
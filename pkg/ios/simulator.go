@@ -0,0 +1,195 @@
+package ios
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+const runtimePrefix = "com.apple.CoreSimulator.SimRuntime."
+
+// Simulator is an iOS simulator known to simctl.
+type Simulator struct {
+	UDID  string
+	Name  string
+	State string
+	// Runtime is the iOS version of the simulator, e.g. 17.0.
+	Runtime   string
+	Available bool
+}
+
+// Booted reports whether the simulator is running.
+func (s Simulator) Booted() bool {
+	return s.State == "Booted"
+}
+
+func (s Simulator) String() string {
+	return fmt.Sprintf("%s (iOS %s, %s)", s.Name, s.Runtime, s.UDID)
+}
+
+// ParseSimulators parses the output of xcrun simctl list devices --json into the iOS simulators, newest runtime
+// first. Simulators of other platforms, such as watchOS, are left out.
+func ParseSimulators(data []byte) ([]Simulator, error) {
+	var list struct {
+		Devices map[string][]struct {
+			UDID        string `json:"udid"`
+			Name        string `json:"name"`
+			State       string `json:"state"`
+			IsAvailable bool   `json:"isAvailable"`
+		} `json:"devices"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse the simctl device list: %v", err)
+	}
+
+	var simulators []Simulator
+	for runtime, devices := range list.Devices {
+		version, ok := strings.CutPrefix(runtime, runtimePrefix+"iOS-")
+		if !ok {
+			continue
+		}
+		for _, device := range devices {
+			simulators = append(simulators, Simulator{
+				UDID:      device.UDID,
+				Name:      device.Name,
+				State:     device.State,
+				Runtime:   strings.ReplaceAll(version, "-", "."),
+				Available: device.IsAvailable,
+			})
+		}
+	}
+
+	sort.SliceStable(simulators, func(i, j int) bool {
+		if order := utils.CompareVersions(simulators[i].Runtime, simulators[j].Runtime); order != 0 {
+			return order > 0
+		}
+		return simulators[i].Name < simulators[j].Name
+	})
+	return simulators, nil
+}
+
+// SelectSimulator picks the simulator to run on: the one whose UDID or name matches query, preferring a booted
+// one and then the newest runtime, or without a query the booted simulator or else the newest iPhone.
+func SelectSimulator(simulators []Simulator, query string) (Simulator, error) {
+	var candidates []Simulator
+	for _, simulator := range simulators {
+		if !simulator.Available {
+			continue
+		}
+		if query == "" || simulator.UDID == query || strings.EqualFold(simulator.Name, query) {
+			candidates = append(candidates, simulator)
+		}
+	}
+
+	for _, simulator := range candidates {
+		if simulator.Booted() {
+			return simulator, nil
+		}
+	}
+	for _, simulator := range candidates {
+		if query != "" || strings.HasPrefix(simulator.Name, "iPhone") {
+			return simulator, nil
+		}
+	}
+
+	if query != "" {
+		var names []string
+		for _, simulator := range simulators {
+			if simulator.Available && !contains(names, simulator.Name) {
+				names = append(names, simulator.Name)
+			}
+		}
+		if suggestion := utils.ClosestMatch(query, names); suggestion != "" {
+			return Simulator{}, fmt.Errorf("no simulator %q, did you mean %q?", query, suggestion)
+		}
+		return Simulator{}, fmt.Errorf("no simulator %q, run xcrun simctl list devices to see the available ones", query)
+	}
+	return Simulator{}, fmt.Errorf("no iPhone simulator available, add one in Xcode under Window > Devices and Simulators")
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Simctl drives the iOS simulators through xcrun simctl.
+type Simctl struct {
+	Exec utils.Executor
+}
+
+// NewSimctl returns a Simctl that runs simctl on the machine.
+func NewSimctl() *Simctl {
+	return &Simctl{Exec: utils.SystemExecutor{}}
+}
+
+// Simulators returns the iOS simulators of the machine.
+func (s *Simctl) Simulators() ([]Simulator, error) {
+	output, err := s.Exec.Output("", nil, "xcrun", "simctl", "list", "devices", "--json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list simulators: %v", err)
+	}
+	return ParseSimulators([]byte(output))
+}
+
+// Boot boots the simulator, waits until it has finished booting and brings up the Simulator app to show it.
+func (s *Simctl) Boot(udid string) error {
+	if err := s.Exec.Run("", nil, "xcrun", "simctl", "bootstatus", udid, "-b"); err != nil {
+		return fmt.Errorf("failed to boot simulator %s: %v", udid, err)
+	}
+	s.Exec.Output("", nil, "open", "-a", "Simulator", "--args", "-CurrentDeviceUDID", udid)
+	return nil
+}
+
+// Install installs the .app on the simulator.
+func (s *Simctl) Install(udid, app string) error {
+	if err := s.Exec.Run("", nil, "xcrun", "simctl", "install", udid, app); err != nil {
+		return fmt.Errorf("failed to install %s on simulator %s: %v", filepath.Base(app), udid, err)
+	}
+	return nil
+}
+
+// Launch launches the app with the bundle identifier on the simulator, terminating a running instance first.
+func (s *Simctl) Launch(udid, bundleID string) error {
+	if err := s.Exec.Run("", nil, "xcrun", "simctl", "launch", "--terminate-running-process", udid, bundleID); err != nil {
+		return fmt.Errorf("failed to launch %s on simulator %s: %v", bundleID, udid, err)
+	}
+	return nil
+}
+
+// StreamLogs streams the unified log of the process with the given executable name until it is interrupted.
+func (s *Simctl) StreamLogs(udid, executable string) error {
+	return s.Exec.Run("", nil, "xcrun", "simctl", "spawn", udid, "log", "stream",
+		"--style", "compact",
+		"--level", "debug",
+		"--predicate", fmt.Sprintf("process == %q", executable),
+	)
+}
+
+// AppBundle returns the bundle identifier and executable name of a built .app, read from its Info.plist,
+// which Xcode writes as a binary plist.
+func (s *Simctl) AppBundle(app string) (string, string, error) {
+	infoPlist := filepath.Join(app, "Info.plist")
+	output, err := s.Exec.Output("", nil, "plutil", "-convert", "xml1", "-o", "-", infoPlist)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read %s: %v", infoPlist, err)
+	}
+
+	plist, err := ParseXMLPlist([]byte(output))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse %s: %v", infoPlist, err)
+	}
+	info, _ := plist.(map[string]interface{})
+	bundleID := plistString(info["CFBundleIdentifier"])
+	if bundleID == "" {
+		return "", "", fmt.Errorf("%s has no CFBundleIdentifier", infoPlist)
+	}
+	return bundleID, plistString(info["CFBundleExecutable"]), nil
+}
@@ -0,0 +1,85 @@
+package ios
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func loadSimulators(t *testing.T) []Simulator {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "simctl-devices.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	simulators, err := ParseSimulators(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return simulators
+}
+
+func TestParseSimulators(t *testing.T) {
+	simulators := loadSimulators(t)
+
+	// Newest runtime first, then by name. The watchOS simulator is left out.
+	var got []string
+	for _, simulator := range simulators {
+		got = append(got, simulator.Name+" "+simulator.Runtime)
+	}
+	want := []string{
+		"iPad Air (5th generation) 17.2",
+		"iPhone 14 17.2",
+		"iPhone 15 Pro 17.2",
+		"iPhone 14 16.4",
+		"iPhone SE (3rd generation) 16.4",
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("ParseSimulators() = %q, want %q", got, want)
+	}
+	if !simulators[3].Booted() || simulators[4].Available {
+		t.Errorf("ParseSimulators() lost the state or availability: %+v", simulators[3:])
+	}
+
+	if _, err := ParseSimulators([]byte("xcrun: error: unable to find utility \"simctl\"")); err == nil {
+		t.Error("ParseSimulators() of an error message succeeded")
+	}
+}
+
+func TestSelectSimulator(t *testing.T) {
+	simulators := loadSimulators(t)
+	shutdown := append([]Simulator{}, simulators...)
+	shutdown[3].State = "Shutdown"
+
+	tests := []struct {
+		name       string
+		simulators []Simulator
+		query      string
+		want       string
+		err        string
+	}{
+		{name: "booted", simulators: simulators, want: "5B8F7E19-0C0D-4A8E-8C1B-7F6E5D4C3B2A"},
+		{name: "newest iPhone", simulators: shutdown, want: "1F2E3D4C-5B6A-4798-8A9B-CDEF01234567"},
+		{name: "name prefers booted", simulators: simulators, query: "iphone 14", want: "5B8F7E19-0C0D-4A8E-8C1B-7F6E5D4C3B2A"},
+		{name: "name prefers newest", simulators: shutdown, query: "iPhone 14", want: "1F2E3D4C-5B6A-4798-8A9B-CDEF01234567"},
+		{name: "by UDID", simulators: simulators, query: "C4E1A2B3-D5F6-4789-A0B1-C2D3E4F5A6B7", want: "C4E1A2B3-D5F6-4789-A0B1-C2D3E4F5A6B7"},
+		{name: "typo", simulators: simulators, query: "iPhone 15 Por", err: `did you mean "iPhone 15 Pro"?`},
+		{name: "unavailable", simulators: simulators, query: "9E3D2C1B-4A5F-4E6D-8C7B-0A1B2C3D4E5F", err: "no simulator"},
+		{name: "no iPhone", simulators: simulators[:1], err: "no iPhone simulator available"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			simulator, err := SelectSimulator(test.simulators, test.query)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("SelectSimulator(%q) = %v, %v, want an error containing %q", test.query, simulator, err, test.err)
+				}
+				return
+			}
+			if err != nil || simulator.UDID != test.want {
+				t.Errorf("SelectSimulator(%q) = %v, %v, want %s", test.query, simulator, err, test.want)
+			}
+		})
+	}
+}
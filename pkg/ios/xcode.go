@@ -0,0 +1,43 @@
+package ios
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// minimumXcodeForDeploymentTarget maps an iOS major version to the first Xcode major version whose SDK ships it.
+var minimumXcodeForDeploymentTarget = map[int]int{
+	11: 9,
+	12: 10,
+	13: 11,
+	14: 12,
+	15: 13,
+	16: 14,
+	17: 15,
+	18: 16,
+}
+
+var xcodeVersionRegexp = regexp.MustCompile(`Xcode (\d+(\.\d+)*)`)
+
+// XcodeVersion returns the version of the active Xcode as reported by `xcodebuild -version`.
+func XcodeVersion() (string, error) {
+	output, err := utils.RunCommandWithOutput("xcodebuild", "-version")
+	if err != nil {
+		return "", fmt.Errorf("failed to get Xcode version: %v", err)
+	}
+
+	match := xcodeVersionRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return "", fmt.Errorf("unexpected xcodebuild output: %s", output)
+	}
+
+	return match[1], nil
+}
+
+// MinimumXcodeVersion returns the oldest Xcode major version able to build for the given deployment target,
+// or zero when the deployment target is unknown.
+func MinimumXcodeVersion(deploymentTarget string) int {
+	return minimumXcodeForDeploymentTarget[utils.MajorVersion(deploymentTarget)]
+}
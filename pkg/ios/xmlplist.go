@@ -0,0 +1,218 @@
+package ios
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseXMLPlist parses an XML property list such as Info.plist or the payload of a provisioning profile.
+// Dictionaries are returned as map[string]interface{}, arrays as []interface{}, and scalars as
+// string, int64, float64, bool, time.Time or []byte.
+func ParseXMLPlist(data []byte) (interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("plist: %v", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local != "plist" {
+				return nil, fmt.Errorf("plist: unexpected root element <%s>", start.Name.Local)
+			}
+			return parseXMLPlistElement(decoder, nil)
+		}
+	}
+}
+
+// parseXMLPlistElement reads the next value element. When start is nil the next start element is read first.
+func parseXMLPlistElement(decoder *xml.Decoder, start *xml.StartElement) (interface{}, error) {
+	if start == nil {
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, fmt.Errorf("plist: %v", err)
+			}
+			if element, ok := token.(xml.StartElement); ok {
+				start = &element
+				break
+			}
+		}
+	}
+
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]interface{}{}
+		for {
+			key, done, err := nextXMLPlistChild(decoder)
+			if err != nil || done {
+				return dict, err
+			}
+			if key.Name.Local != "key" {
+				return nil, fmt.Errorf("plist: expected <key> in dict, found <%s>", key.Name.Local)
+			}
+
+			var name string
+			if err := decoder.DecodeElement(&name, key); err != nil {
+				return nil, fmt.Errorf("plist: %v", err)
+			}
+
+			value, done, err := nextXMLPlistChild(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if done {
+				return nil, fmt.Errorf("plist: missing value for key %q", name)
+			}
+
+			dict[name], err = parseXMLPlistElement(decoder, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	case "array":
+		array := []interface{}{}
+		for {
+			child, done, err := nextXMLPlistChild(decoder)
+			if err != nil || done {
+				return array, err
+			}
+
+			value, err := parseXMLPlistElement(decoder, child)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, fmt.Errorf("plist: %v", err)
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := decoder.DecodeElement(&text, start); err != nil {
+		return nil, fmt.Errorf("plist: %v", err)
+	}
+
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "integer":
+		return strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	case "real":
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	case "date":
+		return time.Parse(time.RFC3339, strings.TrimSpace(text))
+	case "data":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	default:
+		return nil, fmt.Errorf("plist: unsupported element <%s>", start.Name.Local)
+	}
+}
+
+// nextXMLPlistChild returns the next child start element, or done when the parent element ends.
+func nextXMLPlistChild(decoder *xml.Decoder) (*xml.StartElement, bool, error) {
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, false, fmt.Errorf("plist: unexpected end of document")
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("plist: %v", err)
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			return &element, false, nil
+		case xml.EndElement:
+			return nil, true, nil
+		}
+	}
+}
+
+// MarshalXMLPlist encodes a value built from maps, slices, strings, bools and integers as an XML property list.
+// Dictionary keys are written in sorted order so that the output is stable.
+func MarshalXMLPlist(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	buf.WriteString(`<plist version="1.0">` + "\n")
+	if err := marshalXMLPlistValue(&buf, value, 0); err != nil {
+		return nil, err
+	}
+	buf.WriteString("</plist>\n")
+
+	return buf.Bytes(), nil
+}
+
+func marshalXMLPlistValue(buf *bytes.Buffer, value interface{}, depth int) error {
+	indent := strings.Repeat("\t", depth)
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		buf.WriteString(indent + "<dict>\n")
+		for _, key := range keys {
+			buf.WriteString(indent + "\t<key>")
+			xml.EscapeText(buf, []byte(key))
+			buf.WriteString("</key>\n")
+			if err := marshalXMLPlistValue(buf, v[key], depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(indent + "</dict>\n")
+	case map[string]string:
+		dict := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			dict[key] = item
+		}
+		return marshalXMLPlistValue(buf, dict, depth)
+	case []interface{}:
+		buf.WriteString(indent + "<array>\n")
+		for _, item := range v {
+			if err := marshalXMLPlistValue(buf, item, depth+1); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(indent + "</array>\n")
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		return marshalXMLPlistValue(buf, items, depth)
+	case string:
+		buf.WriteString(indent + "<string>")
+		xml.EscapeText(buf, []byte(v))
+		buf.WriteString("</string>\n")
+	case bool:
+		if v {
+			buf.WriteString(indent + "<true/>\n")
+		} else {
+			buf.WriteString(indent + "<false/>\n")
+		}
+	case int:
+		buf.WriteString(indent + "<integer>" + strconv.Itoa(v) + "</integer>\n")
+	case int64:
+		buf.WriteString(indent + "<integer>" + strconv.FormatInt(v, 10) + "</integer>\n")
+	default:
+		return fmt.Errorf("plist: unsupported value of type %T", value)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package ios
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMarshalXMLPlist(t *testing.T) {
+	value := map[string]interface{}{
+		"name":    "Fish & <Chips>",
+		"enabled": true,
+		"count":   3,
+		"size":    int64(1 << 40),
+		"list":    []string{"one", "two"},
+		"nested":  map[string]string{"b": "2", "a": "1"},
+		"empty":   []interface{}{},
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>count</key>
+	<integer>3</integer>
+	<key>empty</key>
+	<array>
+	</array>
+	<key>enabled</key>
+	<true/>
+	<key>list</key>
+	<array>
+		<string>one</string>
+		<string>two</string>
+	</array>
+	<key>name</key>
+	<string>Fish &amp; &lt;Chips&gt;</string>
+	<key>nested</key>
+	<dict>
+		<key>a</key>
+		<string>1</string>
+		<key>b</key>
+		<string>2</string>
+	</dict>
+	<key>size</key>
+	<integer>1099511627776</integer>
+</dict>
+</plist>
+`
+	got, err := MarshalXMLPlist(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("MarshalXMLPlist() =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := MarshalXMLPlist(map[string]interface{}{"ratio": 1.5}); err == nil || !strings.Contains(err.Error(), "float64") {
+		t.Errorf("MarshalXMLPlist() of a float = %v, want an unsupported type error", err)
+	}
+}
+
+func TestMarshalXMLPlistRoundTrip(t *testing.T) {
+	value := map[string]interface{}{
+		"method":               "ad-hoc",
+		"compileBitcode":       false,
+		"provisioningProfiles": map[string]interface{}{"com.acme.app": "Acme Ad Hoc"},
+		"thinning":             "<none>",
+	}
+	data, err := MarshalXMLPlist(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseXMLPlist(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, value) {
+		t.Errorf("ParseXMLPlist(MarshalXMLPlist()) = %#v, want %#v", got, value)
+	}
+}
@@ -0,0 +1,87 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+)
+
+// ParsePrivateKey reads a PEM encoded PKCS #8 private key, the format of Google service account keys and App
+// Store Connect .p8 keys.
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// Sign returns the compact JWT of the claims, signed with RS256 using an RSA key or with ES256 using a P-256 key.
+// The header fields are added to alg and typ.
+func Sign(header map[string]string, claims any, key crypto.Signer) (string, error) {
+	alg := ""
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		alg = "RS256"
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return "", fmt.Errorf("ES256 needs a P-256 key, not %s", key.Curve.Params().Name)
+		}
+		alg = "ES256"
+	default:
+		return "", fmt.Errorf("unsupported signing key type %T", key)
+	}
+	fields := map[string]string{"alg": alg, "typ": "JWT"}
+	for name, value := range header {
+		fields[name] = value
+	}
+
+	headerJSON, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encode(headerJSON) + "." + encode(claimsJSON)
+
+	digest := sha256.Sum256([]byte(signingInput))
+	var signature []byte
+	if ecKey, ok := key.(*ecdsa.PrivateKey); ok {
+		// JWS wants the raw r and s values, 32 bytes each, rather than the ASN.1 encoding crypto.Signer returns.
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		if err != nil {
+			return "", fmt.Errorf("failed to sign the token: %v", err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	} else {
+		signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+		if err != nil {
+			return "", fmt.Errorf("failed to sign the token: %v", err)
+		}
+	}
+	return signingInput + "." + encode(signature), nil
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
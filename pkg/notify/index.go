@@ -0,0 +1,265 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Kinds of build events.
+const (
+	Start   = "start"
+	Success = "success"
+	Failure = "failure"
+)
+
+// Kinds lists every kind of event, the ones a webhook receives unless it lists its own.
+var Kinds = []string{Start, Success, Failure}
+
+// Artifact is a build output with a link to it.
+type Artifact struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	Size int64  `json:"size"`
+	URL  string `json:"url"`
+}
+
+// Event describes a build that started, succeeded or failed.
+type Event struct {
+	Kind        string        `json:"event"`
+	App         string        `json:"app"`
+	Platform    string        `json:"platform"`
+	Variant     string        `json:"variant,omitempty"`
+	Version     string        `json:"version,omitempty"`
+	BuildNumber int           `json:"buildNumber,omitempty"`
+	GitCommit   string        `json:"gitCommit,omitempty"`
+	Duration    time.Duration `json:"-"`
+	Artifacts   []Artifact    `json:"artifacts,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	// LogTail holds the last lines of the build output of a failed build.
+	LogTail string    `json:"logTail,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// MarshalJSON adds the duration in seconds, which is what the receivers of generic webhooks expect.
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	return json.Marshal(struct {
+		event
+		DurationSeconds float64 `json:"durationSeconds"`
+	}{event(e), e.Duration.Round(time.Second).Seconds()})
+}
+
+// Build names the build, e.g. "acme android 1.2.3 (42)".
+func (e Event) Build() string {
+	name := e.App + " " + e.Platform
+	if e.Variant != "" {
+		name += " " + e.Variant
+	}
+	if e.Version != "" {
+		name += " " + e.Version
+		if e.BuildNumber > 0 {
+			name += fmt.Sprintf(" (%d)", e.BuildNumber)
+		}
+	}
+	return name
+}
+
+// Summary is a one line description of the event.
+func (e Event) Summary() string {
+	switch e.Kind {
+	case Start:
+		return "Started building " + e.Build()
+	case Success:
+		return fmt.Sprintf("Built %s in %s", e.Build(), e.Duration.Round(time.Second))
+	default:
+		return fmt.Sprintf("Failed to build %s after %s", e.Build(), e.Duration.Round(time.Second))
+	}
+}
+
+// Color is the hex color chat clients show next to the event.
+func (e Event) Color() string {
+	switch e.Kind {
+	case Success:
+		return "2EB67D"
+	case Failure:
+		return "E01E5A"
+	default:
+		return "1D9BD1"
+	}
+}
+
+// Webhook is an endpoint that receives build events, configured under notify.webhooks in bob.yaml.
+type Webhook struct {
+	// Name identifies the webhook in messages, the URL usually holds a secret.
+	Name string
+	URL  string
+	// Format selects the default template, one of slack, teams or generic.
+	Format string
+	// Events lists the kinds of events the webhook receives, all of them when empty.
+	Events []string
+	// Template is a text/template rendering the request body from an Event, replacing the one of the format.
+	Template string
+	Headers  map[string]string
+}
+
+// String returns the name of the webhook, or its format and host.
+func (w Webhook) String() string {
+	if w.Name != "" {
+		return w.Name
+	}
+	host := w.URL
+	if u, err := url.Parse(w.URL); err == nil {
+		host = u.Host
+	}
+	return fmt.Sprintf("%s webhook %s", w.format(), host)
+}
+
+func (w Webhook) format() string {
+	if w.Format == "" {
+		return "generic"
+	}
+	return w.Format
+}
+
+// Validate checks the URL, format, events and template of the webhook.
+func (w Webhook) Validate() error {
+	if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%s has no valid http(s) URL", w)
+	}
+	if _, ok := templates[w.format()]; !ok {
+		return fmt.Errorf("%s has unknown format %q, use slack, teams or generic", w, w.Format)
+	}
+	for _, kind := range w.Events {
+		if !contains(Kinds, kind) {
+			return fmt.Errorf("%s has unknown event %q, use %s", w, kind, strings.Join(Kinds, ", "))
+		}
+	}
+	_, err := w.template()
+	return err
+}
+
+// Wants reports whether the webhook receives events of the kind.
+func (w Webhook) Wants(kind string) bool {
+	return len(w.Events) == 0 || contains(w.Events, kind)
+}
+
+func (w Webhook) template() (*template.Template, error) {
+	text := w.Template
+	if text == "" {
+		text = templates[w.format()]
+	}
+	t, err := template.New(w.String()).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template for %s: %v", w, err)
+	}
+	return t, nil
+}
+
+// Payload renders the request body for the event.
+func (w Webhook) Payload(event Event) ([]byte, error) {
+	t, err := w.template()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, event); err != nil {
+		return nil, fmt.Errorf("failed to render the template for %s: %v", w, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Notifier posts events to webhooks, retrying failed deliveries.
+type Notifier struct {
+	Webhooks []Webhook
+	HTTP     *http.Client
+	// MaxAttempts is how often a delivery is tried before giving up, on network errors, throttling and server errors.
+	MaxAttempts int
+	// RetryDelay is the wait before the first retry, doubled for every further retry.
+	RetryDelay time.Duration
+}
+
+// NewNotifier returns a notifier of the webhooks with the default retries.
+func NewNotifier(webhooks []Webhook) *Notifier {
+	return &Notifier{
+		Webhooks:    webhooks,
+		HTTP:        &http.Client{Timeout: 30 * time.Second},
+		MaxAttempts: 3,
+		RetryDelay:  2 * time.Second,
+	}
+}
+
+// Notify posts the event to every webhook that wants it and returns the errors of the failed deliveries.
+func (n *Notifier) Notify(event Event) error {
+	var errs []error
+	for _, webhook := range n.Webhooks {
+		if !webhook.Wants(event.Kind) {
+			continue
+		}
+		payload, err := webhook.Payload(event)
+		if err == nil {
+			err = n.deliver(webhook, payload)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) deliver(webhook Webhook, payload []byte) error {
+	delay := n.RetryDelay
+	var lastErr error
+	for attempt := 1; attempt <= n.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+		if err != nil {
+			return fmt.Errorf("failed to notify %s: %v", webhook, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range webhook.Headers {
+			req.Header.Set(name, value)
+		}
+
+		resp, err := n.HTTP.Do(req)
+		if err != nil {
+			// The error quotes the URL, which may hold a secret.
+			lastErr = errors.Unwrap(err)
+			if lastErr == nil {
+				lastErr = err
+			}
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		if resp.StatusCode < 300 {
+			return nil
+		}
+
+		lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout {
+			return fmt.Errorf("failed to notify %s: %v", webhook, lastErr)
+		}
+	}
+	return fmt.Errorf("failed to notify %s after %d attempts: %v", webhook, n.MaxAttempts, lastErr)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver is a webhook endpoint that answers with the statuses in order, repeating the last one, and records
+// the requests it gets.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   [][]byte
+	headers  []http.Header
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bodies = append(r.bodies, body)
+	r.headers = append(r.headers, req.Header)
+
+	status := r.statuses[0]
+	if len(r.statuses) > 1 {
+		r.statuses = r.statuses[1:]
+	}
+	w.WriteHeader(status)
+	if status >= 300 {
+		fmt.Fprint(w, "invalid_payload")
+	}
+}
+
+// newReceiver starts a receiver and returns its URL, with a path standing in for the secret of real webhook URLs.
+func newReceiver(t *testing.T, statuses ...int) (*receiver, string) {
+	t.Helper()
+	r := &receiver{statuses: statuses}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return r, server.URL + "/services/T000/B000/s3cr3t"
+}
+
+func newTestNotifier(webhooks ...Webhook) *Notifier {
+	notifier := NewNotifier(webhooks)
+	notifier.RetryDelay = time.Millisecond
+	return notifier
+}
+
+var failure = Event{
+	Kind:        Failure,
+	App:         "acme",
+	Platform:    "android",
+	Variant:     "acmeRelease",
+	Version:     "1.4.2",
+	BuildNumber: 42,
+	GitCommit:   "a1b2c3d",
+	Duration:    95 * time.Second,
+	Error:       `gradle failed: "exit status 1" <see log>`,
+	LogTail:     "> Task :app:compileReleaseKotlin FAILED\nBUILD FAILED in 1m 35s",
+	Time:        time.Date(2024, time.May, 24, 10, 0, 0, 0, time.UTC),
+}
+
+var success = Event{
+	Kind:      Success,
+	App:       "acme",
+	Platform:  "ios",
+	Version:   "1.4.2",
+	Duration:  3 * time.Minute,
+	Artifacts: []Artifact{{Name: "Acme.ipa", Kind: "ipa", Size: 1024, URL: "https://builds.example.com/acme/ios/1.4.2/Acme.ipa"}},
+	Time:      time.Date(2024, time.May, 24, 10, 0, 0, 0, time.UTC),
+}
+
+func TestTemplates(t *testing.T) {
+	tests := []struct {
+		format string
+		event  Event
+		want   []string
+	}{
+		{format: "slack", event: failure, want: []string{
+			"Failed to build acme android acmeRelease 1.4.2 (42) after 1m35s",
+			`"color": "#E01E5A"`,
+			`gradle failed: \"exit status 1\" <see log>`,
+			"```\\n> Task :app:compileReleaseKotlin FAILED",
+		}},
+		{format: "slack", event: success, want: []string{
+			"Built acme ios 1.4.2 in 3m0s",
+			"<https://builds.example.com/acme/ios/1.4.2/Acme.ipa|Acme.ipa>",
+		}},
+		{format: "teams", event: failure, want: []string{
+			`"@type": "MessageCard"`,
+			`"themeColor": "E01E5A"`,
+			`{"name": "Commit", "value": "a1b2c3d"}`,
+			"BUILD FAILED in 1m 35s",
+		}},
+		{format: "teams", event: success, want: []string{
+			"[Acme.ipa](https://builds.example.com/acme/ios/1.4.2/Acme.ipa)",
+		}},
+		{format: "", event: failure, want: []string{
+			`"event":"failure"`,
+			`"durationSeconds":95`,
+			`"buildNumber":42`,
+			`"time":"2024-05-24T10:00:00Z"`,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.format+" "+test.event.Kind, func(t *testing.T) {
+			payload, err := Webhook{URL: "https://hooks.example.com", Format: test.format}.Payload(test.event)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !json.Valid(payload) {
+				t.Fatalf("Payload() is not valid JSON:\n%s", payload)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(string(payload), want) {
+					t.Errorf("Payload() =\n%s\nwant it to contain %s", payload, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCustomTemplate(t *testing.T) {
+	webhook := Webhook{
+		URL:      "https://hooks.example.com",
+		Template: `{"content": {{json (printf "%s: %s" .Summary (last 9 .LogTail))}}}`,
+	}
+	payload, err := webhook.Payload(failure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"content": "Failed to build acme android acmeRelease 1.4.2 (42) after 1m35s: …in 1m 35s"}`
+	if string(payload) != want {
+		t.Errorf("Payload() = %s, want %s", payload, want)
+	}
+
+	broken := Webhook{URL: "https://hooks.example.com", Template: `{"text": {{.Summary}`}
+	if err := broken.Validate(); err == nil {
+		t.Error("Validate() of a broken template succeeded")
+	}
+	missing := Webhook{URL: "https://hooks.example.com", Template: `{{.Missing}}`}
+	if _, err := missing.Payload(failure); err == nil {
+		t.Error("Payload() of a template using an unknown field succeeded")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]Webhook{
+		"no URL":         {},
+		"not http":       {URL: "ftp://hooks.example.com"},
+		"unknown format": {URL: "https://hooks.example.com", Format: "discord"},
+		"unknown event":  {URL: "https://hooks.example.com", Events: []string{"failed"}},
+	}
+	for name, webhook := range tests {
+		if err := webhook.Validate(); err == nil {
+			t.Errorf("Validate() with %s succeeded", name)
+		}
+	}
+	if err := (Webhook{URL: "https://hooks.example.com", Format: "teams", Events: []string{Failure}}).Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestNotify(t *testing.T) {
+	slack, slackURL := newReceiver(t, http.StatusOK)
+	generic, genericURL := newReceiver(t, http.StatusNoContent)
+	notifier := newTestNotifier(
+		Webhook{URL: slackURL, Format: "slack", Events: []string{Failure}},
+		Webhook{URL: genericURL, Headers: map[string]string{"Authorization": "Bearer token"}},
+	)
+
+	if err := notifier.Notify(success); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.Notify(failure); err != nil {
+		t.Fatal(err)
+	}
+	if len(slack.bodies) != 1 || !strings.Contains(string(slack.bodies[0]), "Failed to build") {
+		t.Errorf("the failure-only webhook got %q, want only the failure", slack.bodies)
+	}
+	if len(generic.bodies) != 2 {
+		t.Fatalf("the webhook for every event got %d requests, want 2", len(generic.bodies))
+	}
+	var event map[string]any
+	if err := json.Unmarshal(generic.bodies[0], &event); err != nil || event["event"] != Success {
+		t.Errorf("generic payload = %s, %v, want the success event", generic.bodies[0], err)
+	}
+	if header := generic.headers[0]; header.Get("Authorization") != "Bearer token" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("headers = %v, want the configured headers and JSON", header)
+	}
+}
+
+func TestNotifyRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		requests int
+		err      string
+	}{
+		{name: "server error then success", statuses: []int{500, 200}, requests: 2},
+		{name: "unavailable then success", statuses: []int{503, 502, 200}, requests: 3},
+		{name: "rate limited then success", statuses: []int{429, 200}, requests: 2},
+		{name: "server errors", statuses: []int{500, 503, 500, 200}, requests: 3, err: "after 3 attempts: HTTP 500: invalid_payload"},
+		{name: "bad request", statuses: []int{400, 200}, requests: 1, err: "HTTP 400: invalid_payload"},
+		{name: "gone", statuses: []int{404, 200}, requests: 1, err: "HTTP 404"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			receiver, url := newReceiver(t, test.statuses...)
+			err := newTestNotifier(Webhook{Name: "builds channel", URL: url, Format: "slack"}).Notify(failure)
+
+			if test.err == "" && err != nil {
+				t.Errorf("Notify() = %v", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err) || !strings.Contains(err.Error(), "builds channel")) {
+				t.Errorf("Notify() = %v, want an error naming the webhook and containing %q", err, test.err)
+			}
+			if len(receiver.bodies) != test.requests {
+				t.Errorf("Notify() sent %d requests, want %d", len(receiver.bodies), test.requests)
+			}
+		})
+	}
+}
+
+func TestNotifyHidesURL(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL + "/services/T000/B000/s3cr3t"
+	server.Close()
+
+	err := newTestNotifier(Webhook{URL: url, Format: "slack"}).Notify(failure)
+	if err == nil {
+		t.Fatal("Notify() to a closed server succeeded")
+	}
+	if strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("Notify() = %v, which quotes the secret URL", err)
+	}
+}
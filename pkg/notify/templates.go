@@ -0,0 +1,95 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// funcs are available to webhook templates next to the fields and methods of Event.
+var funcs = template.FuncMap{
+	// json encodes a value as JSON, e.g. a string with its quotes, so that templates build valid documents.
+	"json": func(value any) (string, error) {
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		// Slack links look like <url|name>, which should not turn into \u003c and \u003e.
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(value); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	},
+	// last keeps the last n characters of s, for services that limit the size of a message.
+	"last": func(n int, s string) string {
+		if count := utf8.RuneCountInString(s); count > n {
+			runes := []rune(s)
+			return "…" + string(runes[count-n:])
+		}
+		return s
+	},
+	// code wraps s in a Markdown code block.
+	"code": func(s string) string {
+		return "```\n" + s + "\n```"
+	},
+	// links renders the artifacts as Markdown links, one per line, or Slack links with "slack".
+	"links": func(style string, artifacts []Artifact) string {
+		var lines []string
+		for _, artifact := range artifacts {
+			if style == "slack" {
+				lines = append(lines, "<"+artifact.URL+"|"+artifact.Name+">")
+			} else {
+				lines = append(lines, "["+artifact.Name+"]("+artifact.URL+")")
+			}
+		}
+		return strings.Join(lines, "\n")
+	},
+}
+
+// templates are the default templates of the webhook formats.
+var templates = map[string]string{
+	"slack": `{
+  "text": {{json .Summary}},
+  "attachments": [{
+    "color": "#{{.Color}}",
+    "blocks": [
+      {"type": "section", "text": {"type": "mrkdwn", "text": {{json .Summary}}}}
+      {{- if .Artifacts}},
+      {"type": "section", "text": {"type": "mrkdwn", "text": {{json (last 2900 (links "slack" .Artifacts))}}}}
+      {{- end}}
+      {{- if .Error}},
+      {"type": "section", "text": {"type": "mrkdwn", "text": {{json (last 2900 .Error)}}}}
+      {{- end}}
+      {{- if .LogTail}},
+      {"type": "section", "text": {"type": "mrkdwn", "text": {{json (code (last 2900 .LogTail))}}}}
+      {{- end}}
+    ]
+  }]
+}`,
+	"teams": `{
+  "@type": "MessageCard",
+  "@context": "https://schema.org/extensions",
+  "summary": {{json .Summary}},
+  "themeColor": {{json .Color}},
+  "title": {{json .Summary}},
+  "sections": [{
+    "facts": [
+      {"name": "App", "value": {{json .App}}},
+      {"name": "Platform", "value": {{json .Platform}}}
+      {{- if .Version}},
+      {"name": "Version", "value": {{json .Version}}}
+      {{- end}}
+      {{- if .GitCommit}},
+      {"name": "Commit", "value": {{json .GitCommit}}}
+      {{- end}}
+    ]
+    {{- if .Artifacts}},
+    "text": {{json (links "markdown" .Artifacts)}}
+    {{- else if .Error}},
+    "text": {{json (printf "%s\n\n%s" .Error (code (last 20000 .LogTail)))}}
+    {{- end}}
+  }]
+}`,
+	"generic": `{{json .}}`,
+}
@@ -0,0 +1,87 @@
+package play
+
+import (
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aman-apptile/bob/pkg/jwt"
+)
+
+const (
+	publisherScope  = "https://www.googleapis.com/auth/androidpublisher"
+	jwtBearerGrant  = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	defaultTokenURI = "https://oauth2.googleapis.com/token"
+)
+
+// ServiceAccount is a Google Cloud service account key, as downloaded from the Cloud console.
+type ServiceAccount struct {
+	Type         string `json:"type"`
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+
+	key crypto.Signer
+}
+
+// LoadServiceAccount reads a service account key file.
+func LoadServiceAccount(path string) (*ServiceAccount, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the service account key: %v", err)
+	}
+
+	account := &ServiceAccount{}
+	if err := json.Unmarshal(data, account); err != nil {
+		return nil, fmt.Errorf("failed to parse the service account key %s: %v", path, err)
+	}
+	if account.Type != "service_account" || account.ClientEmail == "" {
+		return nil, fmt.Errorf("%s is not a service account key", path)
+	}
+	if account.key, err = jwt.ParsePrivateKey([]byte(account.PrivateKey)); err != nil {
+		return nil, fmt.Errorf("failed to read the private key of %s: %v", account.ClientEmail, err)
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = defaultTokenURI
+	}
+	return account, nil
+}
+
+// token exchanges a JWT signed with the account's key for an access token to the Android Publisher API.
+func (a *ServiceAccount) token(client *http.Client, now time.Time) (string, time.Time, error) {
+	assertion, err := jwt.Sign(map[string]string{"kid": a.PrivateKeyID}, map[string]any{
+		"iss":   a.ClientEmail,
+		"scope": publisherScope,
+		"aud":   a.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}, a.key)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	form := url.Values{"grant_type": {jwtBearerGrant}, "assertion": {assertion}}
+	resp, err := client.Post(a.TokenURI, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to authenticate as %s: %v", a.ClientEmail, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("failed to authenticate as %s: HTTP %d %s %s", a.ClientEmail, resp.StatusCode, result.Error, result.ErrorDescription)
+	}
+	return result.AccessToken, now.Add(time.Duration(result.ExpiresIn) * time.Second), nil
+}
@@ -0,0 +1,197 @@
+package play
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the base URL of the Google Play Android Developer API.
+const DefaultBaseURL = "https://androidpublisher.googleapis.com"
+
+// Tracks are the standard release tracks of Google Play.
+var Tracks = []string{"internal", "alpha", "beta", "production"}
+
+// Release statuses of a track.
+const (
+	StatusCompleted  = "completed"
+	StatusInProgress = "inProgress"
+	StatusDraft      = "draft"
+)
+
+// MaxReleaseNotesLength is how many characters Google Play accepts for the release notes of one language.
+const MaxReleaseNotesLength = 500
+
+// LocalizedText is text in one language, e.g. en-US.
+type LocalizedText struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+// Release is a release of a track. UserFraction is set for staged rollouts, with the status StatusInProgress.
+type Release struct {
+	Name         string          `json:"name,omitempty"`
+	VersionCodes []string        `json:"versionCodes"`
+	Status       string          `json:"status"`
+	UserFraction float64         `json:"userFraction,omitempty"`
+	ReleaseNotes []LocalizedText `json:"releaseNotes,omitempty"`
+}
+
+// Client calls the edits API of the Google Play Android Developer API as a service account.
+type Client struct {
+	// BaseURL is where the API is served, DefaultBaseURL unless a test server stands in for it.
+	BaseURL string
+	HTTP    *http.Client
+	Account *ServiceAccount
+
+	accessToken string
+	expiry      time.Time
+}
+
+// NewClient returns a client of the API at baseURL, or at DefaultBaseURL when it is empty.
+func NewClient(baseURL string, account *ServiceAccount) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 30 * time.Minute},
+		Account: account,
+	}
+}
+
+// call sends a request to the path under the base URL and decodes the JSON response into result, unless it is nil.
+func (c *Client) call(method, path string, contentType string, body io.Reader, result any) error {
+	if c.accessToken == "" || time.Now().After(c.expiry.Add(-time.Minute)) {
+		token, expiry, err := c.Account.token(c.HTTP, time.Now())
+		if err != nil {
+			return err
+		}
+		c.accessToken, c.expiry = token, expiry
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var failure struct {
+			Error struct {
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+				Status  string `json:"status"`
+			} `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(data, &failure) == nil && failure.Error.Message != "" {
+			return fmt.Errorf("%s (HTTP %d %s)", failure.Error.Message, resp.StatusCode, failure.Error.Status)
+		}
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (c *Client) callJSON(method, path string, body, result any) error {
+	var reader io.Reader
+	contentType := ""
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader, contentType = bytes.NewReader(data), "application/json"
+	}
+	return c.call(method, path, contentType, reader, result)
+}
+
+func (c *Client) upload(path, file string, result any) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.call(http.MethodPost, "/upload"+path+"?uploadType=media", "application/octet-stream", f, result)
+}
+
+func editPath(packageName, editID string) string {
+	path := "/androidpublisher/v3/applications/" + url.PathEscape(packageName) + "/edits"
+	if editID != "" {
+		path += "/" + url.PathEscape(editID)
+	}
+	return path
+}
+
+// CreateEdit opens an edit of the app. Changes made in the edit are published together when it is committed.
+func (c *Client) CreateEdit(packageName string) (string, error) {
+	var edit struct {
+		ID string `json:"id"`
+	}
+	if err := c.callJSON(http.MethodPost, editPath(packageName, ""), map[string]any{}, &edit); err != nil {
+		return "", fmt.Errorf("failed to create an edit of %s: %v", packageName, err)
+	}
+	return edit.ID, nil
+}
+
+// UploadBundle uploads an Android App Bundle to the edit and returns its version code.
+func (c *Client) UploadBundle(packageName, editID, path string) (int64, error) {
+	var bundle struct {
+		VersionCode int64  `json:"versionCode"`
+		SHA256      string `json:"sha256"`
+	}
+	if err := c.upload(editPath(packageName, editID)+"/bundles", path, &bundle); err != nil {
+		return 0, fmt.Errorf("failed to upload %s: %v", path, err)
+	}
+	return bundle.VersionCode, nil
+}
+
+// UploadMapping uploads the R8 mapping file of the version code to the edit, for deobfuscating crash reports.
+func (c *Client) UploadMapping(packageName, editID string, versionCode int64, path string) error {
+	endpoint := fmt.Sprintf("%s/deobfuscationFiles/%d/proguard", editPath(packageName, editID), versionCode)
+	if err := c.upload(endpoint, path, nil); err != nil {
+		return fmt.Errorf("failed to upload %s: %v", path, err)
+	}
+	return nil
+}
+
+// UpdateTrack replaces the releases of the track in the edit with the release.
+func (c *Client) UpdateTrack(packageName, editID, track string, release Release) error {
+	body := map[string]any{"track": track, "releases": []Release{release}}
+	path := editPath(packageName, editID) + "/tracks/" + url.PathEscape(track)
+	if err := c.callJSON(http.MethodPut, path, body, nil); err != nil {
+		return fmt.Errorf("failed to update the %s track: %v", track, err)
+	}
+	return nil
+}
+
+// CommitEdit publishes the changes of the edit.
+func (c *Client) CommitEdit(packageName, editID string) error {
+	if err := c.callJSON(http.MethodPost, editPath(packageName, editID)+":commit", nil, nil); err != nil {
+		return fmt.Errorf("failed to commit the edit: %v", err)
+	}
+	return nil
+}
+
+// DeleteEdit discards the edit and its changes.
+func (c *Client) DeleteEdit(packageName, editID string) error {
+	return c.callJSON(http.MethodDelete, editPath(packageName, editID), nil, nil)
+}
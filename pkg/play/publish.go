@@ -0,0 +1,147 @@
+package play
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Upload describes a bundle to publish to a track.
+type Upload struct {
+	PackageName string
+	Bundle      string
+	// Mapping is the R8 mapping file of the bundle, if it was minified.
+	Mapping string
+	Track   string
+	// Rollout is the percentage of users the release is staged to. 0 and 100 release to everyone.
+	Rollout      float64
+	ReleaseName  string
+	ReleaseNotes []LocalizedText
+}
+
+// Validate checks the track, rollout and release notes of the upload.
+func (u Upload) Validate() error {
+	if !contains(Tracks, u.Track) {
+		return fmt.Errorf("unknown track %q, use one of %s", u.Track, strings.Join(Tracks, ", "))
+	}
+	if u.Rollout < 0 || u.Rollout > 100 {
+		return fmt.Errorf("the rollout must be a percentage between 0 and 100, not %g", u.Rollout)
+	}
+	for _, notes := range u.ReleaseNotes {
+		if length := utf8.RuneCountInString(notes.Text); length > MaxReleaseNotesLength {
+			return fmt.Errorf("the %s release notes are %d characters long, Google Play accepts at most %d", notes.Language, length, MaxReleaseNotesLength)
+		}
+	}
+	return nil
+}
+
+// Release returns the track release of the version code.
+func (u Upload) Release(versionCode int64) Release {
+	release := Release{
+		Name:         u.ReleaseName,
+		VersionCodes: []string{fmt.Sprint(versionCode)},
+		Status:       StatusCompleted,
+		ReleaseNotes: u.ReleaseNotes,
+	}
+	if u.Rollout > 0 && u.Rollout < 100 {
+		release.Status = StatusInProgress
+		release.UserFraction = u.Rollout / 100
+	}
+	return release
+}
+
+// Publish uploads the bundle and its mapping file in a new edit, releases it to the track and commits the edit,
+// writing its progress to log. The edit is deleted when a step fails, leaving the app as it was.
+func (c *Client) Publish(u Upload, log io.Writer) (int64, error) {
+	if err := u.Validate(); err != nil {
+		return 0, err
+	}
+
+	editID, err := c.CreateEdit(u.PackageName)
+	if err != nil {
+		return 0, err
+	}
+	versionCode, err := c.publishEdit(u, editID, log)
+	if err != nil {
+		c.DeleteEdit(u.PackageName, editID)
+		return 0, err
+	}
+	return versionCode, nil
+}
+
+func (c *Client) publishEdit(u Upload, editID string, log io.Writer) (int64, error) {
+	fmt.Fprintf(log, "Uploading %s...\n", filepath.Base(u.Bundle))
+	versionCode, err := c.UploadBundle(u.PackageName, editID, u.Bundle)
+	if err != nil {
+		return 0, err
+	}
+
+	if u.Mapping != "" {
+		fmt.Fprintf(log, "Uploading %s for version code %d...\n", filepath.Base(u.Mapping), versionCode)
+		if err := c.UploadMapping(u.PackageName, editID, versionCode, u.Mapping); err != nil {
+			return 0, err
+		}
+	}
+
+	release := u.Release(versionCode)
+	if release.Status == StatusInProgress {
+		fmt.Fprintf(log, "Rolling version code %d out to %g%% of the %s track...\n", versionCode, u.Rollout, u.Track)
+	} else {
+		fmt.Fprintf(log, "Releasing version code %d to the %s track...\n", versionCode, u.Track)
+	}
+	if err := c.UpdateTrack(u.PackageName, editID, u.Track, release); err != nil {
+		return 0, err
+	}
+
+	return versionCode, c.CommitEdit(u.PackageName, editID)
+}
+
+// ReadReleaseNotes reads release notes in the language from a file, or from a directory holding one
+// <language>.txt file per language, e.g. en-US.txt and de-DE.txt.
+func ReadReleaseNotes(path, language string) ([]LocalizedText, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the release notes: %v", err)
+	}
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the release notes: %v", err)
+		}
+		return []LocalizedText{{Language: language, Text: strings.TrimSpace(string(data))}}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var notes []LocalizedText
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the release notes: %v", err)
+		}
+		notes = append(notes, LocalizedText{
+			Language: strings.TrimSuffix(filepath.Base(file), ".txt"),
+			Text:     strings.TrimSpace(string(data)),
+		})
+	}
+	if len(notes) == 0 {
+		return nil, fmt.Errorf("no <language>.txt release notes found in %s", path)
+	}
+	return notes, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
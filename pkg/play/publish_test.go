@@ -0,0 +1,281 @@
+package play
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	testPackage = "com.acme.app"
+	testEdits   = "/androidpublisher/v3/applications/com.acme.app/edits"
+)
+
+// fakePlay stands in for the token endpoint and the edits API. It records the API calls and their bodies, and
+// fails the calls listed in failures with the given status.
+type fakePlay struct {
+	t        *testing.T
+	key      *rsa.PublicKey
+	tokenURI string
+	failures map[string]int
+
+	mu       sync.Mutex
+	tokens   int
+	calls    []string
+	bodies   map[string][]byte
+	bodyType map[string]string
+}
+
+func (p *fakePlay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/token" {
+		p.token(w, r)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	if r.Header.Get("Authorization") != "Bearer ya29.test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error": {"code": 401, "message": "Request had invalid authentication credentials.", "status": "UNAUTHENTICATED"}}`)
+		return
+	}
+
+	call := r.Method + " " + r.URL.Path
+	p.mu.Lock()
+	p.calls = append(p.calls, call)
+	p.bodies[call] = body
+	p.bodyType[call] = r.Header.Get("Content-Type")
+	p.mu.Unlock()
+
+	if status, ok := p.failures[call]; ok {
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"error": {"code": %d, "message": "The caller does not have permission", "status": "PERMISSION_DENIED"}}`, status)
+		return
+	}
+	switch {
+	case call == "POST "+testEdits:
+		fmt.Fprint(w, `{"id": "edit-1", "expiryTimeSeconds": "1700000000"}`)
+	case strings.HasSuffix(call, "/edits/edit-1/bundles"):
+		fmt.Fprint(w, `{"versionCode": 42, "sha1": "ignored", "sha256": "ignored"}`)
+	case r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		fmt.Fprint(w, `{}`)
+	}
+}
+
+// token checks the JWT bearer grant, and the RS256 signature and audience of its assertion.
+func (p *fakePlay) token(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.tokens++
+	p.mu.Unlock()
+
+	if r.FormValue("grant_type") != jwtBearerGrant {
+		http.Error(w, `{"error": "unsupported_grant_type"}`, http.StatusBadRequest)
+		return
+	}
+	parts := strings.Split(r.FormValue("assertion"), ".")
+	if len(parts) != 3 {
+		http.Error(w, `{"error": "invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(p.key, crypto.SHA256, digest[:], signature); err != nil {
+		http.Error(w, `{"error": "invalid_grant", "error_description": "Invalid JWT Signature."}`, http.StatusBadRequest)
+		return
+	}
+	claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var assertion struct {
+		Issuer   string `json:"iss"`
+		Scope    string `json:"scope"`
+		Audience string `json:"aud"`
+	}
+	json.Unmarshal(claims, &assertion)
+	if assertion.Issuer != "bob@acme.iam.gserviceaccount.com" || assertion.Scope != publisherScope || assertion.Audience != p.tokenURI {
+		p.t.Errorf("token assertion claims = %+v", assertion)
+	}
+	fmt.Fprint(w, `{"access_token": "ya29.test-token", "expires_in": 3599, "token_type": "Bearer"}`)
+}
+
+// newFakePlay starts a fake Google Play and returns a client of it, authenticating as a service account with a
+// generated key whose token URI points at the fake.
+func newFakePlay(t *testing.T, failures map[string]int) (*fakePlay, *Client) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakePlay{t: t, key: &key.PublicKey, failures: failures, bodies: map[string][]byte{}, bodyType: map[string]string{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	fake.tokenURI = server.URL + "/token"
+
+	keyFile := filepath.Join(t.TempDir(), "service-account.json")
+	data, _ := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"client_email":   "bob@acme.iam.gserviceaccount.com",
+		"private_key_id": "0123456789abcdef",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":      fake.tokenURI,
+	})
+	if err := os.WriteFile(keyFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	account, err := LoadServiceAccount(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fake, NewClient(server.URL, account)
+}
+
+// testUpload writes a bundle and a mapping file and returns an upload of them to the track.
+func testUpload(t *testing.T, track string, rollout float64) Upload {
+	t.Helper()
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "app-release.aab")
+	mapping := filepath.Join(dir, "mapping.txt")
+	if err := os.WriteFile(bundle, []byte("aab"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mapping, []byte("com.acme.MainActivity -> a:"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Upload{
+		PackageName:  testPackage,
+		Bundle:       bundle,
+		Mapping:      mapping,
+		Track:        track,
+		Rollout:      rollout,
+		ReleaseName:  "1.4.2",
+		ReleaseNotes: []LocalizedText{{Language: "en-US", Text: "Bug fixes"}},
+	}
+}
+
+func TestPublish(t *testing.T) {
+	fake, client := newFakePlay(t, nil)
+	var log strings.Builder
+
+	versionCode, err := client.Publish(testUpload(t, "beta", 10), &log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versionCode != 42 {
+		t.Errorf("Publish() = %d, want the version code of the uploaded bundle", versionCode)
+	}
+
+	want := []string{
+		"POST " + testEdits,
+		"POST /upload" + testEdits + "/edit-1/bundles",
+		"POST /upload" + testEdits + "/edit-1/deobfuscationFiles/42/proguard",
+		"PUT " + testEdits + "/edit-1/tracks/beta",
+		"POST " + testEdits + "/edit-1:commit",
+	}
+	if strings.Join(fake.calls, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("calls =\n%s\nwant\n%s", strings.Join(fake.calls, "\n"), strings.Join(want, "\n"))
+	}
+	if fake.tokens != 1 {
+		t.Errorf("Publish() requested %d access tokens, want 1", fake.tokens)
+	}
+	if body, contentType := string(fake.bodies[want[1]]), fake.bodyType[want[1]]; body != "aab" || contentType != "application/octet-stream" {
+		t.Errorf("bundle upload = %q as %s, want the bundle as application/octet-stream", body, contentType)
+	}
+	if body := string(fake.bodies[want[2]]); body != "com.acme.MainActivity -> a:" {
+		t.Errorf("mapping upload = %q, want the mapping file", body)
+	}
+
+	var track struct {
+		Track    string    `json:"track"`
+		Releases []Release `json:"releases"`
+	}
+	if err := json.Unmarshal(fake.bodies[want[3]], &track); err != nil {
+		t.Fatal(err)
+	}
+	wantRelease := Release{
+		Name:         "1.4.2",
+		VersionCodes: []string{"42"},
+		Status:       StatusInProgress,
+		UserFraction: 0.1,
+		ReleaseNotes: []LocalizedText{{Language: "en-US", Text: "Bug fixes"}},
+	}
+	if track.Track != "beta" || len(track.Releases) != 1 || fmt.Sprint(track.Releases[0]) != fmt.Sprint(wantRelease) {
+		t.Errorf("track update = %+v, want %+v on beta", track, wantRelease)
+	}
+	if !strings.Contains(log.String(), "Rolling version code 42 out to 10% of the beta track") {
+		t.Errorf("Publish() logged %q, want the staged rollout", log.String())
+	}
+}
+
+func TestPublishDeletesEditOnFailure(t *testing.T) {
+	tests := map[string]string{
+		"bundle upload": "POST /upload" + testEdits + "/edit-1/bundles",
+		"track update":  "PUT " + testEdits + "/edit-1/tracks/production",
+		"commit":        "POST " + testEdits + "/edit-1:commit",
+	}
+	for name, failing := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake, client := newFakePlay(t, map[string]int{failing: http.StatusForbidden})
+
+			_, err := client.Publish(testUpload(t, "production", 0), io.Discard)
+			if err == nil || !strings.Contains(err.Error(), "The caller does not have permission (HTTP 403 PERMISSION_DENIED)") {
+				t.Errorf("Publish() = %v, want the error of the API", err)
+			}
+			if len(fake.calls) == 0 {
+				t.Fatal("Publish() made no API calls")
+			}
+			if last := fake.calls[len(fake.calls)-1]; last != "DELETE "+testEdits+"/edit-1" {
+				t.Errorf("last call = %q, want the edit to be deleted", last)
+			}
+			for _, call := range fake.calls {
+				if strings.HasSuffix(call, ":commit") && call != failing {
+					t.Errorf("Publish() committed the edit after %s failed", failing)
+				}
+			}
+		})
+	}
+}
+
+func TestPublishRejectsInvalidUpload(t *testing.T) {
+	fake, client := newFakePlay(t, nil)
+	for _, upload := range []Upload{
+		testUpload(t, "nightly", 0),
+		testUpload(t, "beta", 120),
+		{PackageName: testPackage, Track: "beta", ReleaseNotes: []LocalizedText{{Language: "en-US", Text: strings.Repeat("x", MaxReleaseNotesLength+1)}}},
+	} {
+		if _, err := client.Publish(upload, io.Discard); err == nil {
+			t.Errorf("Publish(%+v) succeeded", upload)
+		}
+	}
+	if len(fake.calls) != 0 || fake.tokens != 0 {
+		t.Errorf("Publish() of invalid uploads called the API: %q", fake.calls)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	for rollout, want := range map[float64]Release{
+		0:   {VersionCodes: []string{"42"}, Status: StatusCompleted},
+		100: {VersionCodes: []string{"42"}, Status: StatusCompleted},
+		25:  {VersionCodes: []string{"42"}, Status: StatusInProgress, UserFraction: 0.25},
+	} {
+		if got := (Upload{Rollout: rollout}).Release(42); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("Release() with a %g%% rollout = %+v, want %+v", rollout, got, want)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPartSize is the size of the parts of a multipart upload. S3 needs parts of at least 5 MiB.
+	DefaultPartSize = 16 << 20
+	// MaxPresignExpiry is the longest a Signature Version 4 pre-signed URL can stay valid.
+	MaxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// ErrNotFound is returned when the object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Config locates an S3 compatible store and holds the credentials to access it.
+type Config struct {
+	// Endpoint is the base URL of the store, e.g. http://localhost:9000 for MinIO. Empty means AWS S3 in Region.
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// PathStyle addresses buckets as the first path segment instead of a subdomain. Stores other than AWS
+	// usually need it, so it is always used with a custom Endpoint.
+	PathStyle bool
+}
+
+// Validate checks that the config has credentials and a region.
+func (c Config) Validate() error {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return fmt.Errorf("no S3 credentials, set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	if c.Region == "" {
+		return fmt.Errorf("no S3 region, set AWS_REGION or s3.region in bob.yaml")
+	}
+	return nil
+}
+
+// Client talks to an S3 compatible store with the REST API, signing its requests with Signature Version 4.
+type Client struct {
+	Config   Config
+	HTTP     *http.Client
+	PartSize int64
+	// MaxAttempts is how often a request is tried before giving up, on network errors, throttling and server errors.
+	MaxAttempts int
+	// RetryDelay is the wait before the first retry, doubled for every further retry.
+	RetryDelay time.Duration
+}
+
+// NewClient returns a client for the store with the default part size and retries.
+func NewClient(config Config) *Client {
+	return &Client{
+		Config:      config,
+		HTTP:        &http.Client{Timeout: 10 * time.Minute},
+		PartSize:    DefaultPartSize,
+		MaxAttempts: 4,
+		RetryDelay:  time.Second,
+	}
+}
+
+// objectURL returns the URL of the object, or of the bucket when key is empty.
+func (c *Client) objectURL(bucket, key string) (*url.URL, error) {
+	endpoint := c.Config.Endpoint
+	pathStyle := c.Config.PathStyle || endpoint != ""
+	if endpoint == "" {
+		endpoint = "https://s3." + c.Config.Region + ".amazonaws.com"
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: %v", endpoint, err)
+	}
+	if pathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + bucket + "/" + key
+	} else {
+		u.Host = bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	// Send the path exactly as it is signed.
+	u.RawPath = canonicalURI(u)
+	return u, nil
+}
+
+// do sends a signed request and retries it on network errors, throttling and server errors. It returns the
+// response of the first attempt that gets a status below 300, or an error describing the last failure.
+func (c *Client) do(method, bucket, key string, query url.Values, headers map[string]string, body []byte) (*http.Response, error) {
+	u, err := c.objectURL(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	payloadHash := sha256.Sum256(body)
+	delay := c.RetryDelay
+	var lastErr error
+	for attempt := 1; attempt <= c.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		c.sign(req, hex.EncodeToString(payloadHash[:]), time.Now())
+
+		resp, err := c.HTTP.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		lastErr = responseError(resp)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %v", ErrNotFound, lastErr)
+		}
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout {
+			return nil, lastErr
+		}
+	}
+
+	return nil, fmt.Errorf("%s %s failed after %d attempts: %v", method, u.Path, c.MaxAttempts, lastErr)
+}
+
+// responseError reads the S3 error document of a failed response.
+func responseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var document struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(data, &document) == nil && document.Code != "" {
+		return fmt.Errorf("%s: %s (HTTP %d)", document.Code, document.Message, resp.StatusCode)
+	}
+	return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+}
+
+func contentMD5(data []byte) string {
+	sum := md5.Sum(data)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// PutObject uploads the object in a single request, with a Content-MD5 for the store to check it against.
+func (c *Client) PutObject(bucket, key string, body []byte, contentType string) error {
+	headers := map[string]string{"Content-MD5": contentMD5(body), "Content-Type": contentType}
+	resp, err := c.do(http.MethodPut, bucket, key, nil, headers, body)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %v", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// GetObject returns the content of the object, or an error wrapping ErrNotFound.
+func (c *Client) GetObject(bucket, key string) (io.ReadCloser, error) {
+	resp, err := c.do(http.MethodGet, bucket, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// UploadFile uploads the file to the key, with a multipart upload when it is larger than the part size.
+// Every part carries a Content-MD5, and a multipart upload that fails is aborted so that its parts are not kept.
+func (c *Client) UploadFile(bucket, key, path, contentType string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if info.Size() <= c.PartSize {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", path, err)
+		}
+		return c.PutObject(bucket, key, data, contentType)
+	}
+
+	uploadID, err := c.createMultipartUpload(bucket, key, contentType)
+	if err != nil {
+		return err
+	}
+	if err := c.uploadParts(bucket, key, uploadID, file); err != nil {
+		c.abortMultipartUpload(bucket, key, uploadID)
+		return err
+	}
+	return nil
+}
+
+func (c *Client) createMultipartUpload(bucket, key, contentType string) (string, error) {
+	resp, err := c.do(http.MethodPost, bucket, key, url.Values{"uploads": {""}}, map[string]string{"Content-Type": contentType}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to start the multipart upload of %s: %v", key, err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("failed to start the multipart upload of %s: no upload ID in the response", key)
+	}
+	return result.UploadID, nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (c *Client) uploadParts(bucket, key, uploadID string, file io.Reader) error {
+	var parts []completedPart
+	buf := make([]byte, c.PartSize)
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(file, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("failed to read part %d of %s: %v", number, key, err)
+		}
+
+		part := buf[:n]
+		query := url.Values{"partNumber": {fmt.Sprint(number)}, "uploadId": {uploadID}}
+		resp, err := c.do(http.MethodPut, bucket, key, query, map[string]string{"Content-MD5": contentMD5(part)}, part)
+		if err != nil {
+			return fmt.Errorf("failed to upload part %d of %s: %v", number, key, err)
+		}
+		resp.Body.Close()
+		parts = append(parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+
+		if n < len(buf) {
+			break
+		}
+	}
+
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(http.MethodPost, bucket, key, url.Values{"uploadId": {uploadID}}, map[string]string{"Content-MD5": contentMD5(body)}, body)
+	if err != nil {
+		return fmt.Errorf("failed to complete the multipart upload of %s: %v", key, err)
+	}
+	defer resp.Body.Close()
+
+	// S3 reports some failures of CompleteMultipartUpload in the body of a 200 response.
+	data, _ := io.ReadAll(resp.Body)
+	var failure struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}
+	if xml.Unmarshal(data, &failure) == nil && failure.Code != "" {
+		return fmt.Errorf("failed to complete the multipart upload of %s: %s: %s", key, failure.Code, failure.Message)
+	}
+	return nil
+}
+
+func (c *Client) abortMultipartUpload(bucket, key, uploadID string) {
+	resp, err := c.do(http.MethodDelete, bucket, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// PresignGet returns a URL that downloads the object without credentials until it expires, at most after seven days.
+func (c *Client) PresignGet(bucket, key string, expires time.Duration) (string, error) {
+	if expires <= 0 || expires > MaxPresignExpiry {
+		return "", fmt.Errorf("pre-signed URLs expire after 1s to %s, not %s", MaxPresignExpiry, expires)
+	}
+	u, err := c.objectURL(bucket, key)
+	if err != nil {
+		return "", err
+	}
+	return c.presign(http.MethodGet, u, expires, time.Now()), nil
+}
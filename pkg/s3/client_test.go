@@ -0,0 +1,246 @@
+package s3
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore is an S3 endpoint that records the requests it gets and answers them with respond.
+type fakeStore struct {
+	mu       sync.Mutex
+	requests []string
+	bodies   map[string][]byte
+	respond  func(w http.ResponseWriter, r *http.Request, body []byte)
+}
+
+func (s *fakeStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDTEST/") {
+		http.Error(w, "unsigned request", http.StatusForbidden)
+		return
+	}
+	if md5 := r.Header.Get("Content-MD5"); md5 != "" && md5 != contentMD5(body) {
+		http.Error(w, "<Error><Code>BadDigest</Code><Message>The Content-MD5 you specified did not match.</Message></Error>", http.StatusBadRequest)
+		return
+	}
+
+	call := r.Method + " " + r.URL.Path
+	if r.URL.RawQuery != "" {
+		call += "?" + r.URL.RawQuery
+	}
+	s.mu.Lock()
+	s.requests = append(s.requests, call)
+	s.bodies[call] = body
+	s.mu.Unlock()
+	s.respond(w, r, body)
+}
+
+// newFakeStore starts a store and returns a path style client for it with small parts and no retry delay.
+func newFakeStore(t *testing.T, respond func(w http.ResponseWriter, r *http.Request, body []byte)) (*fakeStore, *Client) {
+	t.Helper()
+	store := &fakeStore{bodies: map[string][]byte{}, respond: respond}
+	server := httptest.NewServer(store)
+	t.Cleanup(server.Close)
+
+	client := NewClient(Config{Endpoint: server.URL, Region: "us-east-1", AccessKeyID: "AKIDTEST", SecretAccessKey: "secret"})
+	client.HTTP = server.Client()
+	client.PartSize = 5
+	client.MaxAttempts = 3
+	client.RetryDelay = time.Millisecond
+	return store, client
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app-release.apk")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUploadFileSingle(t *testing.T) {
+	var contentType string
+	store, client := newFakeStore(t, func(w http.ResponseWriter, r *http.Request, body []byte) {
+		contentType = r.Header.Get("Content-Type")
+	})
+
+	if err := client.UploadFile("builds", "acme/1.4.2/app release.apk", writeFile(t, "apk"), "application/vnd.android.package-archive"); err != nil {
+		t.Fatal(err)
+	}
+	want := "PUT /builds/acme/1.4.2/app release.apk"
+	if len(store.requests) != 1 || store.requests[0] != want {
+		t.Fatalf("requests = %q, want %q", store.requests, want)
+	}
+	if body := string(store.bodies[want]); body != "apk" {
+		t.Errorf("body = %q, want the content of the file", body)
+	}
+	if contentType != "application/vnd.android.package-archive" {
+		t.Errorf("Content-Type = %q", contentType)
+	}
+}
+
+func TestUploadFileMultipart(t *testing.T) {
+	store, client := newFakeStore(t, func(w http.ResponseWriter, r *http.Request, body []byte) {
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			fmt.Fprint(w, `<InitiateMultipartUploadResult><Bucket>builds</Bucket><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+		case r.Method == http.MethodPut && query.Get("uploadId") == "upload-1":
+			w.Header().Set("ETag", `"etag-`+query.Get("partNumber")+`"`)
+		case r.Method == http.MethodPost && query.Get("uploadId") == "upload-1":
+			fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"etag-all"</ETag></CompleteMultipartUploadResult>`)
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	})
+
+	if err := client.UploadFile("builds", "app.apk", writeFile(t, "0123456789ab"), "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"POST /builds/app.apk?uploads=",
+		"PUT /builds/app.apk?partNumber=1&uploadId=upload-1",
+		"PUT /builds/app.apk?partNumber=2&uploadId=upload-1",
+		"PUT /builds/app.apk?partNumber=3&uploadId=upload-1",
+		"POST /builds/app.apk?uploadId=upload-1",
+	}
+	if strings.Join(store.requests, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("requests =\n%s\nwant\n%s", strings.Join(store.requests, "\n"), strings.Join(want, "\n"))
+	}
+	for i, part := range []string{"01234", "56789", "ab"} {
+		if got := string(store.bodies[want[i+1]]); got != part {
+			t.Errorf("part %d = %q, want %q", i+1, got, part)
+		}
+	}
+
+	var complete struct {
+		Parts []completedPart `xml:"Part"`
+	}
+	if err := xml.Unmarshal(store.bodies[want[4]], &complete); err != nil {
+		t.Fatal(err)
+	}
+	wantParts := []completedPart{{1, `"etag-1"`}, {2, `"etag-2"`}, {3, `"etag-3"`}}
+	if fmt.Sprint(complete.Parts) != fmt.Sprint(wantParts) {
+		t.Errorf("CompleteMultipartUpload parts = %v, want %v", complete.Parts, wantParts)
+	}
+}
+
+func TestUploadFileMultipartAborts(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    func(r *http.Request) bool
+		respond string
+		err     string
+	}{
+		{
+			name:    "part rejected",
+			fail:    func(r *http.Request) bool { return r.URL.Query().Get("partNumber") == "2" },
+			respond: "<Error><Code>EntityTooSmall</Code><Message>Your proposed upload is smaller than the minimum allowed object size.</Message></Error>",
+			err:     "failed to upload part 2 of app.apk: EntityTooSmall",
+		},
+		{
+			name: "error in a complete response",
+			fail: func(r *http.Request) bool { return false },
+			err:  "failed to complete the multipart upload of app.apk: InternalError",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store, client := newFakeStore(t, func(w http.ResponseWriter, r *http.Request, body []byte) {
+				query := r.URL.Query()
+				switch {
+				case query.Has("uploads"):
+					fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+				case test.fail(r):
+					w.WriteHeader(http.StatusBadRequest)
+					fmt.Fprint(w, test.respond)
+				case r.Method == http.MethodPost:
+					fmt.Fprint(w, `<Error><Code>InternalError</Code><Message>We encountered an internal error. Please try again.</Message></Error>`)
+				case r.Method == http.MethodDelete:
+					w.WriteHeader(http.StatusNoContent)
+				}
+			})
+
+			err := client.UploadFile("builds", "app.apk", writeFile(t, "0123456789ab"), "application/octet-stream")
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("UploadFile() = %v, want an error containing %q", err, test.err)
+			}
+			if last := store.requests[len(store.requests)-1]; last != "DELETE /builds/app.apk?uploadId=upload-1" {
+				t.Errorf("last request = %q, want the upload to be aborted", last)
+			}
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+		err      string
+	}{
+		{name: "server error then success", statuses: []int{500, 200}, attempts: 2},
+		{name: "slow down then success", statuses: []int{503, 503, 200}, attempts: 3},
+		{name: "throttled then success", statuses: []int{429, 200}, attempts: 2},
+		{name: "server errors", statuses: []int{500, 503, 500}, attempts: 3, err: "PUT /builds/app.apk failed after 3 attempts: HTTP 500"},
+		{name: "access denied", statuses: []int{403, 200}, attempts: 1, err: "AccessDenied: Access Denied (HTTP 403)"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store, client := newFakeStore(t, func(w http.ResponseWriter, r *http.Request, body []byte) {
+				status := test.statuses[0]
+				test.statuses = test.statuses[1:]
+				w.WriteHeader(status)
+				if status == http.StatusForbidden {
+					fmt.Fprint(w, "<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>")
+				}
+			})
+
+			err := client.PutObject("builds", "app.apk", []byte("apk"), "application/octet-stream")
+			if test.err == "" && err != nil {
+				t.Errorf("PutObject() = %v", err)
+			}
+			if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Errorf("PutObject() = %v, want an error containing %q", err, test.err)
+			}
+			if len(store.requests) != test.attempts {
+				t.Errorf("PutObject() took %d attempts, want %d", len(store.requests), test.attempts)
+			}
+		})
+	}
+}
+
+func TestGetObject(t *testing.T) {
+	_, client := newFakeStore(t, func(w http.ResponseWriter, r *http.Request, body []byte) {
+		if r.URL.Path != "/builds/app.apk" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>")
+			return
+		}
+		fmt.Fprint(w, "apk")
+	})
+
+	object, err := client.GetObject("builds", "app.apk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer object.Close()
+	if data, _ := io.ReadAll(object); string(data) != "apk" {
+		t.Errorf("GetObject() = %q, want %q", data, "apk")
+	}
+
+	if _, err := client.GetObject("builds", "missing.apk"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetObject() of a missing key = %v, want ErrNotFound", err)
+	}
+}
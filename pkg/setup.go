@@ -7,17 +7,52 @@ import (
 	"path/filepath"
 
 	"github.com/aman-apptile/bob/pkg/constants"
+	"github.com/aman-apptile/bob/pkg/ios"
 	"github.com/aman-apptile/bob/pkg/utils"
 )
 
-// SetupCocoapods installs CocoaPods using Ruby gem if it is not already installed.
-func SetupCocoapods() {
-	if !utils.IsGemInstalled("cocoapods") {
-		err := utils.RunCommand("sudo", "gem", "install", "cocoapods")
+// SetupCocoapods installs CocoaPods under the rbenv Ruby, without sudo. When the project has a Gemfile, Bundler
+// installs the CocoaPods version pinned in its Gemfile.lock. Otherwise the version recorded in Podfile.lock is installed as a gem.
+func SetupCocoapods(projectDir string) {
+	env := ios.RubyEnv()
+	if env == nil {
+		fmt.Println("rbenv is not set up, CocoaPods would be installed into the system Ruby. Run bob setup again once rbenv is installed.")
+		return
+	}
+
+	gemfile := ios.FindGemfile(projectDir)
+	if gemfile == "" {
+		installed := []string{"list", "--installed", "cocoapods"}
+		install := []string{"install", "cocoapods"}
+		if lock, err := ios.LoadPodfileLock(filepath.Join(projectDir, "ios", "Podfile.lock")); err == nil && lock.CocoapodsVersion != "" {
+			installed = append(installed, "-v", lock.CocoapodsVersion)
+			install = append(install, "-v", lock.CocoapodsVersion)
+		}
+		if _, err := utils.RunCommandInDirWithOutput(projectDir, env, "gem", installed...); err == nil {
+			fmt.Println("CocoaPods is already installed.")
+			return
+		}
+
+		err := utils.RunCommandInDir(projectDir, env, "gem", install...)
 		utils.CheckError(err, "Failed to install CocoaPods")
-	} else {
-		fmt.Println("CocoaPods is already installed.")
+		return
 	}
+
+	env = append(env, "BUNDLE_GEMFILE="+gemfile)
+	installed := []string{"list", "--installed", "bundler"}
+	install := []string{"install", "bundler"}
+	if lock, err := ios.LoadGemfileLock(gemfile + ".lock"); err == nil && lock.BundlerVersion != "" {
+		installed = append(installed, "-v", lock.BundlerVersion)
+		install = append(install, "-v", lock.BundlerVersion)
+	}
+	if _, err := utils.RunCommandInDirWithOutput(projectDir, env, "gem", installed...); err != nil {
+		err := utils.RunCommandInDir(projectDir, env, "gem", install...)
+		utils.CheckError(err, "Failed to install Bundler")
+	}
+
+	fmt.Println("Installing CocoaPods with Bundler...")
+	err := utils.RunCommandInDir(filepath.Dir(gemfile), env, "bundle", "install")
+	utils.CheckError(err, "Failed to install CocoaPods")
 }
 
 // SetupHomebrew installs Homebrew if not already installed.
@@ -148,16 +183,6 @@ func SetupAndroidEnvironment(homeDir string) {
 	} else {
 		fmt.Println("Android SDK is already set up.")
 	}
-
-	// if !utils.IsCommandAvailable("gradle") {
-	// 	err := utils.DownloadAndExtract("https://services.gradle.org/distributions/gradle-7.2-bin.zip", "/usr/local")
-	// 	utils.CheckError(err, "Failed to download and extract Gradle")
-
-	// 	zshrc := filepath.Join(homeDir, ".zshrc")
-	// 	utils.AppendToFile(zshrc, "export PATH=$PATH:/usr/local/gradle-7.2/bin\n")
-	// } else {
-	// 	fmt.Println("Gradle is already installed.")
-	// }
 }
 
 // SetupIosEnvironment installs or updates Xcode command line tools and accepts the license.
@@ -0,0 +1,218 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxHistory is how many runs the history file keeps, older ones are dropped.
+const maxHistory = 1000
+
+// Run is the timing of one bob run, as kept in the history file.
+type Run struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Project string    `json:"project"`
+	Seconds float64   `json:"seconds"`
+	// Steps maps the path of every span to its duration in seconds. A step that ran several times in a run,
+	// once per white-label app for instance, is summed.
+	Steps map[string]float64 `json:"steps"`
+	// Order lists the step paths in the order they first started.
+	Order []string `json:"order"`
+}
+
+// NewRun summarizes the spans of a run of the command in the project.
+func NewRun(command, project string, start time.Time, spans []*Span) Run {
+	run := Run{
+		Time:    start.UTC(),
+		Command: command,
+		Project: project,
+		Seconds: round(time.Since(start).Seconds()),
+		Steps:   map[string]float64{},
+	}
+	for _, span := range spans {
+		if _, ok := run.Steps[span.Path]; !ok {
+			run.Order = append(run.Order, span.Path)
+		}
+		run.Steps[span.Path] = round(run.Steps[span.Path] + span.Duration().Seconds())
+	}
+	return run
+}
+
+func round(seconds float64) float64 {
+	return float64(int64(seconds*1000+0.5)) / 1000
+}
+
+// DefaultHistoryFile returns where the timings of past runs are kept, in the user's cache directory.
+func DefaultHistoryFile() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bob", "timings.jsonl"), nil
+}
+
+// ReadHistory reads the runs in the history file, oldest first. A missing file is an empty history.
+func ReadHistory(path string) ([]Run, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var run Run
+		// Skip lines a crashed run left half written.
+		if json.Unmarshal(scanner.Bytes(), &run) == nil {
+			runs = append(runs, run)
+		}
+	}
+	return runs, scanner.Err()
+}
+
+// AppendHistory adds the run to the history file, dropping the oldest runs beyond maxHistory.
+func AppendHistory(path string, run Run) error {
+	runs, err := ReadHistory(path)
+	if err != nil {
+		return err
+	}
+	runs = append(runs, run)
+	if len(runs) > maxHistory {
+		runs = runs[len(runs)-maxHistory:]
+	}
+
+	var b strings.Builder
+	for _, run := range runs {
+		data, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		b.WriteString("\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write the timing history: %v", err)
+	}
+	return os.Rename(temp, path)
+}
+
+// StepStats summarizes the durations of a step across runs.
+type StepStats struct {
+	Path   string
+	Runs   int
+	Last   float64
+	Median float64
+	Min    float64
+	Max    float64
+	// Previous is the median of the runs before the last one, 0 when there are none.
+	Previous float64
+	// Durations are the durations of the runs that had the step, oldest first.
+	Durations []float64
+}
+
+// Change returns how much slower, or faster when negative, the last run was than the earlier ones, as a fraction.
+func (s StepStats) Change() float64 {
+	if s.Previous == 0 {
+		return 0
+	}
+	return (s.Last - s.Previous) / s.Previous
+}
+
+// Regression reports whether the last run of the step was markedly slower than the earlier ones: by a fifth
+// and by more than two seconds, so that noise on short steps is not flagged.
+func (s StepStats) Regression() bool {
+	return s.Runs > 1 && s.Change() > 0.2 && s.Last-s.Previous > 2
+}
+
+// Stats computes the statistics of every step of the runs, in the order the steps ran in the most recent run,
+// followed by steps that only ran earlier.
+func Stats(runs []Run) []StepStats {
+	var order []string
+	seen := map[string]bool{}
+	for i := len(runs) - 1; i >= 0; i-- {
+		for _, path := range runs[i].Order {
+			if !seen[path] {
+				seen[path] = true
+				order = append(order, path)
+			}
+		}
+	}
+
+	var stats []StepStats
+	for _, path := range order {
+		s := StepStats{Path: path}
+		for _, run := range runs {
+			if seconds, ok := run.Steps[path]; ok {
+				s.Durations = append(s.Durations, seconds)
+			}
+		}
+		s.Runs = len(s.Durations)
+		s.Last = s.Durations[s.Runs-1]
+		s.Median = median(s.Durations)
+		s.Min, s.Max = s.Durations[0], s.Durations[0]
+		for _, seconds := range s.Durations {
+			s.Min = min(s.Min, seconds)
+			s.Max = max(s.Max, seconds)
+		}
+		if s.Runs > 1 {
+			s.Previous = median(s.Durations[:s.Runs-1])
+		}
+		stats = append(stats, s)
+	}
+	return stats
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// sparkBlocks draw a trend from the lowest to the highest value.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws the values as a line of block characters, scaled between their minimum and maximum.
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := values[0], values[0]
+	for _, value := range values {
+		low = min(low, value)
+		high = max(high, value)
+	}
+
+	var b strings.Builder
+	for _, value := range values {
+		index := 0
+		if high > low {
+			index = int((value - low) / (high - low) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[index])
+	}
+	return b.String()
+}
@@ -0,0 +1,181 @@
+package trace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewRun(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	span := func(path string, seconds float64) *Span {
+		at := start.Add(time.Second)
+		return &Span{Name: filepath.Base(path), Path: path, Start: at, End: at.Add(time.Duration(seconds * float64(time.Second)))}
+	}
+	// The apps of a white-label build each run the Gradle step.
+	spans := []*Span{
+		span("acme", 40),
+		span("acme/gradle", 30.5),
+		span("globex", 50),
+		span("globex/gradle", 41.25),
+		span("upload", 2.0004),
+		span("acme", 10),
+	}
+
+	run := NewRun("build", "/projects/acme", start, spans)
+	want := map[string]float64{"acme": 50, "acme/gradle": 30.5, "globex": 50, "globex/gradle": 41.25, "upload": 2}
+	if !reflect.DeepEqual(run.Steps, want) {
+		t.Errorf("Steps = %v, want %v", run.Steps, want)
+	}
+	if want := []string{"acme", "acme/gradle", "globex", "globex/gradle", "upload"}; !reflect.DeepEqual(run.Order, want) {
+		t.Errorf("Order = %q, want %q", run.Order, want)
+	}
+	if run.Command != "build" || run.Project != "/projects/acme" || !run.Time.Equal(start) || run.Time.Location() != time.UTC {
+		t.Errorf("NewRun() = %+v", run)
+	}
+	if run.Seconds < 60 || run.Seconds > 70 {
+		t.Errorf("Seconds = %v, want the time since the start", run.Seconds)
+	}
+}
+
+func TestAppendHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bob", "timings.jsonl")
+	if runs, err := ReadHistory(path); err != nil || runs != nil {
+		t.Errorf("ReadHistory() of a missing file = %v, %v, want an empty history", runs, err)
+	}
+
+	// The first run creates the directory of the history file.
+	if err := AppendHistory(path, Run{Command: "0"}); err != nil {
+		t.Fatal(err)
+	}
+	// A full history, the next run drops the oldest one.
+	var b strings.Builder
+	for i := 0; i < maxHistory; i++ {
+		fmt.Fprintf(&b, "{\"command\":\"%d\",\"seconds\":%d}\n", i, i)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := AppendHistory(path, Run{Command: fmt.Sprint(maxHistory)}); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := ReadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != maxHistory || runs[0].Command != "1" || runs[len(runs)-1].Command != fmt.Sprint(maxHistory) {
+		t.Errorf("ReadHistory() = %d runs from %s to %s, want the last %d", len(runs), runs[0].Command, runs[len(runs)-1].Command, maxHistory)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("the temporary file was left behind")
+	}
+}
+
+func TestReadHistorySkipsTornLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "timings.jsonl")
+	content := `{"command":"build","seconds":90}
+{"command":"deps","seconds":12}
+{"command":"build","sec
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AppendHistory(path, Run{Command: "upload", Seconds: 30}); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := ReadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, run := range runs {
+		got = append(got, run.Command)
+	}
+	if want := []string{"build", "deps", "upload"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadHistory() = %q, want %q", got, want)
+	}
+	if data, _ := os.ReadFile(path); strings.Contains(string(data), `"sec`+"\n") {
+		t.Errorf("the torn line was kept:\n%s", data)
+	}
+}
+
+func TestStats(t *testing.T) {
+	runs := []Run{
+		{Steps: map[string]float64{"deps": 10, "android": 100, "lint": 5}, Order: []string{"lint", "deps", "android"}},
+		{Steps: map[string]float64{"deps": 14, "android": 120}, Order: []string{"deps", "android"}},
+		{Steps: map[string]float64{"deps": 12, "android": 110}, Order: []string{"deps", "android"}},
+		{Steps: map[string]float64{"android": 150, "deps": 13, "ios": 80}, Order: []string{"android", "ios", "deps"}},
+	}
+
+	stats := Stats(runs)
+	var order []string
+	for _, s := range stats {
+		order = append(order, s.Path)
+	}
+	// The order of the latest run, then steps that only ran before.
+	if want := []string{"android", "ios", "deps", "lint"}; !reflect.DeepEqual(order, want) {
+		t.Fatalf("Stats() ordered %q, want %q", order, want)
+	}
+
+	want := []StepStats{
+		{Path: "android", Runs: 4, Last: 150, Median: 115, Min: 100, Max: 150, Previous: 110, Durations: []float64{100, 120, 110, 150}},
+		{Path: "ios", Runs: 1, Last: 80, Median: 80, Min: 80, Max: 80, Durations: []float64{80}},
+		{Path: "deps", Runs: 4, Last: 13, Median: 12.5, Min: 10, Max: 14, Previous: 12, Durations: []float64{10, 14, 12, 13}},
+		{Path: "lint", Runs: 1, Last: 5, Median: 5, Min: 5, Max: 5, Durations: []float64{5}},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("Stats() =\n%+v\nwant\n%+v", stats, want)
+	}
+
+	if got := Stats(nil); got != nil {
+		t.Errorf("Stats() of no runs = %v, want none", got)
+	}
+}
+
+func TestRegression(t *testing.T) {
+	tests := []struct {
+		name       string
+		stats      StepStats
+		change     float64
+		regression bool
+	}{
+		{name: "much slower", stats: StepStats{Runs: 5, Last: 150, Previous: 110}, change: 40.0 / 110, regression: true},
+		{name: "slower by a fifth or less", stats: StepStats{Runs: 5, Last: 132, Previous: 110}, change: 0.2},
+		{name: "slower by two seconds or less", stats: StepStats{Runs: 5, Last: 3.9, Previous: 2}, change: 0.95},
+		{name: "faster", stats: StepStats{Runs: 5, Last: 80, Previous: 110}, change: -30.0 / 110},
+		{name: "first run", stats: StepStats{Runs: 1, Last: 150}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.stats.Change(); fmt.Sprintf("%.6f", got) != fmt.Sprintf("%.6f", test.change) {
+				t.Errorf("Change() = %v, want %v", got, test.change)
+			}
+			if got := test.stats.Regression(); got != test.regression {
+				t.Errorf("Regression() = %t, want %t", got, test.regression)
+			}
+		})
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   string
+	}{
+		{values: nil, want: ""},
+		{values: []float64{42}, want: "▁"},
+		{values: []float64{5, 5, 5}, want: "▁▁▁"},
+		{values: []float64{0, 1, 2, 3, 4, 5, 6, 7}, want: "▁▂▃▄▅▆▇█"},
+		{values: []float64{100, 150, 125}, want: "▁█▄"},
+	}
+	for _, test := range tests {
+		if got := Sparkline(test.values); got != test.want {
+			t.Errorf("Sparkline(%v) = %q, want %q", test.values, got, test.want)
+		}
+	}
+}
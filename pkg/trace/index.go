@@ -0,0 +1,178 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span is a timed step of a bob run, such as a Gradle build or a pod install.
+type Span struct {
+	Name string
+	// Path names the span and the spans it is nested in, e.g. "android/gradle".
+	Path  string
+	Depth int
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns how long the span took, or has taken so far when it is still open.
+func (s *Span) Duration() time.Duration {
+	if s.End.IsZero() {
+		return time.Since(s.Start)
+	}
+	return s.End.Sub(s.Start)
+}
+
+// Recorder collects the spans of a run. Spans started while another is open are nested in it.
+type Recorder struct {
+	mu    sync.Mutex
+	start time.Time
+	spans []*Span
+	open  []*Span
+}
+
+// NewRecorder returns a recorder whose run starts now.
+func NewRecorder() *Recorder {
+	return &Recorder{start: time.Now()}
+}
+
+var std = NewRecorder()
+
+// Start opens a span of the default recorder. Close it with End, usually deferred:
+//
+//	defer trace.Start("gradle").End()
+func Start(name string) *Handle {
+	return std.Start(name)
+}
+
+// Spans returns the spans of the default recorder in the order they started.
+func Spans() []*Span {
+	return std.Spans()
+}
+
+// RunStart returns when the run of the default recorder started.
+func RunStart() time.Time {
+	return std.start
+}
+
+// Handle ends a span.
+type Handle struct {
+	recorder *Recorder
+	span     *Span
+}
+
+// Start opens a span nested in the innermost open span.
+func (r *Recorder) Start(name string) *Handle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	span := &Span{Name: name, Path: name, Start: time.Now()}
+	if len(r.open) > 0 {
+		parent := r.open[len(r.open)-1]
+		span.Path = parent.Path + "/" + name
+		span.Depth = parent.Depth + 1
+	}
+	r.spans = append(r.spans, span)
+	r.open = append(r.open, span)
+	return &Handle{recorder: r, span: span}
+}
+
+// End closes the span, along with any span nested in it that was left open.
+func (h *Handle) End() {
+	r := h.recorder
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !h.span.End.IsZero() {
+		return
+	}
+	now := time.Now()
+	for i := len(r.open) - 1; i >= 0; i-- {
+		span := r.open[i]
+		span.End = now
+		r.open = r.open[:i]
+		if span == h.span {
+			break
+		}
+	}
+}
+
+// Spans returns the spans in the order they started.
+func (r *Recorder) Spans() []*Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*Span(nil), r.spans...)
+}
+
+// WriteTable writes the duration of every span, indented by nesting, with its share of the total run time.
+func WriteTable(w io.Writer, spans []*Span, total time.Duration) {
+	width := len("Total")
+	for _, span := range spans {
+		if n := 2*span.Depth + len(span.Name); n > width {
+			width = n
+		}
+	}
+
+	fmt.Fprintf(w, "%-*s  %10s  %5s\n", width, "Step", "Duration", "Share")
+	for _, span := range spans {
+		share := 0.0
+		if total > 0 {
+			share = float64(span.Duration()) / float64(total) * 100
+		}
+		name := strings.Repeat("  ", span.Depth) + span.Name
+		fmt.Fprintf(w, "%-*s  %10s  %4.0f%%\n", width, name, FormatDuration(span.Duration()), share)
+	}
+	fmt.Fprintf(w, "%-*s  %10s\n", width, "Total", FormatDuration(total))
+}
+
+// FormatDuration rounds a duration for display, to milliseconds below a second and to tenths of seconds above.
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// chromeEvent is an event of the Chrome trace event format, which Perfetto and chrome://tracing open.
+type chromeEvent struct {
+	Name      string         `json:"name"`
+	Category  string         `json:"cat,omitempty"`
+	Phase     string         `json:"ph"`
+	Timestamp int64          `json:"ts"`
+	Duration  int64          `json:"dur,omitempty"`
+	PID       int            `json:"pid"`
+	TID       int            `json:"tid"`
+	Args      map[string]any `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the spans as complete events of the Chrome trace event format, with timestamps in
+// microseconds since the run started.
+func WriteChromeTrace(path, process string, start time.Time, spans []*Span) error {
+	events := []chromeEvent{{Name: "process_name", Phase: "M", PID: 1, TID: 1, Args: map[string]any{"name": process}}}
+	for _, span := range spans {
+		events = append(events, chromeEvent{
+			Name:      span.Name,
+			Category:  "bob",
+			Phase:     "X",
+			Timestamp: span.Start.Sub(start).Microseconds(),
+			Duration:  span.Duration().Microseconds(),
+			PID:       1,
+			TID:       1,
+			Args:      map[string]any{"path": span.Path},
+		})
+	}
+
+	data, err := json.MarshalIndent(map[string]any{"traceEvents": events, "displayTimeUnit": "ms"}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write the trace: %v", err)
+	}
+	return nil
+}
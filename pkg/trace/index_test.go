@@ -0,0 +1,136 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// paths returns the path and depth of each span.
+func paths(spans []*Span) []string {
+	var got []string
+	for _, span := range spans {
+		got = append(got, fmt.Sprintf("%s@%d", span.Path, span.Depth))
+	}
+	return got
+}
+
+func TestRecorderNesting(t *testing.T) {
+	r := NewRecorder()
+	build := r.Start("build")
+	android := r.Start("android")
+	r.Start("gradle").End()
+	r.Start("sign").End()
+	android.End()
+	r.Start("ios").End()
+	build.End()
+	r.Start("upload").End()
+
+	want := []string{"build@0", "build/android@1", "build/android/gradle@2", "build/android/sign@2", "build/ios@1", "upload@0"}
+	if got := paths(r.Spans()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Spans() = %q, want %q", got, want)
+	}
+	for _, span := range r.Spans() {
+		if span.End.IsZero() || span.End.Before(span.Start) {
+			t.Errorf("%s ran from %v to %v", span.Path, span.Start, span.End)
+		}
+	}
+}
+
+func TestEndClosesOpenChildren(t *testing.T) {
+	r := NewRecorder()
+	build := r.Start("build")
+	r.Start("android")
+	r.Start("gradle")
+	build.End()
+
+	spans := r.Spans()
+	for _, span := range spans {
+		if span.End.IsZero() {
+			t.Errorf("%s was left open", span.Path)
+		}
+	}
+	if !spans[1].End.Equal(spans[0].End) || !spans[2].End.Equal(spans[0].End) {
+		t.Errorf("the children ended at %v and %v, want %v", spans[1].End, spans[2].End, spans[0].End)
+	}
+
+	// Spans started afterwards are not nested in the closed ones, and ending a span twice keeps its first end.
+	end := spans[0].End
+	r.Start("upload").End()
+	build.End()
+	if got := r.Spans()[3].Path; got != "upload" {
+		t.Errorf("a span started after End has the path %q, want upload", got)
+	}
+	if !spans[0].End.Equal(end) {
+		t.Errorf("ending build again moved its end from %v to %v", end, spans[0].End)
+	}
+}
+
+func TestEndOfChildKeepsParentOpen(t *testing.T) {
+	r := NewRecorder()
+	r.Start("build")
+	r.Start("android").End()
+	r.Start("ios")
+
+	spans := r.Spans()
+	if !spans[0].End.IsZero() {
+		t.Error("ending a child closed its parent")
+	}
+	if got := spans[2].Path; got != "build/ios" {
+		t.Errorf("the span after a closed child has the path %q, want build/ios", got)
+	}
+}
+
+func TestWriteChromeTrace(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	spans := []*Span{
+		{Name: "build", Path: "build", Start: start, End: start.Add(3 * time.Second)},
+		{Name: "gradle", Path: "build/gradle", Depth: 1, Start: start.Add(250 * time.Millisecond), End: start.Add(2500 * time.Millisecond)},
+	}
+	path := filepath.Join(t.TempDir(), "trace.json")
+	if err := WriteChromeTrace(path, "bob build", start, spans); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"displayTimeUnit": "ms",
+		"traceEvents": []any{
+			map[string]any{"name": "process_name", "ph": "M", "ts": 0.0, "pid": 1.0, "tid": 1.0, "args": map[string]any{"name": "bob build"}},
+			map[string]any{"name": "build", "cat": "bob", "ph": "X", "ts": 0.0, "dur": 3e6, "pid": 1.0, "tid": 1.0, "args": map[string]any{"path": "build"}},
+			map[string]any{"name": "gradle", "cat": "bob", "ph": "X", "ts": 250e3, "dur": 2.25e6, "pid": 1.0, "tid": 1.0, "args": map[string]any{"path": "build/gradle"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteChromeTrace() wrote\n%s\nwant %v", data, want)
+	}
+
+	if err := WriteChromeTrace(filepath.Join(t.TempDir(), "missing", "trace.json"), "bob build", start, spans); err == nil {
+		t.Error("WriteChromeTrace() into a missing directory succeeded")
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		1234567 * time.Nanosecond:             "1ms",
+		850 * time.Millisecond:                "850ms",
+		1249 * time.Millisecond:               "1.2s",
+		95*time.Second + 260*time.Millisecond: "1m35.3s",
+	}
+	for d, want := range tests {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
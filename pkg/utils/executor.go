@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+)
+
+// Executor runs external commands. Code that drives several tools through a longer sequence of steps takes an
+// Executor rather than calling the Run helpers directly, so that fakes can stand in for the tools.
+type Executor interface {
+	// Run runs the command in dir with extra environment variables and streams its output.
+	Run(dir string, env []string, command string, args ...string) error
+	// Output runs the command in dir with extra environment variables and returns its combined output.
+	Output(dir string, env []string, command string, args ...string) (string, error)
+	// Capture runs the command like Run, streaming its output, and also returns that output.
+	Capture(dir string, env []string, command string, args ...string) (string, error)
+	// Start starts the command in the background with its output going to logFile and returns without waiting for it.
+	Start(dir string, env []string, logFile string, command string, args ...string) error
+}
+
+// SystemExecutor is the Executor that runs commands on the machine.
+type SystemExecutor struct{}
+
+func (SystemExecutor) Run(dir string, env []string, command string, args ...string) error {
+	return RunCommandInDir(dir, env, command, args...)
+}
+
+func (SystemExecutor) Output(dir string, env []string, command string, args ...string) (string, error) {
+	return RunCommandInDirWithOutput(dir, env, command, args...)
+}
+
+func (SystemExecutor) Capture(dir string, env []string, command string, args ...string) (string, error) {
+	return RunCommandInDirAndCapture(dir, env, command, args...)
+}
+
+func (SystemExecutor) Start(dir string, env []string, logFile string, command string, args ...string) error {
+	log, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", logFile, err)
+	}
+	defer log.Close()
+
+	cmd := commandInDir(dir, env, command, args...)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
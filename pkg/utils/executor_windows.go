@@ -0,0 +1,11 @@
+package utils
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach puts the command in a process group of its own, which keeps it running when bob is interrupted.
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
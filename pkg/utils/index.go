@@ -2,6 +2,7 @@ package utils
 
 import (
 	"archive/zip"
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/gernest/wow"
 	"github.com/gernest/wow/spin"
@@ -44,12 +46,80 @@ func AppendToFile(filename, content string) {
 
 // RunCommand executes a shell command and streams its output.
 func RunCommand(command string, args ...string) error {
-	cmd := exec.Command(command, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	return RunCommandInDir("", nil, command, args...)
+}
+
+// RunCommandInDir executes a shell command in the given directory with extra environment variables and streams its output.
+// Pass secrets through env rather than args so they never show up on the command line.
+func RunCommandInDir(dir string, env []string, command string, args ...string) error {
+	cmd := commandInDir(dir, env, command, args...)
+
+	stdout, flushStdout := maskedWriter(os.Stdout)
+	stderr, flushStderr := maskedWriter(os.Stderr)
+	defer flushStdout()
+	defer flushStderr()
+	cmd.Stdout = io.MultiWriter(stdout, recentOutput)
+	cmd.Stderr = io.MultiWriter(stderr, recentOutput)
 	return cmd.Run()
 }
 
+// RunCommandInDirAndCapture executes a shell command like RunCommandInDir and also returns its combined output,
+// for callers that need to inspect the output of a long running command.
+func RunCommandInDirAndCapture(dir string, env []string, command string, args ...string) (string, error) {
+	cmd := commandInDir(dir, env, command, args...)
+
+	output := &syncBuffer{}
+	stdout, flushStdout := maskedWriter(os.Stdout)
+	stderr, flushStderr := maskedWriter(os.Stderr)
+	defer flushStdout()
+	defer flushStderr()
+	cmd.Stdout = io.MultiWriter(stdout, output, recentOutput)
+	cmd.Stderr = io.MultiWriter(stderr, output, recentOutput)
+	err := cmd.Run()
+	return output.String(), err
+}
+
+// syncBuffer is a buffer that stdout and stderr can be copied into concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// commandInDir prepares a command in the given directory with extra environment variables. When env sets PATH,
+// the command is looked up on that PATH, so that a pinned toolchain put first on it is the one that runs.
+func commandInDir(dir string, env []string, command string, args ...string) *exec.Cmd {
+	if !strings.ContainsRune(command, filepath.Separator) {
+		for _, variable := range env {
+			if path, found := strings.CutPrefix(variable, "PATH="); found {
+				for _, pathDir := range filepath.SplitList(path) {
+					candidate := filepath.Join(pathDir, command)
+					if info, err := os.Stat(candidate); err == nil && !info.IsDir() && info.Mode()&0111 != 0 {
+						command = candidate
+						break
+					}
+				}
+			}
+		}
+	}
+
+	cmd := exec.Command(command, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	return cmd
+}
+
 // RunCommandWithOutput executes a shell command and returns its output.
 func RunCommandWithOutput(command string, args ...string) (string, error) {
 	cmd := exec.Command(command, args...)
@@ -57,6 +127,13 @@ func RunCommandWithOutput(command string, args ...string) (string, error) {
 	return string(output), err
 }
 
+// RunCommandInDirWithOutput executes a shell command in the given directory with extra environment variables and returns its output.
+func RunCommandInDirWithOutput(dir string, env []string, command string, args ...string) (string, error) {
+	cmd := commandInDir(dir, env, command, args...)
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
 // InstallPackage simplified error handling.
 func InstallPackage(pkg string) {
 	fmt.Printf("Installing %s...\n", pkg)
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+)
+
+const maskedValue = "****"
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+// MaskSecrets registers values that are replaced with **** in everything printed by the commands run
+// through this package, so that secrets passed to a build never end up in CI logs.
+func MaskSecrets(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, value := range values {
+		// Masking very short values would garble unrelated output.
+		if len(value) >= 4 {
+			secrets = append(secrets, value)
+		}
+	}
+}
+
+// Mask replaces the registered secrets in s.
+func Mask(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, maskedValue)
+	}
+	return s
+}
+
+func hasSecrets() bool {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	return len(secrets) > 0
+}
+
+// maskWriter masks secrets in a stream. It holds back partial lines so that a secret split across
+// two writes is still masked.
+type maskWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// maskedWriter wraps w when secrets are registered. The returned flush function writes any held back output.
+func maskedWriter(w io.Writer) (io.Writer, func()) {
+	if !hasSecrets() {
+		return w, func() {}
+	}
+	mw := &maskWriter{w: w}
+	return mw, mw.flush
+}
+
+func (m *maskWriter) Write(p []byte) (int, error) {
+	m.buf = append(m.buf, p...)
+	end := bytes.LastIndexAny(m.buf, "\r\n")
+	if end < 0 {
+		return len(p), nil
+	}
+
+	if _, err := io.WriteString(m.w, Mask(string(m.buf[:end+1]))); err != nil {
+		return 0, err
+	}
+	m.buf = append(m.buf[:0], m.buf[end+1:]...)
+	return len(p), nil
+}
+
+func (m *maskWriter) flush() {
+	if len(m.buf) > 0 {
+		io.WriteString(m.w, Mask(string(m.buf)))
+		m.buf = m.buf[:0]
+	}
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// withSecrets registers secrets for the duration of a test.
+func withSecrets(t *testing.T, values ...string) {
+	t.Helper()
+	secretsMu.Lock()
+	saved := secrets
+	secrets = nil
+	secretsMu.Unlock()
+	t.Cleanup(func() {
+		secretsMu.Lock()
+		secrets = saved
+		secretsMu.Unlock()
+	})
+	MaskSecrets(values...)
+}
+
+func TestMask(t *testing.T) {
+	withSecrets(t, "hunter2!", "sk_live_123", "abc")
+
+	tests := map[string]string{
+		"password=hunter2!":                  "password=****",
+		"sk_live_123 and sk_live_123 again":  "**** and **** again",
+		"abc is too short to be masked":      "abc is too short to be masked",
+		"nothing to hide":                    "nothing to hide",
+		"-Pandroid.injected.signing=hunter2": "-Pandroid.injected.signing=hunter2",
+	}
+	for input, want := range tests {
+		if got := Mask(input); got != want {
+			t.Errorf("Mask(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestMaskWriter(t *testing.T) {
+	withSecrets(t, "sk_live_123")
+
+	tests := []struct {
+		name   string
+		writes []string
+		// want is what has been written before the flush, all is what has been written after it.
+		want, all string
+	}{
+		{
+			name:   "secret split across writes",
+			writes: []string{"key sk_li", "ve_123 used\n"},
+			want:   "key **** used\n",
+			all:    "key **** used\n",
+		},
+		{
+			name:   "partial line held back",
+			writes: []string{"line one\nkey sk_live", "_123"},
+			want:   "line one\n",
+			all:    "line one\nkey ****",
+		},
+		{
+			name:   "progress lines",
+			writes: []string{"upload sk_live_123 10%\r", "upload sk_live_123 50%\r", "upload sk_"},
+			want:   "upload **** 10%\rupload **** 50%\r",
+			all:    "upload **** 10%\rupload **** 50%\rupload sk_",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w, flush := maskedWriter(&out)
+			for _, write := range test.writes {
+				if n, err := w.Write([]byte(write)); err != nil || n != len(write) {
+					t.Fatalf("Write(%q) = %d, %v", write, n, err)
+				}
+			}
+			if out.String() != test.want {
+				t.Errorf("before the flush got %q, want %q", out.String(), test.want)
+			}
+			flush()
+			if out.String() != test.all {
+				t.Errorf("after the flush got %q, want %q", out.String(), test.all)
+			}
+		})
+	}
+}
+
+func TestMaskedWriterWithoutSecrets(t *testing.T) {
+	withSecrets(t)
+	if w, _ := maskedWriter(os.Stdout); w != os.Stdout {
+		t.Errorf("maskedWriter() = %T, want the writer itself when no secrets are registered", w)
+	}
+}
@@ -0,0 +1,38 @@
+package utils
+
+// ClosestMatch returns the candidate with the smallest edit distance to input, or "" when none is reasonably close.
+func ClosestMatch(input string, candidates []string) string {
+	best := ""
+	bestDistance := len(input)/2 + 2
+	for _, candidate := range candidates {
+		if distance := editDistance(input, candidate); distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+
+	return best
+}
+
+// editDistance computes the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
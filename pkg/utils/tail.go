@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"strings"
+	"sync"
+)
+
+// outputTailSize is how much of the most recent command output is kept for failure reports.
+const outputTailSize = 64 << 10
+
+var recentOutput = &tailBuffer{}
+
+// tailBuffer keeps the last outputTailSize bytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if excess := len(t.buf) - outputTailSize; excess > 0 {
+		t.buf = append(t.buf[:0], t.buf[excess:]...)
+	}
+	return len(p), nil
+}
+
+// OutputTail returns the last lines printed by the commands run through this package, with secrets masked.
+func OutputTail(lines int) string {
+	recentOutput.mu.Lock()
+	output := string(recentOutput.buf)
+	recentOutput.mu.Unlock()
+
+	all := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	return Mask(strings.Join(all, "\n"))
+}
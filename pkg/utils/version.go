@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares two dotted version strings numerically.
+// It returns -1 if a < b, 0 if a == b and 1 if a > b. Missing components are treated as zero.
+func CompareVersions(a, b string) int {
+	partsA := strings.Split(strings.TrimPrefix(strings.TrimSpace(a), "v"), ".")
+	partsB := strings.Split(strings.TrimPrefix(strings.TrimSpace(b), "v"), ".")
+
+	for i := 0; i < len(partsA) || i < len(partsB); i++ {
+		var numA, numB int
+		if i < len(partsA) {
+			numA = leadingNumber(partsA[i])
+		}
+		if i < len(partsB) {
+			numB = leadingNumber(partsB[i])
+		}
+
+		if numA < numB {
+			return -1
+		}
+		if numA > numB {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// MajorVersion returns the first component of a dotted version string.
+func MajorVersion(version string) int {
+	major, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	return leadingNumber(major)
+}
+
+// leadingNumber parses the digits at the start of a version component, so "3-rc1" becomes 3.
+func leadingNumber(part string) int {
+	end := 0
+	for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+		end++
+	}
+
+	n, _ := strconv.Atoi(part[:end])
+	return n
+}
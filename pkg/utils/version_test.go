@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.15.2", "1.15.2", 0},
+		{"1.15", "1.15.0", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.9", "1.10", -1},
+		{"13.4", "12.4", 1},
+		{"15.0.1", "15", 1},
+		{"8.0.0-rc1", "8.0.0", 0},
+		{"0.74.1", "0.73.9", 1},
+		{" 2.7.6\n", "2.7.10", -1},
+		{"", "0", 0},
+	}
+
+	for _, test := range tests {
+		if got := CompareVersions(test.a, test.b); got != test.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestMajorVersion(t *testing.T) {
+	tests := map[string]int{
+		"15.4":     15,
+		"v18.19.0": 18,
+		"17":       17,
+		"3-rc1.2":  3,
+		"beta":     0,
+		"":         0,
+	}
+
+	for version, want := range tests {
+		if got := MajorVersion(version); got != want {
+			t.Errorf("MajorVersion(%q) = %d, want %d", version, got, want)
+		}
+	}
+}
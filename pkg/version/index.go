@@ -0,0 +1,294 @@
+package version
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/ios"
+	"github.com/aman-apptile/bob/pkg/utils"
+)
+
+// Version is a marketing version together with the build number the stores use to order uploads.
+type Version struct {
+	Name  string
+	Build int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%s (%d)", v.Name, v.Build)
+}
+
+// Location is a single place in the project where a version is recorded.
+type Location struct {
+	File    string
+	Version Version
+	// HasBuild is false for files such as package.json that only record the marketing version.
+	HasBuild bool
+}
+
+// versionField rewrites one value in a file while leaving everything around it untouched.
+// The pattern must capture the text before the value, the value itself and the text after it.
+type versionField struct {
+	pattern *regexp.Regexp
+	// anchor limits the search to the text following its first match, e.g. the defaultConfig block.
+	anchor *regexp.Regexp
+	all    bool
+}
+
+var (
+	packageJSONVersion = versionField{pattern: regexp.MustCompile(`("version"\s*:\s*")([^"]*)(")`)}
+
+	gradleVersionName = versionField{
+		pattern: regexp.MustCompile(`(versionName\s*=?\s*["'])([^"']*)(["'])`),
+		anchor:  regexp.MustCompile(`defaultConfig\s*\{`),
+	}
+	gradleVersionCode = versionField{
+		pattern: regexp.MustCompile(`(versionCode\s*=?\s*)(\d+)()`),
+		anchor:  regexp.MustCompile(`defaultConfig\s*\{`),
+	}
+
+	pbxprojMarketingVersion = versionField{pattern: regexp.MustCompile(`(MARKETING_VERSION = "?)([^";]+)("?;)`), all: true}
+	pbxprojBuildNumber      = versionField{pattern: regexp.MustCompile(`(CURRENT_PROJECT_VERSION = "?)([^";]+)("?;)`), all: true}
+
+	plistShortVersion = versionField{pattern: regexp.MustCompile(`(<key>CFBundleShortVersionString</key>\s*<string>)([^<]*)(</string>)`)}
+	plistBuildNumber  = versionField{pattern: regexp.MustCompile(`(<key>CFBundleVersion</key>\s*<string>)([^<]*)(</string>)`)}
+)
+
+// find returns the current value of the field, or "" when it is absent. Like replace, it skips values that
+// reference build settings, such as a test target's CURRENT_PROJECT_VERSION = "$(inherited)".
+func (f versionField) find(content string) string {
+	start := f.start(content)
+	if start < 0 {
+		return ""
+	}
+
+	matches := f.pattern.FindAllStringSubmatch(content[start:], -1)
+	for _, match := range matches {
+		if !strings.HasPrefix(match[2], "$") {
+			return match[2]
+		}
+	}
+	if len(matches) > 0 {
+		return matches[0][2]
+	}
+	return ""
+}
+
+// replace sets the value of the field, skipping values that reference build settings such as $(MARKETING_VERSION).
+func (f versionField) replace(content, value string) string {
+	start := f.start(content)
+	if start < 0 {
+		return content
+	}
+
+	replaced := 0
+	rest := f.pattern.ReplaceAllStringFunc(content[start:], func(match string) string {
+		groups := f.pattern.FindStringSubmatch(match)
+		if (!f.all && replaced > 0) || strings.HasPrefix(groups[2], "$") {
+			return match
+		}
+		replaced++
+		return groups[1] + value + groups[3]
+	})
+
+	return content[:start] + rest
+}
+
+func (f versionField) start(content string) int {
+	if f.anchor == nil {
+		return 0
+	}
+
+	loc := f.anchor.FindStringIndex(content)
+	if loc == nil {
+		return -1
+	}
+	return loc[1]
+}
+
+// versionFile describes where a file keeps its marketing version and, optionally, its build number.
+type versionFile struct {
+	path  string
+	name  versionField
+	build *versionField
+}
+
+// versionFiles lists the files of a React Native project that record the app version.
+func versionFiles(projectDir string) []versionFile {
+	files := []versionFile{
+		{path: filepath.Join(projectDir, "package.json"), name: packageJSONVersion},
+		{path: filepath.Join(projectDir, "android", "app", "build.gradle"), name: gradleVersionName, build: &gradleVersionCode},
+	}
+
+	iosDir := filepath.Join(projectDir, "ios")
+	xcodeproj, err := ios.FindXcodeProject(iosDir)
+	if err != nil {
+		return files
+	}
+	files = append(files, versionFile{path: filepath.Join(xcodeproj, "project.pbxproj"), name: pbxprojMarketingVersion, build: &pbxprojBuildNumber})
+
+	// Info.plist only holds the version when it has not been moved to build settings.
+	if project, err := ios.LoadProject(xcodeproj); err == nil {
+		if infoPlist := project.Setting(project.MainTarget(), "Release", "INFOPLIST_FILE"); infoPlist != "" {
+			infoPlist = strings.ReplaceAll(infoPlist, "$(SRCROOT)/", "")
+			files = append(files, versionFile{path: filepath.Join(iosDir, infoPlist), name: plistShortVersion, build: &plistBuildNumber})
+		}
+	}
+
+	return files
+}
+
+// Read returns the version recorded in every file of the project that has one.
+func Read(projectDir string) ([]Location, error) {
+	var locations []Location
+	for _, file := range versionFiles(projectDir) {
+		data, err := os.ReadFile(file.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", file.path, err)
+		}
+
+		content := string(data)
+		location := Location{File: file.path, Version: Version{Name: file.name.find(content)}}
+		if file.build != nil {
+			location.HasBuild = true
+			location.Version.Build, _ = strconv.Atoi(file.build.find(content))
+		}
+		if location.Version.Name == "" || strings.HasPrefix(location.Version.Name, "$") {
+			continue
+		}
+
+		locations = append(locations, location)
+	}
+
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("no version found in %s", projectDir)
+	}
+
+	return locations, nil
+}
+
+// Current combines the recorded versions, taking the highest marketing version and build number.
+func Current(locations []Location) Version {
+	var current Version
+	for _, location := range locations {
+		if utils.CompareVersions(location.Version.Name, current.Name) > 0 {
+			current.Name = location.Version.Name
+		}
+		if location.Version.Build > current.Build {
+			current.Build = location.Version.Build
+		}
+	}
+
+	return current
+}
+
+// Bump increments the given part (major, minor, patch or build) of the version.
+// Every bump also increments the build number, since the stores reject re-used build numbers.
+func Bump(current Version, part string) (Version, error) {
+	next := Version{Name: current.Name, Build: current.Build + 1}
+	if part == "build" {
+		return next, nil
+	}
+
+	parts := strings.Split(current.Name, ".")
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+	numbers := make([]int, 3)
+	for i := range numbers {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return Version{}, fmt.Errorf("version %q is not in X.Y.Z form", current.Name)
+		}
+		numbers[i] = n
+	}
+
+	switch part {
+	case "major":
+		numbers = []int{numbers[0] + 1, 0, 0}
+	case "minor":
+		numbers = []int{numbers[0], numbers[1] + 1, 0}
+	case "patch":
+		numbers = []int{numbers[0], numbers[1], numbers[2] + 1}
+	default:
+		return Version{}, fmt.Errorf("unknown version part %q, expected major, minor, patch or build", part)
+	}
+
+	next.Name = fmt.Sprintf("%d.%d.%d", numbers[0], numbers[1], numbers[2])
+	return next, nil
+}
+
+var semverRegexp = regexp.MustCompile(`^\d+\.\d+\.\d+$`)
+
+// Validate checks that a version name is in X.Y.Z form.
+func Validate(name string) error {
+	if !semverRegexp.MatchString(name) {
+		return fmt.Errorf("version %q is not in X.Y.Z form", name)
+	}
+	return nil
+}
+
+// Write records the version in every file of the project, keeping the rest of each file byte-for-byte intact.
+// It returns the files that changed.
+func Write(projectDir string, v Version) ([]string, error) {
+	var changed []string
+	for _, file := range versionFiles(projectDir) {
+		data, err := os.ReadFile(file.path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return changed, fmt.Errorf("failed to read %s: %v", file.path, err)
+		}
+
+		content := file.name.replace(string(data), v.Name)
+		if file.build != nil {
+			content = file.build.replace(content, strconv.Itoa(v.Build))
+		}
+		if content == string(data) {
+			continue
+		}
+
+		info, err := os.Stat(file.path)
+		if err != nil {
+			return changed, err
+		}
+		if err := os.WriteFile(file.path, []byte(content), info.Mode()); err != nil {
+			return changed, fmt.Errorf("failed to write %s: %v", file.path, err)
+		}
+		changed = append(changed, file.path)
+	}
+
+	return changed, nil
+}
+
+// Tag commits the changed files and tags the commit as v<name>.
+func Tag(projectDir string, v Version, files []string) error {
+	tag := "v" + v.Name
+	args := []string{"add", "--"}
+	for _, file := range files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		args = append(args, path)
+	}
+	if err := utils.RunCommandInDir(projectDir, nil, "git", args...); err != nil {
+		return fmt.Errorf("failed to stage version files: %v", err)
+	}
+	if err := utils.RunCommandInDir(projectDir, nil, "git", "commit", "-m", "Release "+v.String()); err != nil {
+		return fmt.Errorf("failed to commit version files: %v", err)
+	}
+	if err := utils.RunCommandInDir(projectDir, nil, "git", "tag", "-a", tag, "-m", "Release "+v.String()); err != nil {
+		return fmt.Errorf("failed to create tag %s: %v", tag, err)
+	}
+
+	return nil
+}
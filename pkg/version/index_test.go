@@ -0,0 +1,132 @@
+package version
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// copyProject copies the fixture project into a temporary directory, since Write edits it in place.
+func copyProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := filepath.Join("testdata", "project")
+	err := filepath.WalkDir(src, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if entry.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, rel), 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), data, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestRead(t *testing.T) {
+	locations, err := Read(filepath.Join("testdata", "project"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var files []string
+	for _, location := range locations {
+		files = append(files, filepath.Base(location.File))
+		if location.Version.Name != "1.4.2" {
+			t.Errorf("%s: version %q, want 1.4.2", location.File, location.Version.Name)
+		}
+		if location.HasBuild && location.Version.Build != 42 {
+			t.Errorf("%s: build %d, want 42", location.File, location.Version.Build)
+		}
+	}
+	if want := []string{"package.json", "build.gradle", "project.pbxproj", "Info.plist"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("Read() files = %q, want %q", files, want)
+	}
+	if current := Current(locations); current != (Version{Name: "1.4.2", Build: 42}) {
+		t.Errorf("Current() = %s, want 1.4.2 (42)", current)
+	}
+}
+
+func TestWriteGolden(t *testing.T) {
+	dir := copyProject(t)
+	changed, err := Write(dir, Version{Name: "1.5.0", Build: 43})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 4 {
+		t.Errorf("Write() changed %d files, want 4: %q", len(changed), changed)
+	}
+
+	for _, file := range []string{
+		"package.json",
+		"android/app/build.gradle",
+		"ios/HelloWorld.xcodeproj/project.pbxproj",
+		"ios/HelloWorld/Info.plist",
+	} {
+		t.Run(file, func(t *testing.T) {
+			got, err := os.ReadFile(filepath.Join(dir, file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", "golden", filepath.Base(file)+".golden")
+			if *update {
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("%s differs from %s, run go test -update to inspect", file, golden)
+			}
+		})
+	}
+
+	// Writing the same version again leaves every file as it is.
+	changed, err = Write(dir, Version{Name: "1.5.0", Build: 43})
+	if err != nil || len(changed) != 0 {
+		t.Errorf("second Write() = %q, %v, want no changes", changed, err)
+	}
+}
+
+func TestBump(t *testing.T) {
+	current := Version{Name: "1.4.2", Build: 42}
+	tests := map[string]Version{
+		"major": {Name: "2.0.0", Build: 43},
+		"minor": {Name: "1.5.0", Build: 43},
+		"patch": {Name: "1.4.3", Build: 43},
+		"build": {Name: "1.4.2", Build: 43},
+	}
+	for part, want := range tests {
+		if got, err := Bump(current, part); err != nil || got != want {
+			t.Errorf("Bump(%s) = %s, %v, want %s", part, got, err, want)
+		}
+	}
+
+	if got, err := Bump(Version{Name: "1.4", Build: 1}, "patch"); err != nil || got.Name != "1.4.1" {
+		t.Errorf("Bump(1.4, patch) = %s, %v, want 1.4.1", got, err)
+	}
+	if _, err := Bump(current, "micro"); err == nil || !strings.Contains(err.Error(), "unknown version part") {
+		t.Errorf("Bump(micro) error = %v", err)
+	}
+	if _, err := Bump(Version{Name: "1.x"}, "minor"); err == nil {
+		t.Error("Bump(1.x) succeeded, want an error")
+	}
+}
@@ -0,0 +1,304 @@
+package whitelabel
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/android"
+	"github.com/aman-apptile/bob/pkg/assets"
+	"github.com/aman-apptile/bob/pkg/ios"
+)
+
+var (
+	gradleApplicationIDRegexp = regexp.MustCompile(`(applicationId\s*=?\s*["'])([^"']*)(["'])`)
+	appNameRegexp             = regexp.MustCompile(`(<string name="app_name"[^>]*>)([^<]*)(</string>)`)
+	displayNameRegexp         = regexp.MustCompile(`(<key>CFBundleDisplayName</key>\s*<string>)([^<]*)(</string>)`)
+)
+
+// Apply rewrites the native projects in projectDir so that they build the given app.
+func Apply(projectDir string, app *App) error {
+	steps := []func(string, *App) error{
+		applyAndroidApplicationID,
+		applyAndroidAppName,
+		applyAndroidColors,
+		applyIosBundleID,
+		applyIosDisplayName,
+		applyEnv,
+		applyIcon,
+		applySplash,
+	}
+
+	for _, step := range steps {
+		if err := step(projectDir, app); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyAndroidApplicationID sets the applicationId in defaultConfig. Projects that leave it out, which AGP 8 then
+// takes from the namespace, get one added, rather than the applicationId of a flavor replaced.
+func applyAndroidApplicationID(projectDir string, app *App) error {
+	if app.ApplicationID == "" {
+		return nil
+	}
+
+	path := android.AppBuildGradle(projectDir)
+	return editFile(path, func(content string) (string, error) {
+		start, end, ok := android.FindGradleBlock(content, "defaultConfig")
+		if !ok {
+			return "", fmt.Errorf("no defaultConfig block found")
+		}
+
+		body := content[start:end]
+		if match := gradleApplicationIDRegexp.FindStringSubmatchIndex(body); match != nil {
+			return content[:start] + body[:match[4]] + app.ApplicationID + body[match[5]:] + content[end:], nil
+		}
+
+		assignment := `applicationId "%s"`
+		if strings.HasSuffix(path, ".kts") {
+			assignment = `applicationId = "%s"`
+		}
+		line := "\n" + blockIndent(body) + fmt.Sprintf(assignment, app.ApplicationID)
+		return content[:start] + line + content[start:], nil
+	})
+}
+
+// blockIndent returns the indentation of the first line of a block body.
+func blockIndent(body string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if trimmed := strings.TrimLeft(line, " \t"); trimmed != "" {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "    "
+}
+
+func applyAndroidAppName(projectDir string, app *App) error {
+	if app.Name == "" {
+		return nil
+	}
+
+	path := filepath.Join(projectDir, "android", "app", "src", "main", "res", "values", "strings.xml")
+	return editFile(path, func(content string) (string, error) {
+		if !appNameRegexp.MatchString(content) {
+			return "", fmt.Errorf("no app_name string found")
+		}
+		name := strings.ReplaceAll(xmlEscape(app.Name), "'", `\'`)
+		return appNameRegexp.ReplaceAllString(content, "${1}"+literal(name)+"${3}"), nil
+	})
+}
+
+func applyAndroidColors(projectDir string, app *App) error {
+	if len(app.Colors) == 0 {
+		return nil
+	}
+
+	path := filepath.Join(projectDir, "android", "app", "src", "main", "res", "values", "colors.xml")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.WriteFile(path, []byte("<resources>\n</resources>\n"), 0644); err != nil {
+			return fmt.Errorf("failed to create %s: %v", path, err)
+		}
+	}
+
+	names := make([]string, 0, len(app.Colors))
+	for name := range app.Colors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return editFile(path, func(content string) (string, error) {
+		for _, name := range names {
+			colorRegexp := regexp.MustCompile(`(<color name="` + regexp.QuoteMeta(name) + `"[^>]*>)([^<]*)(</color>)`)
+			if colorRegexp.MatchString(content) {
+				content = colorRegexp.ReplaceAllString(content, "${1}"+literal(xmlEscape(app.Colors[name]))+"${3}")
+				continue
+			}
+
+			end := strings.LastIndex(content, "</resources>")
+			if end < 0 {
+				return "", fmt.Errorf("no </resources> found")
+			}
+			color := fmt.Sprintf("    <color name=\"%s\">%s</color>\n", name, xmlEscape(app.Colors[name]))
+			content = content[:end] + color + content[end:]
+		}
+
+		return content, nil
+	})
+}
+
+// applyIosBundleID replaces the main target's bundle identifier, keeping the suffixes of extension targets
+// so that they stay prefixed by the app's identifier.
+func applyIosBundleID(projectDir string, app *App) error {
+	if app.BundleID == "" {
+		return nil
+	}
+
+	xcodeproj, err := ios.FindXcodeProject(filepath.Join(projectDir, "ios"))
+	if err != nil {
+		return nil
+	}
+	project, err := ios.LoadProject(xcodeproj)
+	if err != nil {
+		return err
+	}
+
+	original := project.BundleIdentifier("Release")
+	if original == "" || strings.HasPrefix(original, "$") {
+		return fmt.Errorf("cannot replace bundle identifier %q of %s", original, filepath.Base(xcodeproj))
+	}
+
+	bundleIDRegexp := regexp.MustCompile(`(PRODUCT_BUNDLE_IDENTIFIER = "?)` + regexp.QuoteMeta(original) + `((?:\.[^";]*)?"?;)`)
+	return editFile(filepath.Join(xcodeproj, "project.pbxproj"), func(content string) (string, error) {
+		return bundleIDRegexp.ReplaceAllString(content, "${1}"+literal(app.BundleID)+"${2}"), nil
+	})
+}
+
+func applyIosDisplayName(projectDir string, app *App) error {
+	if app.Name == "" {
+		return nil
+	}
+
+	iosDir := filepath.Join(projectDir, "ios")
+	xcodeproj, err := ios.FindXcodeProject(iosDir)
+	if err != nil {
+		return nil
+	}
+	project, err := ios.LoadProject(xcodeproj)
+	if err != nil {
+		return err
+	}
+
+	infoPlist := project.Setting(project.MainTarget(), "Release", "INFOPLIST_FILE")
+	if infoPlist == "" {
+		return nil
+	}
+	infoPlist = filepath.Join(iosDir, strings.ReplaceAll(infoPlist, "$(SRCROOT)/", ""))
+
+	return editFile(infoPlist, func(content string) (string, error) {
+		if displayNameRegexp.MatchString(content) {
+			return displayNameRegexp.ReplaceAllString(content, "${1}"+literal(xmlEscape(app.Name))+"${3}"), nil
+		}
+
+		start := strings.Index(content, "<dict>")
+		if start < 0 {
+			return "", fmt.Errorf("no <dict> found")
+		}
+		start += len("<dict>")
+		entry := "\n\t<key>CFBundleDisplayName</key>\n\t<string>" + xmlEscape(app.Name) + "</string>"
+		return content[:start] + entry + content[start:], nil
+	})
+}
+
+// applyEnv sets the app's variables in the project's .env files, which react-native-config exposes to JS and
+// native code. Every .env.<environment> file is updated too, so the app's values win whichever one a build selects.
+func applyEnv(projectDir string, app *App) error {
+	if len(app.Env) == 0 {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(projectDir, ".env.*"))
+	if err != nil {
+		return err
+	}
+	paths = append([]string{filepath.Join(projectDir, ".env")}, paths...)
+
+	for _, path := range paths {
+		if err := applyEnvFile(path, app.Env); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyEnvFile(path string, env map[string]string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		key, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), "export "), "=")
+		if _, overridden := env[strings.TrimSpace(key)]; !overridden && line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, key+"="+env[key])
+	}
+
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// applyIcon generates the launcher icons from the app's icon. The iconBackground color, white by default,
+// fills the adaptive icon background and transparent areas of iOS icons.
+func applyIcon(projectDir string, app *App) error {
+	if app.Icon == "" {
+		return nil
+	}
+
+	background, err := app.color("iconBackground")
+	if err != nil {
+		return err
+	}
+	_, err = assets.GenerateIcons(projectDir, app.Asset(app.Icon), assets.IconOptions{Background: background})
+	return err
+}
+
+// applySplash generates the splash screen from the app's splash logo on the splashBackground color.
+func applySplash(projectDir string, app *App) error {
+	if app.Splash == "" {
+		return nil
+	}
+
+	background, err := app.color("splashBackground")
+	if err != nil {
+		return err
+	}
+	_, err = assets.GenerateSplash(projectDir, app.Asset(app.Splash), assets.SplashOptions{Background: background})
+	return err
+}
+
+// editFile rewrites a file in place. Files that do not exist are left alone.
+func editFile(path string, edit func(string) (string, error)) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	content, err := edit(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %v", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), info.Mode())
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+// literal escapes a value for use in a regexp replacement template.
+func literal(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
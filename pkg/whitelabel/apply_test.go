@@ -0,0 +1,139 @@
+package whitelabel
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// globex is a white-label app whose name needs escaping in XML and in Android string resources.
+var globex = &App{
+	ID:            "globex",
+	Name:          "Globex & Co's",
+	BundleID:      "com.globex.shop",
+	ApplicationID: "com.globex.shop",
+	Colors:        map[string]string{"primary": "#1A73E8", "splashBackground": "#FFFFFF"},
+	Env:           map[string]string{"API_URL": "https://api.globex.com", "THEME": "light"},
+}
+
+// rewrittenFiles are the files of testdata/project that Apply changes, compared to the ones in testdata/golden.
+var rewrittenFiles = []string{
+	".env",
+	".env.staging",
+	"android/app/build.gradle",
+	"android/app/src/main/res/values/strings.xml",
+	"android/app/src/main/res/values/colors.xml",
+	"ios/HelloWorld/Info.plist",
+	"ios/HelloWorld.xcodeproj/project.pbxproj",
+}
+
+func TestPrepare(t *testing.T) {
+	project := filepath.Join("testdata", "project")
+	workDir, err := Prepare(project, filepath.Join(project, "build-output"), globex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(workDir) })
+
+	for _, file := range rewrittenFiles {
+		got, err := os.ReadFile(filepath.Join(workDir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(filepath.Join("testdata", "golden", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s =\n%s\nwant\n%s", file, got, want)
+		}
+	}
+
+	// The configured dist directory is left out, a directory that only happens to be called dist is not.
+	if _, err := os.Stat(filepath.Join(workDir, "build-output")); !os.IsNotExist(err) {
+		t.Errorf("the working copy has the dist directory build-output, want it skipped")
+	}
+	if _, err := os.Stat(filepath.Join(workDir, "dist", "android", "app.apk")); err != nil {
+		t.Errorf("the working copy misses dist/android/app.apk: %v", err)
+	}
+
+	original, err := os.ReadFile(filepath.Join(project, "android", "app", "build.gradle"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(original), `applicationId "com.acme.app"`) {
+		t.Error("Prepare() changed the project instead of the working copy")
+	}
+}
+
+func TestApplyAndroidApplicationID(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		script string
+		want   string
+	}{
+		{
+			name:   "in defaultConfig",
+			file:   "build.gradle",
+			script: "android {\n    defaultConfig {\n        applicationId 'com.acme.app'\n    }\n}\n",
+			want:   "android {\n    defaultConfig {\n        applicationId 'com.globex.shop'\n    }\n}\n",
+		},
+		{
+			// AGP 8 takes the application ID from the namespace, the one of the flavor must stay.
+			name: "only in a flavor",
+			file: "build.gradle",
+			script: "android {\n    namespace \"com.acme.app\"\n    defaultConfig {\n        versionCode 1\n    }\n" +
+				"    productFlavors {\n        staging {\n            applicationId \"com.acme.staging\"\n        }\n    }\n}\n",
+			want: "android {\n    namespace \"com.acme.app\"\n    defaultConfig {\n        applicationId \"com.globex.shop\"\n        versionCode 1\n    }\n" +
+				"    productFlavors {\n        staging {\n            applicationId \"com.acme.staging\"\n        }\n    }\n}\n",
+		},
+		{
+			name:   "Kotlin",
+			file:   "build.gradle.kts",
+			script: "android {\n\tnamespace = \"com.acme.app\"\n\tdefaultConfig {\n\t\tminSdk = 24\n\t}\n}\n",
+			want:   "android {\n\tnamespace = \"com.acme.app\"\n\tdefaultConfig {\n\t\tapplicationId = \"com.globex.shop\"\n\t\tminSdk = 24\n\t}\n}\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			path := filepath.Join(projectDir, "android", "app", test.file)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(test.script), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := applyAndroidApplicationID(projectDir, globex); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != test.want {
+				t.Errorf("build script =\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyAndroidApplicationIDWithoutDefaultConfig(t *testing.T) {
+	projectDir := t.TempDir()
+	path := filepath.Join(projectDir, "android", "app", "build.gradle")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("android {\n    // defaultConfig { applicationId \"com.acme.app\" }\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := applyAndroidApplicationID(projectDir, globex)
+	if err == nil || !strings.Contains(err.Error(), "no defaultConfig block found") {
+		t.Errorf("applyAndroidApplicationID() = %v, want an error about the missing defaultConfig", err)
+	}
+}
@@ -0,0 +1,105 @@
+package whitelabel
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aman-apptile/bob/pkg/assets"
+)
+
+// App is the configuration of one white-label customer app.
+type App struct {
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	BundleID      string            `json:"bundleId"`
+	ApplicationID string            `json:"applicationId"`
+	Icon          string            `json:"icon"`
+	Splash        string            `json:"splash"`
+	Colors        map[string]string `json:"colors"`
+	Env           map[string]string `json:"env"`
+	Signing       Signing           `json:"signing"`
+
+	// Dir is the directory the config was loaded from. Relative asset paths are resolved against it.
+	Dir string `json:"-"`
+}
+
+// Signing references the credentials an app is signed with. Secrets are referenced by environment variable name.
+type Signing struct {
+	Android struct {
+		StoreFile        string `json:"storeFile"`
+		KeyAlias         string `json:"keyAlias"`
+		StorePasswordEnv string `json:"storePasswordEnv"`
+		KeyPasswordEnv   string `json:"keyPasswordEnv"`
+	} `json:"android"`
+	Ios struct {
+		TeamID  string `json:"teamId"`
+		Profile string `json:"profile"`
+	} `json:"ios"`
+}
+
+// Load reads an app config. ref is either the path of a JSON file, or an app ID looked up in appsDir
+// as <appsDir>/<id>/app.json or <appsDir>/<id>.json.
+func Load(ref, appsDir string) (*App, error) {
+	candidates := []string{ref}
+	if !strings.HasSuffix(ref, ".json") {
+		candidates = []string{
+			filepath.Join(appsDir, ref, "app.json"),
+			filepath.Join(appsDir, ref+".json"),
+		}
+	}
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", path, err)
+		}
+
+		app := &App{}
+		if err := json.Unmarshal(data, app); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+		app.Dir = filepath.Dir(path)
+		if app.ID == "" {
+			app.ID = strings.TrimSuffix(filepath.Base(path), ".json")
+			if app.ID == "app" {
+				app.ID = filepath.Base(app.Dir)
+			}
+		}
+		if app.ApplicationID == "" {
+			app.ApplicationID = app.BundleID
+		}
+
+		return app, nil
+	}
+
+	return nil, fmt.Errorf("no config found for app %q in %s", ref, appsDir)
+}
+
+// Asset resolves a path from the app config relative to the config's directory.
+func (a *App) Asset(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(a.Dir, path)
+}
+
+// color parses one of the app's colors, defaulting to white when it is not set.
+func (a *App) color(name string) (color.NRGBA, error) {
+	value, ok := a.Colors[name]
+	if !ok {
+		return color.NRGBA{R: 255, G: 255, B: 255, A: 255}, nil
+	}
+
+	c, err := assets.ParseHexColor(value)
+	if err != nil {
+		return c, fmt.Errorf("colors.%s: %v", name, err)
+	}
+	return c, nil
+}
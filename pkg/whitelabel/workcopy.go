@@ -0,0 +1,117 @@
+package whitelabel
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// linkedDirs are too large to copy for every app and are not modified by the white-label config,
+// so the working copy links to the originals instead.
+var linkedDirs = map[string]bool{
+	"node_modules": true,
+	"ios/Pods":     true,
+}
+
+// skippedDirs hold build outputs and VCS data that the working copy does not need. The directory artifacts are
+// collected into is skipped too, see Prepare.
+var skippedDirs = map[string]bool{
+	".git":              true,
+	"android/.gradle":   true,
+	"android/build":     true,
+	"android/app/build": true,
+	"ios/build":         true,
+}
+
+// Prepare creates a temporary working copy of the project and applies the app config to it, leaving out distDir,
+// where the artifacts of earlier builds are collected. The caller is responsible for removing the returned directory.
+func Prepare(projectDir, distDir string, app *App) (string, error) {
+	source, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", err
+	}
+	dist, err := filepath.Abs(distDir)
+	if err != nil {
+		return "", err
+	}
+	dist, err = filepath.Rel(source, dist)
+	if err != nil {
+		return "", err
+	}
+
+	workDir, err := os.MkdirTemp("", "bob-"+app.ID+"-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create working copy: %v", err)
+	}
+
+	if err := copyProject(source, workDir, filepath.ToSlash(dist)); err != nil {
+		os.RemoveAll(workDir)
+		return "", err
+	}
+
+	if err := Apply(workDir, app); err != nil {
+		os.RemoveAll(workDir)
+		return "", err
+	}
+
+	return workDir, nil
+}
+
+// copyProject copies source to dest, skipping the directory dist, relative to source.
+func copyProject(source, dest, dist string) error {
+	return filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(source, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		slashRel := filepath.ToSlash(rel)
+
+		switch {
+		case rel == ".":
+			return nil
+		case (skippedDirs[slashRel] || slashRel == dist) && info.IsDir():
+			return filepath.SkipDir
+		case linkedDirs[slashRel] && info.IsDir():
+			if err := os.Symlink(path, target); err != nil {
+				return fmt.Errorf("failed to link %s: %v", rel, err)
+			}
+			return filepath.SkipDir
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(source, dest string, mode os.FileMode) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %v", source, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", dest, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %v", source, err)
+	}
+
+	return out.Close()
+}